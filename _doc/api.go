@@ -48,22 +48,74 @@ type successResponseData struct {
 	Data    interface{} `json:"data"`
 }
 
+type shareReq struct {
+	ExpiresIn  string `json:"expires_in" example:"2h"`
+	TrackHours int    `json:"track_hours" example:"1"`
+}
+
+type placeReq struct {
+	Name   string  `json:"name" example:"Gym"`
+	Lat    float64 `json:"lat" example:"23.8103"`
+	Lon    float64 `json:"lon" example:"90.4125"`
+	Radius float64 `json:"radius" example:"100"`
+}
+
+type accessPointReq struct {
+	BSSID string `json:"bssid" example:"c0:ff:ee:00:00:01"`
+	SSID  string `json:"ssid" example:"office"`
+}
+
+type waypointReq struct {
+	Username    string  `json:"username" example:"*"`
+	Device      string  `json:"device" example:""`
+	Description string  `json:"description" example:"HQ"`
+	Lat         float64 `json:"lat" example:"23.8103"`
+	Lon         float64 `json:"lon" example:"90.4125"`
+	Radius      int32   `json:"radius" example:"100"`
+}
+
+type commandReq struct {
+	Action        string                 `json:"action" enums:"reportLocation,setConfiguration,clearWaypoints,action"`
+	Configuration map[string]interface{} `json:"configuration"`
+	Content       string                 `json:"content"`
+	URL           string                 `json:"url"`
+}
+
+type preferenceReq struct {
+	TimeZone string `json:"time_zone" example:"Asia/Dhaka"`
+	Units    string `json:"units" example:"imperial"`
+	Clock    string `json:"clock" example:"12h"`
+	Language string `json:"language" example:"de"`
+}
+
 type pingReq struct {
-	Type  string  `json:"_type" validate:"required"`
-	Tst   int64   `json:"tst" validate:"required"`
-	Acc   int8    `json:"acc"`
-	Alt   int8    `json:"alt"`
-	Batt  int8    `json:"batt"`
-	Bs    int8    `json:"bs"`
-	Lat   float64 `json:"lat" validate:"required"`
-	Lon   float64 `json:"lon" validate:"required"`
-	M     int8    `json:"m"`
-	T     string  `json:"t"`
-	Tid   string  `json:"tid"`
-	Vac   int8    `json:"vac"`
-	Vel   int8    `json:"vel"`
-	Bssid string  `json:"BSSID"`
-	Ssid  string  `json:"SSID"`
+	Type             string   `json:"_type" validate:"required"`
+	ID               string   `json:"_id"`
+	Tst              int64    `json:"tst" validate:"required"`
+	CreatedAt        int64    `json:"created_at"`
+	Acc              int8     `json:"acc"`
+	Alt              int8     `json:"alt"`
+	Batt             int8     `json:"batt"`
+	Bs               int8     `json:"bs"`
+	Lat              float64  `json:"lat" validate:"required"`
+	Lon              float64  `json:"lon" validate:"required"`
+	M                int8     `json:"m"`
+	T                string   `json:"t"`
+	Tid              string   `json:"tid"`
+	Vac              int8     `json:"vac"`
+	Vel              int8     `json:"vel"`
+	Cog              int16    `json:"cog"`
+	Rad              int32    `json:"rad"`
+	P                float64  `json:"p"`
+	Poi              string   `json:"poi"`
+	Tag              string   `json:"tag"`
+	Conn             string   `json:"conn" enums:"w,m,o"`
+	Topic            string   `json:"topic"`
+	InRegions        []string `json:"inregions"`
+	InRids           []string `json:"inrids"`
+	MotionActivities []string `json:"motionactivities"`
+	Bssid            string   `json:"BSSID"`
+	Ssid             string   `json:"SSID"`
 }
 
 // Root will let you know, whoami
@@ -86,9 +138,38 @@ func Root() {}
 // @Router /health [get]
 func Health() {}
 
+// Live
+// @Summary Service Liveness
+// @Description answers as long as the process serves requests
+// @Tags system
+// @Produce	json
+// @Success	200	{object} HealthResponse
+// @Router /health/live [get]
+func Live() {}
+
+// Ready
+// @Summary Service Readiness
+// @Description fails while a critical component(database, schema, ingest queue) is down
+// @Tags system
+// @Produce	json
+// @Success	200	{object} HealthResponse
+// @Failure	503	{object} failedResponse
+// @Router /health/ready [get]
+func Ready() {}
+
+// HealthDetails
+// @Summary Detailed Service Health
+// @Description state of every component, DB latency, schema version, queue depth, last ingest time
+// @Tags system
+// @Produce	json
+// @Success	200	{object} usecase.HealthReport
+// @Failure	503	{object} usecase.HealthReport
+// @Router /health/details [get]
+func HealthDetails() {}
+
 // Ping
 // @Summary Ping Location
-// @Description store location ping from mobile app
+// @Description store location ping from mobile app, responds with the commands for the device
 // @Tags location
 // @Param x-limit-u header string true "{username}"
 // @Param x-limit-d header string true "{device}"
@@ -96,12 +177,29 @@ func Health() {}
 // @Accept json
 // @Param payload body pingReq false "Ping Payload [Details Here](https://owntracks.org/booklet/tech/http/)"
 // @Produce	json
-// @Success	200	{object} []string{}
+// @Success	200	{object} []model.WaypointsCommand{}
 // @Failure	400 {object} badReqResponse
 // @Failure	500	{object} failedResponse
 // @Router /api/v1/ping [post]
 func Ping() {}
 
+// PingBatch
+// @Summary Batch Ping Location
+// @Description store queued location pings, body is a JSON array or newline delimited JSON of messages
+// @Tags location
+// @Param x-limit-u header string true "{username}"
+// @Param x-limit-d header string true "{device}"
+// @Param X-Real-ID header string true "{client_ip}"
+// @Accept json
+// @Accept application/x-ndjson
+// @Param payload body []pingReq false "Ping Payloads"
+// @Produce	json
+// @Success	200	{object} http.BatchPingResponse
+// @Failure	400,422 {object} badReqResponse
+// @Failure	500	{object} failedResponse
+// @Router /api/v1/ping/batch [post]
+func PingBatch() {}
+
 // LastLocation
 // @Summary last location
 // @Description User last ping location
@@ -112,6 +210,463 @@ func Ping() {}
 // @Router /api/v1/last-location [get]
 func LastLocation() {}
 
+// LastLocations
+// @Summary Last Locations
+// @Description last location of every device, of one user when username is set
+// @Tags location
+// @Param username query string false "username"
+// @Produce	json
+// @Success	200	{object} []model.LocationDetails
+// @Failure	404,500	{object} failedResponse
+// @Router /api/v1/last-locations [get]
+func LastLocations() {}
+
+// Users
+// @Summary Users
+// @Description every user with a location
+// @Tags location
+// @Produce	json
+// @Success	200	{object} []string
+// @Failure	500	{object} failedResponse
+// @Router /api/v1/users [get]
+func Users() {}
+
+// Devices
+// @Summary User Devices
+// @Description devices of a user with the unix time of their last location
+// @Tags location
+// @Param user path string true "username"
+// @Produce	json
+// @Success	200	{object} []model.Device
+// @Failure	404,500	{object} failedResponse
+// @Router /api/v1/users/{user}/devices [get]
+func Devices() {}
+
+// History
+// @Summary Location History
+// @Description track of a user ordered by time, default range is the last 24 hours
+// @Tags location
+// @Param username query string true "username"
+// @Param device query string false "device, all devices when empty"
+// @Param from query int false "unix seconds"
+// @Param to query int false "unix seconds"
+// @Param limit query int false "max points, default 5000, max 50000"
+// @Produce	json
+// @Success	200	{object} []model.TrackPoint
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/history [get]
+func History() {}
+
+// BatteryHistory
+// @Summary Battery History
+// @Description battery levels & status per device & time bucket, empty buckets are left out
+// @Tags location
+// @Param username query string true "username"
+// @Param device query string false "device, all devices when empty"
+// @Param from query int false "unix seconds"
+// @Param to query int false "unix seconds"
+// @Param bucket query string false "duration from 1m to 24h, default 15m"
+// @Produce	json
+// @Success	200	{object} []model.BatteryBucket
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/history/battery [get]
+func BatteryHistory() {}
+
+// NetworkHistory
+// @Summary Network History
+// @Description wifi & IP per device, time bucket & network with the first and last time seen
+// @Tags location
+// @Param username query string true "username"
+// @Param device query string false "device, all devices when empty"
+// @Param from query int false "unix seconds"
+// @Param to query int false "unix seconds"
+// @Param bucket query string false "duration from 1m to 24h, default 15m"
+// @Produce	json
+// @Success	200	{object} []model.NetworkBucket
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/history/network [get]
+func NetworkHistory() {}
+
+// Trips
+// @Summary Trips
+// @Description movements of a user between stays, same params as history
+// @Tags location
+// @Param username query string true "username"
+// @Param device query string false "device, all devices when empty"
+// @Param from query int false "unix seconds"
+// @Param to query int false "unix seconds"
+// @Produce	json
+// @Success	200	{object} []model.Trip
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/trips [get]
+func Trips() {}
+
+// Stays
+// @Summary Stays
+// @Description places a user stayed within 100 meters for at least 5 minutes, same params as history
+// @Tags location
+// @Param username query string true "username"
+// @Param device query string false "device, all devices when empty"
+// @Param from query int false "unix seconds"
+// @Param to query int false "unix seconds"
+// @Produce	json
+// @Success	200	{object} []model.Stay
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/stays [get]
+func Stays() {}
+
+// Within
+// @Summary Locations Within
+// @Description locations of every user inside a bounding box, ordered by time
+// @Tags location
+// @Param bbox query string true "minLon,minLat,maxLon,maxLat"
+// @Param username query string false "username, every user when empty"
+// @Param device query string false "device"
+// @Param from query int false "unix seconds"
+// @Param to query int false "unix seconds"
+// @Param limit query int false "default 5000, max 50000"
+// @Produce	json
+// @Success	200	{object} []model.SpatialPoint
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/locations/within [get]
+func Within() {}
+
+// Nearby
+// @Summary Locations Nearby
+// @Description locations of every user within radius meters of a coordinate, ordered by time
+// @Tags location
+// @Param lat query number true "latitude"
+// @Param lon query number true "longitude"
+// @Param radius query number true "meters, max 50000"
+// @Param username query string false "username, every user when empty"
+// @Param device query string false "device"
+// @Param from query int false "unix seconds"
+// @Param to query int false "unix seconds"
+// @Param limit query int false "default 5000, max 50000"
+// @Produce	json
+// @Success	200	{object} []model.SpatialPoint
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/locations/nearby [get]
+func Nearby() {}
+
+// Heatmap
+// @Summary Location Heatmap
+// @Description number of locations of a user per geohash cell, lat & lon are the average of the cell
+// @Tags location
+// @Param username query string true "username"
+// @Param device query string false "device"
+// @Param precision query int false "geohash length of the cells 1 to 9, default 7(about 150 x 150 meters)"
+// @Param from query int false "unix seconds"
+// @Param to query int false "unix seconds"
+// @Produce	json
+// @Success	200	{object} []model.HeatmapCell
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/heatmap [get]
+func Heatmap() {}
+
+// Tile
+// @Summary Location Vector Tile
+// @Description Mapbox Vector Tile of the locations inside a tile, layer points has a feature per location,
+// @Description layer tracks a line per device, broken by gaps over 30 minutes
+// @Tags location
+// @Param z path int true "zoom 0 to 22"
+// @Param x path int true "tile column"
+// @Param y path string true "tile row with the .mvt extension"
+// @Param username query string false "username, every user when empty"
+// @Param device query string false "device"
+// @Param from query int false "unix seconds"
+// @Param to query int false "unix seconds"
+// @Param limit query int false "default 5000, max 50000"
+// @Produce	application/vnd.mapbox-vector-tile
+// @Success	200	{file} binary
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/tiles/{z}/{x}/{y}.mvt [get]
+func Tile() {}
+
+// Stream
+// @Summary Live Location Stream
+// @Description Server-Sent Events of every accepted location the viewer may see, event name is location
+// @Tags location
+// @Param x-limit-u header string false "{viewer}, required when stream access rules are configured"
+// @Produce	text/event-stream
+// @Success	200	{object} model.LocationDetails
+// @Failure	401	{object} failedResponse
+// @Router /api/v1/stream [get]
+func Stream() {}
+
+// StreamWS
+// @Summary Live Location WebSocket
+// @Description WebSocket equivalent of the stream, one JSON text message per location
+// @Tags location
+// @Param x-limit-u header string false "{viewer}, required when stream access rules are configured"
+// @Success	101	{object} model.LocationDetails
+// @Failure	401,403	{object} failedResponse
+// @Router /api/v1/stream/ws [get]
+func StreamWS() {}
+
+// CreateShare
+// @Summary Create Share Link
+// @Description public link to the live location of the caller, optionally with the track of the last track_hours
+// @Tags share
+// @Param x-limit-u header string true "{username}"
+// @Accept json
+// @Param payload body shareReq true "expires_in is a duration up to 168h, track_hours 0 to 168"
+// @Produce	json
+// @Success	201	{object} successResponseData
+// @Failure	400,422,500	{object} failedResponse
+// @Router /api/v1/shares [post]
+func CreateShare() {}
+
+// ListShares
+// @Summary List Share Links
+// @Description share links of the caller, newest first, with view counts
+// @Tags share
+// @Param x-limit-u header string true "{username}"
+// @Produce	json
+// @Success	200	{object} []model.Share
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/shares [get]
+func ListShares() {}
+
+// RevokeShare
+// @Summary Revoke Share Link
+// @Description end a share link of the caller before it expires
+// @Tags share
+// @Param x-limit-u header string true "{username}"
+// @Param id path int true "share id"
+// @Produce	json
+// @Success	200	{object} successResponse
+// @Failure	400,404,500	{object} failedResponse
+// @Router /api/v1/shares/{id} [delete]
+func RevokeShare() {}
+
+// SharedLocation
+// @Summary Shared Location
+// @Description last location & track of a share link, no login needed, only position, accuracy, time & battery
+// @Tags share
+// @Param token path string true "share token"
+// @Produce	json
+// @Success	200	{object} model.SharedLocation
+// @Failure	404,410,500	{object} failedResponse
+// @Router /s/{token}/location [get]
+func SharedLocation() {}
+
+// DeviceStatus
+// @Summary Device Status
+// @Description reporting cadence, battery & connectivity of every device with its alerts
+// @Tags device
+// @Param username query string false "username"
+// @Produce	json
+// @Success	200	{object} []model.DeviceStatus
+// @Failure	404,500	{object} failedResponse
+// @Router /api/v1/devices/status [get]
+func DeviceStatus() {}
+
+// ListPlaces
+// @Summary List Places
+// @Description places of the caller, pinned first then the most stayed, kind is home, work or empty
+// @Tags place
+// @Param x-limit-u header string true "{username}"
+// @Produce	json
+// @Success	200	{object} successResponseData
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/places [get]
+func ListPlaces() {}
+
+// CreatePlace
+// @Summary Create Place
+// @Description pin a named place of the caller, radius 10 to 1000 meters, 100 by default
+// @Tags place
+// @Param x-limit-u header string true "{username}"
+// @Accept json
+// @Param payload body placeReq true "Place"
+// @Produce	json
+// @Success	201	{object} successResponseData
+// @Failure	400,422,500	{object} failedResponse
+// @Router /api/v1/places [post]
+func CreatePlace() {}
+
+// RefreshPlaces
+// @Summary Refresh Places
+// @Description cluster the stays of the caller into places & infer home and work, pinned places are kept
+// @Tags place
+// @Param x-limit-u header string true "{username}"
+// @Param days query int false "days of history, 1 to 365, 30 by default"
+// @Produce	json
+// @Success	200	{object} successResponseData
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/places/refresh [post]
+func RefreshPlaces() {}
+
+// UpdatePlace
+// @Summary Update Place
+// @Description name, resize or pin a place of the caller, naming pins it
+// @Tags place
+// @Param x-limit-u header string true "{username}"
+// @Param id path int true "place id"
+// @Accept json
+// @Param payload body model.PlaceUpdate true "changes, missing fields are kept"
+// @Produce	json
+// @Success	200	{object} successResponseData
+// @Failure	400,404,422,500	{object} failedResponse
+// @Router /api/v1/places/{id} [patch]
+func UpdatePlace() {}
+
+// DeletePlace
+// @Summary Delete Place
+// @Description remove a place of the caller, an unpinned one comes back on refresh
+// @Tags place
+// @Param x-limit-u header string true "{username}"
+// @Param id path int true "place id"
+// @Produce	json
+// @Success	200	{object} successResponse
+// @Failure	400,404,500	{object} failedResponse
+// @Router /api/v1/places/{id} [delete]
+func DeletePlace() {}
+
+// ListAccessPoints
+// @Summary List Access Points
+// @Description wifi access points of the caller mapped to its places
+// @Tags place
+// @Param x-limit-u header string true "{username}"
+// @Produce	json
+// @Success	200	{object} successResponseData
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/places/access-points [get]
+func ListAccessPoints() {}
+
+// AddAccessPoint
+// @Summary Add Access Point
+// @Description map a wifi access point to a place of the caller & pin it, locations connected to it are at the place
+// @Tags place
+// @Param x-limit-u header string true "{username}"
+// @Param id path int true "place id"
+// @Accept json
+// @Param payload body accessPointReq true "Access Point"
+// @Produce	json
+// @Success	201	{object} successResponseData
+// @Failure	400,404,422,500	{object} failedResponse
+// @Router /api/v1/places/{id}/access-points [post]
+func AddAccessPoint() {}
+
+// DeleteAccessPoint
+// @Summary Delete Access Point
+// @Description remove a wifi access point of the caller, its place is kept
+// @Tags place
+// @Param x-limit-u header string true "{username}"
+// @Param id path int true "access point id"
+// @Produce	json
+// @Success	200	{object} successResponse
+// @Failure	400,404,500	{object} failedResponse
+// @Router /api/v1/places/access-points/{id} [delete]
+func DeleteAccessPoint() {}
+
+// ListWaypoints
+// @Summary List Waypoints
+// @Description waypoints of the caller & of the whole fleet, admins get every waypoint or those of username
+// @Tags waypoint
+// @Param x-limit-u header string true "{username}"
+// @Param username query string false "username, admins only"
+// @Param device query string false "device, its own waypoints & those of every device"
+// @Produce	json
+// @Success	200	{object} successResponseData
+// @Failure	400,403,500	{object} failedResponse
+// @Router /api/v1/waypoints [get]
+func ListWaypoints() {}
+
+// CreateWaypoint
+// @Summary Create Waypoint
+// @Description define a waypoint pushed to devices, of every device when device is empty, admins may set username to
+// @Description any user or * for the whole fleet; radius in meters, 100 by default
+// @Tags waypoint
+// @Param x-limit-u header string true "{username}"
+// @Accept json
+// @Param payload body waypointReq true "Waypoint"
+// @Produce	json
+// @Success	201	{object} successResponseData
+// @Failure	400,403,422,500	{object} failedResponse
+// @Router /api/v1/waypoints [post]
+func CreateWaypoint() {}
+
+// UpdateWaypoint
+// @Summary Update Waypoint
+// @Description move, resize or rename a waypoint the caller manages, devices get it on their next ping
+// @Tags waypoint
+// @Param x-limit-u header string true "{username}"
+// @Param id path int true "waypoint id"
+// @Accept json
+// @Param payload body model.WaypointUpdate true "changes, missing fields are kept"
+// @Produce	json
+// @Success	200	{object} successResponseData
+// @Failure	400,404,422,500	{object} failedResponse
+// @Router /api/v1/waypoints/{id} [patch]
+func UpdateWaypoint() {}
+
+// DeleteWaypoint
+// @Summary Delete Waypoint
+// @Description remove a waypoint the caller manages, devices that got it keep it
+// @Tags waypoint
+// @Param x-limit-u header string true "{username}"
+// @Param id path int true "waypoint id"
+// @Produce	json
+// @Success	200	{object} successResponse
+// @Failure	400,404,500	{object} failedResponse
+// @Router /api/v1/waypoints/{id} [delete]
+func DeleteWaypoint() {}
+
+// ListDeviceCommands
+// @Summary List Device Commands
+// @Description the last 100 commands queued for a device, newest first, delivered_at is 0 while pending
+// @Tags device
+// @Param x-limit-u header string true "{username}, an admin"
+// @Param id path string true "device"
+// @Param username query string true "username"
+// @Produce	json
+// @Success	200	{object} successResponseData
+// @Failure	400,403,500	{object} failedResponse
+// @Router /api/v1/devices/{id}/commands [get]
+func ListDeviceCommands() {}
+
+// EnqueueDeviceCommand
+// @Summary Queue Device Command
+// @Description queue an OwnTracks cmd for a device, returned in the response of its next ping,
+// @Description marked delivered once a later ping confirms it
+// @Tags device
+// @Param x-limit-u header string true "{username}, an admin"
+// @Param id path string true "device"
+// @Param username query string true "username"
+// @Accept json
+// @Param payload body commandReq true "Command [Details Here](https://owntracks.org/booklet/tech/json/#_typecmd)"
+// @Produce	json
+// @Success	201	{object} successResponseData
+// @Failure	400,403,500	{object} failedResponse
+// @Router /api/v1/devices/{id}/commands [post]
+func EnqueueDeviceCommand() {}
+
+// GetPreferences
+// @Summary Get Preferences
+// @Description time zone, units, clock & language the locations of the caller are shown with
+// @Tags preference
+// @Param x-limit-u header string true "{username}"
+// @Produce	json
+// @Success	200	{object} model.Preference
+// @Failure	400,500	{object} failedResponse
+// @Router /api/v1/preferences [get]
+func GetPreferences() {}
+
+// UpdatePreferences
+// @Summary Update Preferences
+// @Description change preferences of the caller, missing fields are kept & empty ones reset to the default
+// @Tags preference
+// @Param x-limit-u header string true "{username}"
+// @Accept json
+// @Param payload body preferenceReq true "Preferences"
+// @Produce	json
+// @Success	200	{object} model.Preference
+// @Failure	400,422,500	{object} failedResponse
+// @Router /api/v1/preferences [patch]
+func UpdatePreferences() {}
+
 // TelegramHook
 // @Summary Telegram Hook
 // @Description get user last location in telegram via bot
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CommandHandler represent the http handler for device commands
+type CommandHandler struct {
+	CUseCase model.CommandUsecase
+}
+
+func NewCommandHandler(e *echo.Echo, us model.CommandUsecase) {
+	handler := &CommandHandler{
+		CUseCase: us,
+	}
+
+	// admins are told apart by the caller, the authenticated user
+	v1 := e.Group("/api/v1", middlewares.AuthenticatedCaller)
+	// id is the device, of ?username=
+	v1.GET("/devices/:id/commands", handler.List)
+	v1.POST("/devices/:id/commands", handler.Enqueue)
+}
+
+// List returns the commands queued for the device, newest first
+func (h *CommandHandler) List(c echo.Context) error {
+	caller := c.Request().Header.Get("x-limit-u")
+	if caller == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	commands, err := h.CUseCase.List(c.Request().Context(), caller, c.QueryParam("username"), c.Param("id"))
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", commands))
+}
+
+// Enqueue queues a command like {"action":"reportLocation"} for the device, sent in the response of its next ping
+func (h *CommandHandler) Enqueue(c echo.Context) error {
+	caller := c.Request().Header.Get("x-limit-u")
+	if caller == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+	}
+
+	command := &model.DeviceCommand{
+		Username: c.QueryParam("username"),
+		Device:   c.Param("id"),
+		Payload:  body,
+	}
+
+	if err := h.CUseCase.Enqueue(c.Request().Context(), caller, command); err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	_, res := response.RespondSuccess("command queued", command)
+
+	return c.JSON(http.StatusCreated, res)
+}
@@ -0,0 +1,109 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	cHttp "ot-recorder/app/command/delivery/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func buildRequest(method, path, body, username string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	if username != "" {
+		req.Header.Set("x-limit-u", username)
+	}
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("phone")
+
+	return c, rec
+}
+
+func TestList(t *testing.T) {
+	mockUsecase := new(mocks.CommandUsecase)
+	mockUsecase.On("List", mock.Anything, "admin", "dev", "phone").Return([]model.DeviceCommand{
+		{ID: 2, Username: "dev", Device: "phone", Action: model.CommandReportLocation,
+			Payload: json.RawMessage(`{"_type":"cmd","action":"reportLocation"}`), CreatedBy: "admin"},
+	}, nil).Once()
+
+	handler := cHttp.CommandHandler{CUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.GET, "/api/v1/devices/phone/commands?username=dev", "", "admin")
+	assert.NoError(t, handler.List(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `{"id":2,"username":"dev","device":"phone","action":"reportLocation",`+
+		`"payload":{"_type":"cmd","action":"reportLocation"},"created_by":"admin"`)
+
+	c, rec = buildRequest(echo.GET, "/api/v1/devices/phone/commands?username=dev", "", "")
+	assert.NoError(t, handler.List(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestEnqueue(t *testing.T) {
+	body := `{"action":"reportLocation"}`
+
+	mockUsecase := new(mocks.CommandUsecase)
+	mockUsecase.On("Enqueue", mock.Anything, "admin", mock.MatchedBy(func(c *model.DeviceCommand) bool {
+		return c.Username == "dev" && c.Device == "phone" && string(c.Payload) == body
+	})).Run(func(args mock.Arguments) {
+		args.Get(2).(*model.DeviceCommand).ID = 3
+	}).Return(nil).Once()
+	mockUsecase.On("Enqueue", mock.Anything, "dev", mock.Anything).
+		Return(response.WrapError(response.ErrForbidden, http.StatusForbidden)).Once()
+
+	handler := cHttp.CommandHandler{CUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.POST, "/api/v1/devices/phone/commands?username=dev", body, "admin")
+	assert.NoError(t, handler.Enqueue(c))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":3`)
+
+	c, rec = buildRequest(echo.POST, "/api/v1/devices/phone/commands?username=dev", body, "dev")
+	assert.NoError(t, handler.Enqueue(c))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestSpoofedCaller(t *testing.T) {
+	mockUsecase := new(mocks.CommandUsecase)
+	mockUsecase.On("List", mock.Anything, "admin", "dev", "phone").Return([]model.DeviceCommand{}, nil).Once()
+
+	e := echo.New()
+	cHttp.NewCommandHandler(e, mockUsecase)
+
+	// a user authenticated by the proxy claims to be an admin
+	req := httptest.NewRequest(echo.POST, "/api/v1/devices/phone/commands?username=dev",
+		strings.NewReader(`{"action":"setConfiguration","configuration":{"locatorInterval":1}}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("x-limit-u", "admin")
+	req.SetBasicAuth("dev", "secret")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "x-limit-u isn't the authenticated user")
+
+	// without x-limit-u the authenticated user is the caller
+	req = httptest.NewRequest(echo.GET, "/api/v1/devices/phone/commands?username=dev", nil)
+	req.SetBasicAuth("admin", "secret")
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockUsecase.AssertNotCalled(t, "Enqueue", mock.Anything, mock.Anything, mock.Anything)
+	mockUsecase.AssertExpectations(t)
+}
@@ -0,0 +1,146 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/metrics"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//nolint:gochecknoglobals
+var (
+	tracer         = otel.Tracer("ot-recorder/app/command/repository/pgsql")
+	spanAttributes = trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBSQLTableKey.String("device_commands"))
+)
+
+type commandRepository struct {
+	db *sql.DB
+}
+
+func NewPgsqlCommandRepository(db *sql.DB) model.CommandRepository {
+	return &commandRepository{
+		db: db,
+	}
+}
+
+const createCommand = `INSERT INTO device_commands (
+  username, device, action, payload, created_by, created_at, delivered_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+func (r *commandRepository) CreateCommand(ctx context.Context, command *model.DeviceCommand) error {
+	defer metrics.ObserveDBQuery("command", "CreateCommand", time.Now())
+
+	ctx, span := tracer.Start(ctx, "commandRepository.CreateCommand", spanAttributes)
+	defer span.End()
+
+	return r.db.QueryRowContext(ctx, createCommand, command.Username, command.Device, command.Action,
+		string(command.Payload), command.CreatedBy, command.CreatedAt, command.DeliveredAt).Scan(&command.ID)
+}
+
+const commandSelectColumns = `id, username, device, action, payload, created_by, created_at, delivered_at`
+
+const getCommands = `SELECT ` + commandSelectColumns + ` FROM device_commands
+WHERE username = $1 AND device = $2 ORDER BY id DESC LIMIT $3`
+
+// GetCommands returns the newest commands of the device first, delivered or not
+func (r *commandRepository) GetCommands(
+	ctx context.Context,
+	username, device string,
+	limit int,
+) ([]model.DeviceCommand, error) {
+	defer metrics.ObserveDBQuery("command", "GetCommands", time.Now())
+
+	ctx, span := tracer.Start(ctx, "commandRepository.GetCommands", spanAttributes)
+	defer span.End()
+
+	return queryCommands(ctx, r.db, getCommands, username, device, limit)
+}
+
+const (
+	getPendingCommands = `SELECT ` + commandSelectColumns + ` FROM device_commands
+WHERE username = $1 AND device = $2 AND delivered_at = 0 AND created_at >= $3 ORDER BY id`
+	deliverCommand = `UPDATE device_commands SET delivered_at = $1 WHERE id = $2 AND delivered_at = 0`
+)
+
+// GetPendingCommands returns the pending commands of the device created since oldest first
+func (r *commandRepository) GetPendingCommands(
+	ctx context.Context,
+	username, device string,
+	since int64,
+) ([]model.DeviceCommand, error) {
+	defer metrics.ObserveDBQuery("command", "GetPendingCommands", time.Now())
+
+	ctx, span := tracer.Start(ctx, "commandRepository.GetPendingCommands", spanAttributes)
+	defer span.End()
+
+	return queryCommands(ctx, r.db, getPendingCommands, username, device, since)
+}
+
+// DeliverCommands marks the commands delivered, those delivered already keep their time
+func (r *commandRepository) DeliverCommands(ctx context.Context, ids []int64, deliveredAt int64) error {
+	defer metrics.ObserveDBQuery("command", "DeliverCommands", time.Now())
+
+	ctx, span := tracer.Start(ctx, "commandRepository.DeliverCommands", spanAttributes)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, deliverCommand, deliveredAt, id); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// querier a DB or a transaction
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func queryCommands(ctx context.Context, q querier, query string, args ...interface{}) ([]model.DeviceCommand, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	commands := []model.DeviceCommand{}
+
+	for rows.Next() {
+		var (
+			c       model.DeviceCommand
+			payload []byte
+		)
+
+		err := rows.Scan(
+			&c.ID,
+			&c.Username,
+			&c.Device,
+			&c.Action,
+			&payload,
+			&c.CreatedBy,
+			&c.CreatedAt,
+			&c.DeliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Payload = payload
+		commands = append(commands, c)
+	}
+
+	return commands, rows.Err()
+}
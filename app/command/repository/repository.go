@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"database/sql"
+	"ot-recorder/app/command/repository/mysql"
+	"ot-recorder/app/command/repository/pgsql"
+	"ot-recorder/app/command/repository/sqlite"
+	"ot-recorder/app/model"
+)
+
+// NewCommandRepository returns the device command repository for the given database type
+func NewCommandRepository(dbType string, dbClient *sql.DB) model.CommandRepository {
+	switch dbType {
+	case "postgres":
+		return pgsql.NewPgsqlCommandRepository(dbClient)
+	case "mysql":
+		return mysql.NewMysqlCommandRepository(dbClient)
+	default:
+		return sqlite.NewSqliteCommandRepository(dbClient)
+	}
+}
@@ -0,0 +1,59 @@
+package sqlite_test
+
+import (
+	"context"
+	"encoding/json"
+	commandRepo "ot-recorder/app/command/repository/sqlite"
+	"ot-recorder/app/model"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	payload := `{"_type":"cmd","action":"reportLocation"}`
+	command := &model.DeviceCommand{Username: "dev", Device: "phone", Action: model.CommandReportLocation,
+		Payload: json.RawMessage(payload), CreatedBy: "admin", CreatedAt: 1000}
+
+	mock.ExpectExec("INSERT INTO device_commands").
+		WithArgs("dev", "phone", "reportLocation", payload, "admin", int64(1000), int64(0)).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+
+	columns := []string{"id", "username", "device", "action", "payload", "created_by", "created_at", "delivered_at"}
+	mock.ExpectQuery("FROM device_commands WHERE username = \\? AND device = \\? ORDER BY id DESC LIMIT \\?").
+		WithArgs("dev", "phone", 50).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(4, "dev", "phone", "reportLocation", payload, "admin", 1000, 0))
+
+	mock.ExpectQuery("FROM device_commands WHERE username = \\? AND device = \\? AND delivered_at = 0").
+		WithArgs("dev", "phone", int64(900)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(4, "dev", "phone", "reportLocation", payload, "admin", 1000, 0))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE device_commands SET delivered_at").WithArgs(int64(1100), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE device_commands SET delivered_at").WithArgs(int64(1100), int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	cr := commandRepo.NewSqliteCommandRepository(db)
+	assert.NoError(t, cr.CreateCommand(context.TODO(), command))
+	assert.Equal(t, int64(4), command.ID)
+
+	commands, err := cr.GetCommands(context.TODO(), "dev", "phone", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.DeviceCommand{*command}, commands)
+
+	commands, err = cr.GetPendingCommands(context.TODO(), "dev", "phone", 900)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.DeviceCommand{*command}, commands)
+
+	assert.NoError(t, cr.DeliverCommands(context.TODO(), []int64{4, 5}, 1100))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
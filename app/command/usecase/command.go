@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	listLimit  = 100
+	maxNameLen = 20
+)
+
+var tracer = otel.Tracer("ot-recorder/app/command/usecase") //nolint:gochecknoglobals
+
+//nolint:gochecknoglobals
+var errInternal = response.WrapError(
+	errors.New("internal server error, please report to admin"),
+	http.StatusInternalServerError,
+)
+
+//nolint:gochecknoglobals
+var errNotAdmin = response.WrapError(errors.New("only admins may manage device commands"), http.StatusForbidden)
+
+type commandUsecase struct {
+	repo           model.CommandRepository
+	admins         map[string]bool
+	cfg            config.CommandsConfig
+	contextTimeout time.Duration
+
+	mu   sync.Mutex
+	sent map[string]sentCommands
+}
+
+// sentCommands the commands sent in the response to the message of tst
+type sentCommands struct {
+	ids []int64
+	tst int64
+}
+
+func NewCommandUsecase(
+	repo model.CommandRepository,
+	cfg config.CommandsConfig,
+	timeout time.Duration,
+) model.CommandUsecase {
+	u := &commandUsecase{
+		repo:           repo,
+		admins:         map[string]bool{},
+		cfg:            cfg,
+		contextTimeout: timeout,
+		sent:           map[string]sentCommands{},
+	}
+
+	for _, admin := range cfg.Admins {
+		u.admins[admin] = true
+	}
+
+	return u
+}
+
+// Enqueue queues the cmd message in Payload for the device, returned in the response of its next ping
+func (u *commandUsecase) Enqueue(c context.Context, caller string, command *model.DeviceCommand) (err error) {
+	c, span := tracer.Start(c, "commandUsecase.Enqueue")
+	defer func() { tracing.End(span, err) }()
+
+	if !u.admins[caller] {
+		return errNotAdmin
+	}
+
+	if command.Username == "" || command.Device == "" {
+		return response.WrapError(errors.New("username and device are required"), http.StatusBadRequest)
+	}
+
+	if len(command.Username) > maxNameLen || len(command.Device) > maxNameLen {
+		return response.WrapError(fmt.Errorf("username and device must be at most %d characters", maxNameLen),
+			http.StatusBadRequest)
+	}
+
+	if command.Action, command.Payload, err = cmdMessage(command.Payload); err != nil {
+		return response.WrapError(err, http.StatusBadRequest)
+	}
+
+	command.CreatedBy = caller
+	command.CreatedAt = time.Now().Unix()
+	command.DeliveredAt = 0
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if err = u.repo.CreateCommand(ctx, command); err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	return nil
+}
+
+// List returns the newest commands of the device first, delivered or not
+func (u *commandUsecase) List(
+	c context.Context,
+	caller, username, device string,
+) (commands []model.DeviceCommand, err error) {
+	c, span := tracer.Start(c, "commandUsecase.List")
+	defer func() { tracing.End(span, err) }()
+
+	if !u.admins[caller] {
+		return nil, errNotAdmin
+	}
+
+	if username == "" {
+		return nil, response.WrapError(errors.New("username is required"), http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	commands, err = u.repo.GetCommands(ctx, username, device, listLimit)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	return commands, nil
+}
+
+// Commands returns the pending commands of the device queued in the last cfg.ExpireAfter oldest first,
+// none when they can't be loaded. They stay pending & are sent again until a message with another tst
+// than the one answered confirms them, a device sends another message only after it got a response
+func (u *commandUsecase) Commands(c context.Context, username, device string, tst int64) []interface{} {
+	c, span := tracer.Start(c, "commandUsecase.Commands")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	key := username + "/" + device
+
+	u.mu.Lock()
+	sent, ok := u.sent[key]
+	u.mu.Unlock()
+
+	if ok && sent.tst != tst {
+		if err := u.repo.DeliverCommands(ctx, sent.ids, time.Now().Unix()); err != nil {
+			logger.FromContext(ctx).Errorln(err)
+
+			return nil
+		}
+
+		u.mu.Lock()
+		delete(u.sent, key)
+		u.mu.Unlock()
+	}
+
+	pending, err := u.repo.GetPendingCommands(ctx, username, device, time.Now().Add(-u.cfg.ExpireAfter).Unix())
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil
+	}
+
+	commands := make([]interface{}, 0, len(pending))
+	sent = sentCommands{ids: make([]int64, 0, len(pending)), tst: tst}
+
+	for _, command := range pending {
+		commands = append(commands, command.Payload)
+		sent.ids = append(sent.ids, command.ID)
+	}
+
+	if len(pending) > 0 {
+		u.mu.Lock()
+		u.sent[key] = sent
+		u.mu.Unlock()
+	}
+
+	return commands
+}
+
+// cmdMessage returns the action & the OwnTracks cmd message of a request like
+// {"action":"setConfiguration","configuration":{...}}, the fields an action doesn't take are dropped
+func cmdMessage(body json.RawMessage) (string, json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return "", nil, errors.New("command must be a JSON object")
+	}
+
+	var action string
+	if err := json.Unmarshal(fields["action"], &action); err != nil {
+		return "", nil, errors.New("action must be a string")
+	}
+
+	msg := map[string]interface{}{"_type": "cmd", "action": action}
+
+	switch action {
+	case model.CommandReportLocation, model.CommandClearWaypoints:
+	case model.CommandSetConfiguration:
+		var configuration map[string]interface{}
+		if err := json.Unmarshal(fields["configuration"], &configuration); err != nil || configuration == nil {
+			return "", nil, errors.New("configuration must be a JSON object")
+		}
+
+		configuration["_type"] = "configuration"
+		msg["configuration"] = configuration
+	case model.CommandAction:
+		// content, url, notification & extern are passed on as they are
+		for k, v := range fields {
+			if k != "_type" && k != "action" {
+				msg[k] = v
+			}
+		}
+	default:
+		return "", nil, fmt.Errorf("action must be one of %s, %s, %s or %s", model.CommandReportLocation,
+			model.CommandSetConfiguration, model.CommandClearWaypoints, model.CommandAction)
+	}
+
+	payload, err := json.Marshal(msg)
+
+	return action, payload, err
+}
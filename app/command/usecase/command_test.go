@@ -0,0 +1,116 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"ot-recorder/app/command/usecase"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var cfg = config.CommandsConfig{Admins: []string{"admin"}, ExpireAfter: time.Hour} //nolint:gochecknoglobals
+
+func TestEnqueue(t *testing.T) {
+	mockRepo := new(mocks.CommandRepository)
+	mockRepo.On("CreateCommand", mock.Anything, mock.AnythingOfType("*model.DeviceCommand")).Return(nil)
+
+	u := usecase.NewCommandUsecase(mockRepo, cfg, time.Second*2)
+
+	for body, expected := range map[string]string{
+		`{"action":"reportLocation","extra":1}`: `{"_type":"cmd","action":"reportLocation"}`,
+		`{"action":"clearWaypoints"}`:           `{"_type":"cmd","action":"clearWaypoints"}`,
+		`{"action":"setConfiguration","configuration":{"locatorInterval":60}}`: `{"_type":"cmd",` +
+			`"action":"setConfiguration","configuration":{"_type":"configuration","locatorInterval":60}}`,
+		`{"action":"action","content":"# Hello","url":"https://example.com"}`: `{"_type":"cmd",` +
+			`"action":"action","content":"# Hello","url":"https://example.com"}`,
+	} {
+		command := &model.DeviceCommand{Username: "dev", Device: "phone", Payload: json.RawMessage(body)}
+		assert.NoError(t, u.Enqueue(context.TODO(), "admin", command), body)
+		assert.JSONEq(t, expected, string(command.Payload), body)
+		assert.Equal(t, "admin", command.CreatedBy)
+		assert.NotZero(t, command.CreatedAt)
+	}
+
+	err := u.Enqueue(context.TODO(), "dev", &model.DeviceCommand{Username: "dev", Device: "phone",
+		Payload: json.RawMessage(`{"action":"reportLocation"}`)})
+	code, _ := response.RespondError(err)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	for _, command := range []model.DeviceCommand{
+		{Username: "dev", Payload: json.RawMessage(`{"action":"reportLocation"}`)},
+		{Username: "dev", Device: "phone", Payload: json.RawMessage(`{"action":"dump"}`)},
+		{Username: "dev", Device: "phone", Payload: json.RawMessage(`{"action":"setConfiguration"}`)},
+		{Username: "dev", Device: "phone", Payload: json.RawMessage(`[1]`)},
+	} {
+		command := command
+		err := u.Enqueue(context.TODO(), "admin", &command)
+		code, _ := response.RespondError(err)
+		assert.Equal(t, http.StatusBadRequest, code, string(command.Payload))
+	}
+
+	mockRepo.AssertNumberOfCalls(t, "CreateCommand", 4)
+}
+
+func TestList(t *testing.T) {
+	mockRepo := new(mocks.CommandRepository)
+	mockRepo.On("GetCommands", mock.Anything, "dev", "phone", 100).
+		Return([]model.DeviceCommand{{ID: 2}, {ID: 1}}, nil).Once()
+	mockRepo.On("GetCommands", mock.Anything, "mom", "", 100).Return(nil, errors.New("db down")).Once()
+
+	u := usecase.NewCommandUsecase(mockRepo, cfg, time.Second*2)
+
+	commands, err := u.List(context.TODO(), "admin", "dev", "phone")
+	assert.NoError(t, err)
+	assert.Len(t, commands, 2)
+
+	_, err = u.List(context.TODO(), "dev", "dev", "phone")
+	code, _ := response.RespondError(err)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	_, err = u.List(context.TODO(), "admin", "mom", "")
+	code, _ = response.RespondError(err)
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCommands(t *testing.T) {
+	payload := `{"_type":"cmd","action":"reportLocation"}`
+	since := mock.MatchedBy(func(since int64) bool { return time.Now().Unix()-since >= 3600 })
+
+	mockRepo := new(mocks.CommandRepository)
+	mockRepo.On("GetPendingCommands", mock.Anything, "dev", "phone", since).
+		Return([]model.DeviceCommand{{ID: 1, Payload: json.RawMessage(payload)}}, nil).Twice()
+	mockRepo.On("DeliverCommands", mock.Anything, []int64{1}, mock.AnythingOfType("int64")).Return(nil).Once()
+	mockRepo.On("GetPendingCommands", mock.Anything, "dev", "phone", since).
+		Return([]model.DeviceCommand{}, nil).Once()
+	mockRepo.On("GetPendingCommands", mock.Anything, "mom", "tablet", mock.Anything).
+		Return(nil, errors.New("db down")).Once()
+
+	u := usecase.NewCommandUsecase(mockRepo, cfg, time.Second*2)
+
+	commands := u.Commands(context.TODO(), "dev", "phone", 1000)
+	assert.Len(t, commands, 1)
+
+	b, err := json.Marshal(commands)
+	assert.NoError(t, err)
+	assert.JSONEq(t, "["+payload+"]", string(b))
+
+	// the device didn't get the response & sends the message again
+	assert.Len(t, u.Commands(context.TODO(), "dev", "phone", 1000), 1)
+	mockRepo.AssertNotCalled(t, "DeliverCommands", mock.Anything, mock.Anything, mock.Anything)
+
+	// the next message confirms them
+	assert.Empty(t, u.Commands(context.TODO(), "dev", "phone", 1001))
+	assert.Empty(t, u.Commands(context.TODO(), "mom", "tablet", 1000))
+	mockRepo.AssertExpectations(t)
+}
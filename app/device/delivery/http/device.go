@@ -0,0 +1,32 @@
+package http
+
+import (
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DeviceHandler represent the http handler for device health
+type DeviceHandler struct {
+	DUseCase model.DeviceUsecase
+}
+
+func NewDeviceHandler(e *echo.Echo, us model.DeviceUsecase) {
+	handler := &DeviceHandler{
+		DUseCase: us,
+	}
+
+	v1 := e.Group("/api/v1")
+	v1.GET("/devices/status", handler.Status)
+}
+
+// Status returns the health of every device, of one user with the username query param
+func (h *DeviceHandler) Status(c echo.Context) error {
+	statuses, err := h.DUseCase.Status(c.Request().Context(), c.QueryParam("username"))
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", statuses))
+}
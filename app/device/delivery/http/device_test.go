@@ -0,0 +1,44 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	dHttp "ot-recorder/app/device/delivery/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStatus(t *testing.T) {
+	mockUsecase := new(mocks.DeviceUsecase)
+	mockUsecase.On("Status", mock.Anything, "dev").Return([]model.DeviceStatus{{
+		Username:      "dev",
+		Device:        "phone",
+		LastSeen:      1669146895,
+		Cadence:       600,
+		Battery:       15,
+		BatteryStatus: "Unplugged",
+		Alerts:        []string{model.AlertBatteryLow},
+	}}, nil).Once()
+	mockUsecase.On("Status", mock.Anything, "mom").Return(nil, response.ErrNotFound).Once()
+
+	handler := dHttp.DeviceHandler{DUseCase: mockUsecase}
+
+	req := httptest.NewRequest(echo.GET, "/api/v1/devices/status?username=dev", nil)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, handler.Status(echo.New().NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"device":"phone","last_seen":1669146895,"cadence":600,"battery":15`)
+	assert.Contains(t, rec.Body.String(), `"alerts":["battery_low"]`)
+
+	req = httptest.NewRequest(echo.GET, "/api/v1/devices/status?username=mom", nil)
+	rec = httptest.NewRecorder()
+	assert.NoError(t, handler.Status(echo.New().NewContext(req, rec)))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/config"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const clientTimeout = 10 * time.Second
+
+// FromConfig the notifiers enabled by the monitor config
+func FromConfig(monitor config.MonitorConfig, telegram config.TelegramHook) []model.Notifier {
+	client := &http.Client{Timeout: clientTimeout}
+
+	var notifiers []model.Notifier
+
+	if monitor.Telegram {
+		if telegram.BotToken == "" {
+			logrus.Warnln("device alerts via telegram need hook.telegram.bot_token")
+		} else {
+			notifiers = append(notifiers, NewTelegram(client, telegram.BotToken, telegram.ChatID))
+		}
+	}
+
+	for _, u := range monitor.Webhooks {
+		notifiers = append(notifiers, NewWebhook(client, u))
+	}
+
+	return notifiers
+}
+
+// withoutURL drops the url of a client error, it may hold a secret
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+
+	return err
+}
@@ -0,0 +1,86 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelegram(t *testing.T) {
+	var body map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		if r.URL.Path != "/botXXX:YYY/sendMessage" {
+			_, _ = w.Write([]byte(`{"ok":false,"description":"Not Found"}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	api := telegramAPI
+	telegramAPI = srv.URL
+
+	defer func() { telegramAPI = api }()
+
+	alert := &model.DeviceAlert{
+		Username: "dev",
+		Device:   "phone",
+		Kind:     model.AlertInactive,
+		Message:  "dev/phone reports again",
+		Resolved: true,
+	}
+
+	assert.NoError(t, NewTelegram(srv.Client(), "XXX:YYY", -123).Notify(context.TODO(), alert))
+	assert.Equal(t, map[string]interface{}{"chat_id": -123.0, "text": "✅ dev/phone reports again"}, body)
+
+	err := NewTelegram(srv.Client(), "wrong", -123).Notify(context.TODO(), alert)
+	assert.EqualError(t, err, "telegram sendMessage: Not Found")
+}
+
+func TestWebhook(t *testing.T) {
+	var alert model.DeviceAlert
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(&alert)
+	}))
+	defer srv.Close()
+
+	sent := &model.DeviceAlert{Username: "dev", Device: "phone", Kind: model.AlertBatteryLow, Message: "low", At: 1}
+
+	assert.NoError(t, NewWebhook(srv.Client(), srv.URL+"/alerts").Notify(context.TODO(), sent))
+	assert.Equal(t, *sent, alert)
+
+	down := NewWebhook(srv.Client(), srv.URL+"/down")
+	assert.EqualError(t, down.Notify(context.TODO(), sent), down.Name()+": status 502")
+
+	assert.EqualError(t, NewWebhook(srv.Client(), "http://127.0.0.1:1/secret").Notify(context.TODO(), sent),
+		"webhook 127.0.0.1:1: dial tcp 127.0.0.1:1: connect: connection refused")
+}
+
+func TestFromConfig(t *testing.T) {
+	notifiers := FromConfig(
+		config.MonitorConfig{Telegram: true, Webhooks: []string{"https://example.com/hook"}},
+		config.TelegramHook{BotToken: "XXX:YYY", ChatID: -123},
+	)
+
+	assert.Len(t, notifiers, 2)
+	assert.Equal(t, "telegram", notifiers[0].Name())
+	assert.Equal(t, "webhook example.com", notifiers[1].Name())
+
+	assert.Len(t, FromConfig(config.MonitorConfig{Telegram: true}, config.TelegramHook{}), 0)
+}
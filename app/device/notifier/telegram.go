@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"ot-recorder/app/model"
+)
+
+// telegramAPI base url of the bot API, replaced in tests
+var telegramAPI = "https://api.telegram.org" //nolint:gochecknoglobals
+
+// Telegram sends alerts to a chat as the bot of token
+type Telegram struct {
+	client *http.Client
+	token  string
+	chatID int64
+}
+
+func NewTelegram(client *http.Client, token string, chatID int64) *Telegram {
+	return &Telegram{client: client, token: token, chatID: chatID}
+}
+
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+// Notify sends the alert message with sendMessage
+func (t *Telegram) Notify(ctx context.Context, alert *model.DeviceAlert) error {
+	text := "⚠️ " + alert.Message
+	if alert.Resolved {
+		text = "✅ " + alert.Message
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id": t.chatID,
+		"text":    text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, telegramAPI+"/bot"+t.token+"/sendMessage",
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		// the url holds the bot token
+		return fmt.Errorf("telegram sendMessage: %w", withoutURL(err))
+	}
+	defer res.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("telegram sendMessage: status %d", res.StatusCode)
+	}
+
+	if !result.OK {
+		return fmt.Errorf("telegram sendMessage: %s", result.Description)
+	}
+
+	return nil
+}
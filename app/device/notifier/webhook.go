@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"ot-recorder/app/model"
+)
+
+// Webhook posts alerts as JSON to a url
+type Webhook struct {
+	client *http.Client
+	url    string
+}
+
+func NewWebhook(client *http.Client, url string) *Webhook {
+	return &Webhook{client: client, url: url}
+}
+
+// Name the host of the url, the path may hold a secret
+func (w *Webhook) Name() string {
+	u, err := url.Parse(w.url)
+	if err != nil || u.Host == "" {
+		return "webhook"
+	}
+
+	return "webhook " + u.Host
+}
+
+// Notify posts the alert, any status but 2xx fails
+func (w *Webhook) Notify(ctx context.Context, alert *model.DeviceAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", w.Name(), withoutURL(err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s: status %d", w.Name(), res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/metrics"
+	"ot-recorder/infrastructure/tracing"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+const statusLocationLimit = 20000
+
+var tracer = otel.Tracer("ot-recorder/app/device/usecase") //nolint:gochecknoglobals
+
+//nolint:gochecknoglobals
+var errInternal = response.WrapError(
+	errors.New("internal server error, please report to admin"),
+	http.StatusInternalServerError,
+)
+
+type deviceUsecase struct {
+	lRepo          model.LocationRepository
+	notifiers      []model.Notifier
+	cfg            config.MonitorConfig
+	contextTimeout time.Duration
+
+	mu       sync.Mutex
+	active   map[string]model.DeviceAlert // by username, device & kind, nil until the first check
+	failures map[string]string            // last error by notifier
+}
+
+func NewDeviceUsecase(
+	lRepo model.LocationRepository,
+	notifiers []model.Notifier,
+	cfg config.MonitorConfig,
+	timeout time.Duration,
+) model.DeviceUsecase {
+	u := &deviceUsecase{
+		lRepo:          lRepo,
+		notifiers:      notifiers,
+		cfg:            cfg,
+		contextTimeout: timeout,
+		failures:       map[string]string{},
+	}
+
+	for _, n := range notifiers {
+		u.failures[n.Name()] = ""
+	}
+
+	return u
+}
+
+// Status returns the health of every device, of one user when username isn't empty
+func (u *deviceUsecase) Status(c context.Context, username string) (statuses []model.DeviceStatus, err error) {
+	c, span := tracer.Start(c, "deviceUsecase.Status")
+	defer func() { tracing.End(span, err) }()
+
+	statuses, err = u.statuses(c, username, time.Now().Unix())
+	if err != nil {
+		logger.FromContext(c).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	if username != "" && len(statuses) == 0 {
+		return nil, response.ErrNotFound
+	}
+
+	return statuses, nil
+}
+
+// Check compares the alerts of every device with the previous check and sends
+// those which started or ended through the notifiers. The first check only takes
+// the current alerts, those of devices inactive before a restart aren't sent again
+func (u *deviceUsecase) Check(c context.Context) (alerts []model.DeviceAlert, err error) {
+	c, span := tracer.Start(c, "deviceUsecase.Check")
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now().Unix()
+
+	statuses, err := u.statuses(c, "", now)
+	if err != nil {
+		logger.FromContext(c).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	current := map[string]model.DeviceAlert{}
+
+	for i := range statuses {
+		s := &statuses[i]
+		for _, kind := range s.Alerts {
+			current[alertKey(s.Username, s.Device, kind)] = model.DeviceAlert{
+				Username: s.Username,
+				Device:   s.Device,
+				Kind:     kind,
+				Message:  alertMessage(s, kind, u.cfg, now),
+				At:       now,
+			}
+		}
+	}
+
+	u.mu.Lock()
+	if u.active == nil {
+		u.active = current
+		u.mu.Unlock()
+
+		return nil, nil
+	}
+
+	for key, alert := range current {
+		if _, ok := u.active[key]; !ok {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	for key, alert := range u.active {
+		if _, ok := current[key]; !ok {
+			alert.Resolved = true
+			alert.Message = resolvedMessage(&alert)
+			alert.At = now
+			alerts = append(alerts, alert)
+		}
+	}
+
+	u.active = current
+	u.mu.Unlock()
+
+	sort.Slice(alerts, func(i, j int) bool {
+		a, b := &alerts[i], &alerts[j]
+		return alertKey(a.Username, a.Device, a.Kind) < alertKey(b.Username, b.Device, b.Kind)
+	})
+
+	for i := range alerts {
+		metrics.DeviceAlerts.WithLabelValues(alerts[i].Kind).Inc()
+		u.notify(c, &alerts[i])
+	}
+
+	return alerts, nil
+}
+
+// Notifiers returns the last error of every notifier, empty when its last alert was sent
+func (u *deviceUsecase) Notifiers() map[string]string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	failures := make(map[string]string, len(u.failures))
+	for name, failure := range u.failures {
+		failures[name] = failure
+	}
+
+	return failures
+}
+
+func (u *deviceUsecase) notify(c context.Context, alert *model.DeviceAlert) {
+	for _, n := range u.notifiers {
+		ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+		err := n.Notify(ctx, alert)
+		cancel()
+
+		failure := ""
+		if err != nil {
+			failure = err.Error()
+			logger.FromContext(c).Errorf("device alert via %s: %v", n.Name(), err)
+		}
+
+		u.mu.Lock()
+		u.failures[n.Name()] = failure
+		u.mu.Unlock()
+	}
+}
+
+// statuses loads the last locations and then the window of every device, each query with its own timeout
+func (u *deviceUsecase) statuses(c context.Context, username string, now int64) ([]model.DeviceStatus, error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	last, err := u.lRepo.GetLastLocations(ctx, username)
+	cancel()
+
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]model.DeviceStatus, 0, len(last))
+
+	for i := range last {
+		l := &last[i]
+
+		ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+		locations, err := u.lRepo.GetLocations(ctx, model.LocationQuery{
+			Username: l.Username,
+			Device:   l.Device,
+			From:     now - int64(u.cfg.Window.Seconds()),
+			To:       now,
+			Limit:    statusLocationLimit,
+		})
+		cancel()
+
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, deviceStatus(l, locations, u.cfg, now))
+	}
+
+	return statuses, nil
+}
+
+func alertKey(username, device, kind string) string {
+	return username + "\x00" + device + "\x00" + kind
+}
+
+func alertMessage(s *model.DeviceStatus, kind string, cfg config.MonitorConfig, now int64) string {
+	name := s.Username + "/" + s.Device
+
+	switch kind {
+	case model.AlertInactive:
+		return fmt.Sprintf("%s stopped reporting, last seen %s ago", name, since(now-s.LastSeen))
+	case model.AlertBatteryLow:
+		return fmt.Sprintf("%s battery is low at %d%% (below %d%%)", name, s.Battery, cfg.BatteryLow)
+	default:
+		return fmt.Sprintf("%s is unplugged for %s", name, since(now-s.UnpluggedSince))
+	}
+}
+
+func resolvedMessage(alert *model.DeviceAlert) string {
+	name := alert.Username + "/" + alert.Device
+
+	switch alert.Kind {
+	case model.AlertInactive:
+		return name + " reports again"
+	case model.AlertBatteryLow:
+		return name + " battery is fine again"
+	default:
+		return name + " is plugged in"
+	}
+}
+
+// since formats seconds as hours & minutes like 2h5m
+func since(seconds int64) string {
+	minutes := seconds / 60
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	return fmt.Sprintf("%dh%dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,184 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"ot-recorder/app/device/usecase"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//nolint:gochecknoglobals
+var monitorCfg = config.MonitorConfig{
+	Window:         48 * time.Hour,
+	InactiveAfter:  2 * time.Hour,
+	UnpluggedAfter: 24 * time.Hour,
+	BatteryLow:     20,
+}
+
+// track locations of a device every 10 minutes until at, battery draining 6% per hour
+func track(device string, at int64, bs int8, n int) []model.Location {
+	locations := make([]model.Location, n)
+	for i := range locations {
+		tst := at - int64(n-1-i)*600
+		locations[i] = model.Location{
+			Username:  "dev",
+			Device:    device,
+			CreatedAt: tst,
+			Batt:      int8(30 + n - 1 - i),
+			Bs:        bs,
+			T:         "u",
+			Ssid:      "home",
+		}
+	}
+
+	return locations
+}
+
+func TestStatus(t *testing.T) {
+	now := time.Now().Unix()
+
+	phone := track("phone", now-60, int8(model.Unplugged), 13)
+	tablet := track("tablet", now-5*3600, int8(model.Charging), 3)
+	tablet[2].Batt = 10
+
+	mockLocRepo := new(mocks.LocationRepository)
+	mockLocRepo.On("GetLastLocations", mock.Anything, "dev").
+		Return([]model.Location{phone[12], tablet[2]}, nil).Once()
+	mockLocRepo.On("GetLocations", mock.Anything, mock.MatchedBy(func(q model.LocationQuery) bool {
+		return q.Device == "phone" && q.To-q.From == 48*3600
+	})).Return(phone, nil).Once()
+	mockLocRepo.On("GetLocations", mock.Anything, mock.MatchedBy(func(q model.LocationQuery) bool {
+		return q.Device == "tablet"
+	})).Return(tablet, nil).Once()
+
+	u := usecase.NewDeviceUsecase(mockLocRepo, nil, monitorCfg, time.Second*2)
+
+	statuses, err := u.Status(context.TODO(), "dev")
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+
+	assert.Equal(t, "phone", statuses[0].Device)
+	assert.Equal(t, int64(600), statuses[0].Cadence)
+	assert.Equal(t, -6.0, statuses[0].BatteryTrend)
+	assert.Equal(t, phone[0].CreatedAt, statuses[0].UnpluggedSince)
+	assert.Equal(t, "Unplugged", statuses[0].BatteryStatus)
+	assert.Equal(t, "home", statuses[0].WifiName)
+	assert.Empty(t, statuses[0].Alerts)
+
+	// charging at 10% is fine, 5 hours without a report isn't
+	assert.Equal(t, []string{model.AlertInactive}, statuses[1].Alerts)
+	assert.Zero(t, statuses[1].UnpluggedSince)
+	mockLocRepo.AssertExpectations(t)
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockLocRepo := new(mocks.LocationRepository)
+		mockLocRepo.On("GetLastLocations", mock.Anything, "mom").Return([]model.Location{}, nil).Once()
+
+		u := usecase.NewDeviceUsecase(mockLocRepo, nil, monitorCfg, time.Second*2)
+
+		_, err := u.Status(context.TODO(), "mom")
+		assert.ErrorIs(t, err, response.ErrNotFound)
+	})
+
+	t.Run("timeout per query", func(t *testing.T) {
+		var errs []error
+
+		mockLocRepo := new(mocks.LocationRepository)
+		mockLocRepo.On("GetLastLocations", mock.Anything, "dev").
+			Return([]model.Location{phone[12], tablet[2]}, nil).Once()
+		mockLocRepo.On("GetLocations", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			time.Sleep(60 * time.Millisecond)
+			errs = append(errs, args.Get(0).(context.Context).Err())
+		}).Return(phone, nil).Twice()
+
+		u := usecase.NewDeviceUsecase(mockLocRepo, nil, monitorCfg, 100*time.Millisecond)
+
+		_, err := u.Status(context.TODO(), "dev")
+		assert.NoError(t, err)
+		assert.Equal(t, []error{nil, nil}, errs)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mockLocRepo := new(mocks.LocationRepository)
+		mockLocRepo.On("GetLastLocations", mock.Anything, "").Return(nil, errors.New("db down")).Once()
+
+		u := usecase.NewDeviceUsecase(mockLocRepo, nil, monitorCfg, time.Second*2)
+
+		_, err := u.Status(context.TODO(), "")
+		code, _ := response.RespondError(err)
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now().Unix()
+
+	// unplugged for more than a day with a low battery
+	phone := track("phone", now-60, int8(model.Unplugged), 12)
+	phone[0].CreatedAt = now - 25*3600
+	phone[11].Batt = 15
+
+	plugged := append([]model.Location{}, phone...)
+	plugged[11].Bs = int8(model.Charging)
+
+	mockLocRepo := new(mocks.LocationRepository)
+
+	for _, locations := range [][]model.Location{phone, plugged, phone} {
+		mockLocRepo.On("GetLastLocations", mock.Anything, "").
+			Return([]model.Location{locations[11]}, nil).Once()
+		mockLocRepo.On("GetLocations", mock.MatchedBy(func(ctx context.Context) bool {
+			_, ok := ctx.Deadline()
+			return ok
+		}), mock.Anything).Return(locations, nil).Once()
+	}
+
+	var sent []string
+
+	mockNotifier := new(mocks.Notifier)
+	mockNotifier.On("Name").Return("webhook")
+	mockNotifier.On("Notify", mock.Anything, mock.AnythingOfType("*model.DeviceAlert")).
+		Run(func(args mock.Arguments) {
+			sent = append(sent, args.Get(1).(*model.DeviceAlert).Message)
+		}).Return(nil).Once()
+	mockNotifier.On("Notify", mock.Anything, mock.AnythingOfType("*model.DeviceAlert")).
+		Return(errors.New("status 502"))
+
+	u := usecase.NewDeviceUsecase(mockLocRepo, []model.Notifier{mockNotifier}, monitorCfg, time.Second*2)
+	assert.Equal(t, map[string]string{"webhook": ""}, u.Notifiers())
+
+	// the first check takes the alerts of a restart as they are
+	alerts, err := u.Check(context.TODO())
+	assert.NoError(t, err)
+	assert.Empty(t, alerts)
+	mockNotifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+
+	// charging ends both
+	alerts, err = u.Check(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 2)
+	assert.True(t, alerts[0].Resolved)
+	assert.Equal(t, "dev/phone battery is fine again", sent[0])
+	assert.Equal(t, "dev/phone is plugged in", alerts[1].Message)
+	assert.Equal(t, map[string]string{"webhook": "status 502"}, u.Notifiers())
+
+	// unplugging starts them again, the notifier failure is kept
+	alerts, err = u.Check(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 2)
+	assert.Equal(t, model.AlertBatteryLow, alerts[0].Kind)
+	assert.False(t, alerts[0].Resolved)
+	assert.Equal(t, model.AlertUnplugged, alerts[1].Kind)
+	assert.Equal(t, "dev/phone is unplugged for 25h0m", alerts[1].Message)
+	assert.Equal(t, map[string]string{"webhook": "status 502"}, u.Notifiers())
+	mockLocRepo.AssertExpectations(t)
+	mockNotifier.AssertNumberOfCalls(t, "Notify", 4)
+}
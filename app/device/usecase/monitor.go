@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"ot-recorder/app/model"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Monitor checks the devices every interval and sends their alerts until it is closed
+type Monitor struct {
+	usecase  model.DeviceUsecase
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// StartMonitor starts checking the devices in the background
+func StartMonitor(usecase model.DeviceUsecase, interval time.Duration) *Monitor {
+	m := &Monitor{
+		usecase:  usecase,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go m.run()
+
+	return m
+}
+
+// Close stops the checks, waits for a running check until the context is done
+func (m *Monitor) Close(ctx context.Context) error {
+	close(m.stop)
+
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("device monitor: %w", ctx.Err())
+	}
+}
+
+func (m *Monitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	defer close(m.done)
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-m.stop:
+			return
+		}
+
+		alerts, err := m.usecase.Check(context.Background())
+		if err != nil {
+			continue
+		}
+
+		for i := range alerts {
+			logrus.Infof("device alert: %s", alerts[i].Message)
+		}
+	}
+}
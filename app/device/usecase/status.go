@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"math"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/config"
+	"sort"
+)
+
+// a device is inactive after this many of its usual intervals without a report,
+// when that is longer than the configured inactive_after
+const inactiveCadences = 4
+
+// deviceStatus health of the device of last from its time ordered locations in the window
+func deviceStatus(
+	last *model.Location,
+	locations []model.Location,
+	cfg config.MonitorConfig,
+	now int64,
+) model.DeviceStatus {
+	s := model.DeviceStatus{
+		Username:      last.Username,
+		Device:        last.Device,
+		LastSeen:      last.CreatedAt,
+		Cadence:       cadence(locations),
+		Battery:       last.Batt,
+		BatteryStatus: model.BatteryStatusEnum(last.Bs).String(),
+		BatteryTrend:  batteryTrend(locations),
+		Trigger:       last.T,
+		WifiName:      last.Ssid,
+		Alerts:        []string{},
+	}
+
+	if model.BatteryStatusEnum(last.Bs) == model.Unplugged {
+		s.UnpluggedSince = last.CreatedAt
+
+		for i := len(locations) - 1; i >= 0 && model.BatteryStatusEnum(locations[i].Bs) == model.Unplugged; i-- {
+			if locations[i].CreatedAt < s.UnpluggedSince {
+				s.UnpluggedSince = locations[i].CreatedAt
+			}
+		}
+	}
+
+	inactiveAfter := int64(cfg.InactiveAfter.Seconds())
+	if s.Cadence*inactiveCadences > inactiveAfter {
+		inactiveAfter = s.Cadence * inactiveCadences
+	}
+
+	if now-s.LastSeen > inactiveAfter {
+		s.Alerts = append(s.Alerts, model.AlertInactive)
+	}
+
+	charging := model.BatteryStatusEnum(last.Bs) == model.Charging || model.BatteryStatusEnum(last.Bs) == model.Full
+	if s.Battery > 0 && s.Battery < cfg.BatteryLow && !charging {
+		s.Alerts = append(s.Alerts, model.AlertBatteryLow)
+	}
+
+	if s.UnpluggedSince > 0 && now-s.UnpluggedSince > int64(cfg.UnpluggedAfter.Seconds()) {
+		s.Alerts = append(s.Alerts, model.AlertUnplugged)
+	}
+
+	return s
+}
+
+// cadence median seconds between the reports, 0 with less than two
+func cadence(locations []model.Location) int64 {
+	if len(locations) < 2 {
+		return 0
+	}
+
+	gaps := make([]int64, 0, len(locations)-1)
+	for i := 1; i < len(locations); i++ {
+		gaps = append(gaps, locations[i].CreatedAt-locations[i-1].CreatedAt)
+	}
+
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+
+	return gaps[len(gaps)/2]
+}
+
+// batteryTrend least squares slope of the battery level in percent per hour, locations
+// without a level are skipped, 0 with less than two hours of levels
+func batteryTrend(locations []model.Location) float64 {
+	var (
+		n, sumT, sumB, sumTT, sumTB float64
+		first, latest               int64
+	)
+
+	for i := range locations {
+		l := &locations[i]
+		if l.Batt <= 0 {
+			continue
+		}
+
+		if n == 0 {
+			first = l.CreatedAt
+		}
+
+		latest = l.CreatedAt
+
+		// hours since the first level keeps the sums small
+		t := float64(l.CreatedAt-first) / 3600
+		b := float64(l.Batt)
+		n++
+		sumT += t
+		sumB += b
+		sumTT += t * t
+		sumTB += t * b
+	}
+
+	const minTrendSeconds = 2 * 60 * 60
+
+	denominator := n*sumTT - sumT*sumT
+	if latest-first < minTrendSeconds || denominator == 0 {
+		return 0
+	}
+
+	return math.Round((n*sumTB-sumT*sumB)/denominator*10) / 10
+}
@@ -0,0 +1,139 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+const noise = -1
+
+// Cluster groups points with DBSCAN, a point with at least minPoints points(itself included) within
+// eps meters is a core point, clusters are the points reachable from core points. Neighbours are
+// looked up in a grid of cells at least eps wide.
+// It returns the point indexes of every cluster in order of their first point, noise points are left out
+func Cluster(points []Point, eps float64, minPoints int) [][]int {
+	labels := make([]int, len(points))
+	visited := make([]bool, len(points))
+
+	for i := range labels {
+		labels[i] = noise
+	}
+
+	g := newGrid(points, eps)
+
+	var clusters [][]int
+
+	for i := range points {
+		if visited[i] {
+			continue
+		}
+
+		visited[i] = true
+
+		seeds := g.neighbours(i)
+		if len(seeds) < minPoints {
+			continue
+		}
+
+		id := len(clusters)
+		clusters = append(clusters, nil)
+
+		for k := 0; k < len(seeds); k++ {
+			j := seeds[k]
+
+			if !visited[j] {
+				visited[j] = true
+
+				if more := g.neighbours(j); len(more) >= minPoints {
+					seeds = append(seeds, more...)
+				}
+			}
+
+			if labels[j] == noise {
+				labels[j] = id
+				clusters[id] = append(clusters[id], j)
+			}
+		}
+	}
+
+	return clusters
+}
+
+// grid buckets points into cells at least eps meters high & wide, every point within eps of
+// a point is in its cell or one of the 8 around it. Columns wrap around the antimeridian.
+type grid struct {
+	points []Point
+	eps    float64
+	dLat   float64 // cell height in degrees
+	cols   int     // columns around the globe, 1 when every longitude may be within eps
+	cells  map[[2]int][]int
+}
+
+func newGrid(points []Point, eps float64) *grid {
+	g := &grid{
+		points: points,
+		eps:    eps,
+		dLat:   math.Max(eps/earthRadius*180/math.Pi, minCellDegrees),
+		cols:   1,
+		cells:  map[[2]int][]int{},
+	}
+
+	// longitude degrees within eps are the most at the highest latitude of the points
+	var maxLat float64
+	for _, p := range points {
+		maxLat = math.Max(maxLat, math.Abs(p.Lat))
+	}
+
+	if cos := math.Cos(radians(maxLat)); cos > 0 {
+		if s := math.Sin(eps/earthRadius/2) / cos; s < 1 {
+			dLon := math.Max(2*math.Asin(s)*180/math.Pi, minCellDegrees)
+			if cols := int(360 / dLon); cols >= 3 {
+				g.cols = cols
+			}
+		}
+	}
+
+	for i, p := range points {
+		c := g.cell(p)
+		g.cells[c] = append(g.cells[c], i)
+	}
+
+	return g
+}
+
+// minCellDegrees keeps cells of a tiny eps countable
+const minCellDegrees = 1e-7
+
+func (g *grid) cell(p Point) [2]int {
+	row := int(math.Floor((p.Lat + 90) / g.dLat))
+	col := int(math.Floor((p.Lon+180)/(360/float64(g.cols)))) % g.cols
+
+	return [2]int{row, col}
+}
+
+// neighbours returns the indexes of the points within eps of point i, itself included, in order
+func (g *grid) neighbours(i int) []int {
+	var found []int
+
+	p := g.points[i]
+	c := g.cell(p)
+
+	cols := []int{0}
+	if g.cols > 1 {
+		cols = []int{(c[1] + g.cols - 1) % g.cols, c[1], (c[1] + 1) % g.cols}
+	}
+
+	for row := c[0] - 1; row <= c[0]+1; row++ {
+		for _, col := range cols {
+			for _, j := range g.cells[[2]int{row, col}] {
+				if Distance(p.Lat, p.Lon, g.points[j].Lat, g.points[j].Lon) <= g.eps {
+					found = append(found, j)
+				}
+			}
+		}
+	}
+
+	sort.Ints(found)
+
+	return found
+}
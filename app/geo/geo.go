@@ -0,0 +1,110 @@
+// Package geo has distance and movement helpers working on plain coordinates
+package geo
+
+import "math"
+
+const earthRadius = 6371008.8 // meters
+
+// Point a location at a unix time
+type Point struct {
+	Lat float64
+	Lon float64
+	Tst int64
+}
+
+// Segment index range of points, End is inclusive
+type Segment struct {
+	Start int
+	End   int
+}
+
+// Distance great-circle distance between two coordinates in meters
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	rLat1 := radians(lat1)
+	rLat2 := radians(lat2)
+	dLat := radians(lat2 - lat1)
+	dLon := radians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rLat1)*math.Cos(rLat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// PathLength sum of the distances between consecutive points in meters
+func PathLength(points []Point) float64 {
+	var length float64
+
+	for i := 1; i < len(points); i++ {
+		length += Distance(points[i-1].Lat, points[i-1].Lon, points[i].Lat, points[i].Lon)
+	}
+
+	return length
+}
+
+// Centroid mean coordinate of the points
+func Centroid(points []Point) (lat, lon float64) {
+	if len(points) == 0 {
+		return 0, 0
+	}
+
+	for _, p := range points {
+		lat += p.Lat
+		lon += p.Lon
+	}
+
+	n := float64(len(points))
+
+	return lat / n, lon / n
+}
+
+// Stays finds runs of time ordered points staying within radius meters of the
+// first point of the run for at least minDuration seconds
+func Stays(points []Point, radius float64, minDuration int64) []Segment {
+	var stays []Segment
+
+	for i := 0; i < len(points); {
+		j := i + 1
+		for j < len(points) && Distance(points[i].Lat, points[i].Lon, points[j].Lat, points[j].Lon) <= radius {
+			j++
+		}
+
+		if points[j-1].Tst-points[i].Tst >= minDuration {
+			stays = append(stays, Segment{Start: i, End: j - 1})
+			i = j
+
+			continue
+		}
+
+		i++
+	}
+
+	return stays
+}
+
+// Trips finds the movements between stays, including the ones before the first
+// and after the last stay, shorter than minDistance meters are skipped
+func Trips(points []Point, stays []Segment, minDistance float64) []Segment {
+	var trips []Segment
+
+	add := func(start, end int) {
+		if end > start && PathLength(points[start:end+1]) >= minDistance {
+			trips = append(trips, Segment{Start: start, End: end})
+		}
+	}
+
+	start := 0
+
+	for _, stay := range stays {
+		// a trip ends where the next stay starts
+		add(start, stay.Start)
+		start = stay.End
+	}
+
+	add(start, len(points)-1)
+
+	return trips
+}
@@ -0,0 +1,62 @@
+package geo_test
+
+import (
+	"ot-recorder/app/geo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	// Dhaka to Chattogram
+	assert.InDelta(t, 213950, geo.Distance(23.8103, 90.4125, 22.3569, 91.7832), 100)
+	assert.Equal(t, 0.0, geo.Distance(23, 90, 23, 90))
+}
+
+func TestStaysAndTrips(t *testing.T) {
+	points := []geo.Point{
+		// home
+		{Lat: 23.0000, Lon: 90.0000, Tst: 0},
+		{Lat: 23.0001, Lon: 90.0001, Tst: 600},
+		{Lat: 23.0000, Lon: 90.0001, Tst: 1200},
+		// moving
+		{Lat: 23.0100, Lon: 90.0100, Tst: 1500},
+		{Lat: 23.0200, Lon: 90.0200, Tst: 1800},
+		// office
+		{Lat: 23.0300, Lon: 90.0300, Tst: 2100},
+		{Lat: 23.0301, Lon: 90.0300, Tst: 3000},
+		{Lat: 23.0300, Lon: 90.0301, Tst: 4000},
+	}
+
+	stays := geo.Stays(points, 100, 300)
+	assert.Equal(t, []geo.Segment{{Start: 0, End: 2}, {Start: 5, End: 7}}, stays)
+
+	trips := geo.Trips(points, stays, 100)
+	assert.Equal(t, []geo.Segment{{Start: 2, End: 5}}, trips)
+	assert.InDelta(t, 4500, geo.PathLength(points[2:6]), 200)
+
+	lat, lon := geo.Centroid(points[0:3])
+	assert.InDelta(t, 23.00003, lat, 0.00001)
+	assert.InDelta(t, 90.00007, lon, 0.00001)
+}
+
+func TestCluster(t *testing.T) {
+	points := []geo.Point{
+		{Lat: 23.8103, Lon: 90.4125}, // home
+		{Lat: 23.7500, Lon: 90.3900}, // work
+		{Lat: 23.8104, Lon: 90.4126},
+		{Lat: 23.7501, Lon: 90.3901},
+		{Lat: 23.8105, Lon: 90.4127},
+		{Lat: 23.9000, Lon: 90.5000}, // visited once
+	}
+
+	clusters := geo.Cluster(points, 100, 2)
+	assert.Equal(t, [][]int{{0, 2, 4}, {1, 3}}, clusters)
+
+	assert.Empty(t, geo.Cluster(points, 100, 4))
+	assert.Len(t, geo.Cluster(points, 100, 1), 3)
+
+	// neighbours across the antimeridian & near the pole are found through the grid
+	assert.Equal(t, [][]int{{0, 1}}, geo.Cluster([]geo.Point{{Lat: 10, Lon: 179.9995}, {Lat: 10, Lon: -179.9995}}, 200, 2))
+	assert.Equal(t, [][]int{{0, 1}}, geo.Cluster([]geo.Point{{Lat: 89.9995, Lon: 0}, {Lat: 89.9995, Lon: 180}}, 200, 2))
+}
@@ -0,0 +1,141 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+// GeohashPrecision characters of stored geohashes, cells are about 5 x 5 meters
+const GeohashPrecision = 9
+
+const (
+	geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+	geohashBits     = 5
+)
+
+// Box a bounding box of coordinates
+type Box struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// Contains reports whether the coordinate is inside the box, edges included
+func (b Box) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// BoundingBox the box enclosing the circle of radius meters around a coordinate,
+// clamped to valid coordinates
+func BoundingBox(lat, lon, radius float64) Box {
+	dLat := radius / earthRadius * 180 / math.Pi
+	dLon := 180.0
+
+	// longitude degrees shrink towards the poles, near them every longitude is in range
+	if cos := math.Cos(radians(lat)); cos > 1e-9 {
+		dLon = math.Min(dLat/cos, 180)
+	}
+
+	return Box{
+		MinLat: math.Max(lat-dLat, -90),
+		MinLon: math.Max(lon-dLon, -180),
+		MaxLat: math.Min(lat+dLat, 90),
+		MaxLon: math.Min(lon+dLon, 180),
+	}
+}
+
+// Geohash encodes a coordinate to a geohash of precision characters
+func Geohash(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	var hash strings.Builder
+
+	bit, ch, even := 0, 0, true
+
+	for hash.Len() < precision {
+		r, v := &latRange, lat
+		if even {
+			r, v = &lonRange, lon
+		}
+
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+
+		even = !even
+
+		if bit++; bit == geohashBits {
+			hash.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+
+	return hash.String()
+}
+
+// GeohashCover returns geohash prefixes of the finest precision whose cells cover the box
+// with at most maxCells cells, boxes too big for single characters get every needed one.
+// Every location inside the box has a geohash starting with one of them, locations outside may too.
+func GeohashCover(box Box, maxCells int) []string {
+	precision := 1
+
+	for p := GeohashPrecision; p > 1; p-- {
+		latCells, lonCells := coverCells(box, p)
+		if latCells*lonCells <= maxCells {
+			precision = p
+			break
+		}
+	}
+
+	latStep, lonStep := cellSize(precision)
+	minLat, minLon := cellIndex(box.MinLat, -90, latStep), cellIndex(box.MinLon, -180, lonStep)
+	maxLat, maxLon := cellIndex(box.MaxLat, -90, latStep), cellIndex(box.MaxLon, -180, lonStep)
+
+	cover := make([]string, 0, (maxLat-minLat+1)*(maxLon-minLon+1))
+
+	for i := minLat; i <= maxLat; i++ {
+		for j := minLon; j <= maxLon; j++ {
+			lat := -90 + (float64(i)+0.5)*latStep
+			lon := -180 + (float64(j)+0.5)*lonStep
+			cover = append(cover, Geohash(lat, lon, precision))
+		}
+	}
+
+	return cover
+}
+
+// cellSize degrees of a geohash cell, longitude gets the extra bit of odd bit counts
+func cellSize(precision int) (lat, lon float64) {
+	bits := precision * geohashBits
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+
+	return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(lonBits))
+}
+
+func coverCells(box Box, precision int) (latCells, lonCells int) {
+	latStep, lonStep := cellSize(precision)
+
+	latCells = cellIndex(box.MaxLat, -90, latStep) - cellIndex(box.MinLat, -90, latStep) + 1
+	lonCells = cellIndex(box.MaxLon, -180, lonStep) - cellIndex(box.MinLon, -180, lonStep) + 1
+
+	return latCells, lonCells
+}
+
+// cellIndex index of the cell holding v, the maximum belongs to the last cell
+func cellIndex(v, min, step float64) int {
+	i := int(math.Floor((v - min) / step))
+	if last := int(math.Round((-min*2)/step)) - 1; i > last {
+		i = last
+	}
+
+	return i
+}
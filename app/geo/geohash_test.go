@@ -0,0 +1,54 @@
+package geo_test
+
+import (
+	"ot-recorder/app/geo"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeohash(t *testing.T) {
+	assert.Equal(t, "ezs42", geo.Geohash(42.6, -5.6, 5))
+	assert.Equal(t, "u4pruydqq", geo.Geohash(57.64911, 10.40744, 9))
+	assert.Equal(t, "wh0r3", geo.Geohash(23.8103, 90.4125, 5))
+}
+
+func TestBoundingBox(t *testing.T) {
+	box := geo.BoundingBox(23.8103, 90.4125, 1000)
+
+	assert.InDelta(t, 1000, geo.Distance(23.8103, 90.4125, box.MaxLat, 90.4125), 1)
+	assert.InDelta(t, 1000, geo.Distance(23.8103, 90.4125, 23.8103, box.MinLon), 5)
+	assert.True(t, box.Contains(23.8103, 90.4125))
+	assert.False(t, box.Contains(23.83, 90.4125))
+
+	polar := geo.BoundingBox(89.999, 0, 1000)
+	assert.Equal(t, 90.0, polar.MaxLat)
+	assert.Equal(t, -180.0, polar.MinLon)
+}
+
+func TestGeohashCover(t *testing.T) {
+	box := geo.BoundingBox(23.8103, 90.4125, 500)
+	cover := geo.GeohashCover(box, 16)
+
+	assert.NotEmpty(t, cover)
+	assert.LessOrEqual(t, len(cover), 16)
+
+	// corners & center are covered
+	for _, c := range [][2]float64{
+		{box.MinLat, box.MinLon}, {box.MinLat, box.MaxLon}, {box.MaxLat, box.MinLon}, {box.MaxLat, box.MaxLon},
+		{23.8103, 90.4125},
+	} {
+		hash := geo.Geohash(c[0], c[1], geo.GeohashPrecision)
+
+		covered := false
+		for _, prefix := range cover {
+			covered = covered || strings.HasPrefix(hash, prefix)
+		}
+
+		assert.True(t, covered, hash)
+	}
+
+	world := geo.GeohashCover(geo.Box{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}, 16)
+	assert.Len(t, world, 32)
+}
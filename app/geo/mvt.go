@@ -0,0 +1,276 @@
+package geo
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// GeomType geometry type of a vector tile feature
+type GeomType int
+
+const (
+	GeomPoint      GeomType = 1
+	GeomLineString GeomType = 2
+)
+
+// vector tile protobuf fields & wire types, see https://github.com/mapbox/vector-tile-spec/tree/master/2.1
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+
+	tileLayers = 3
+
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+	layerVersion  = 15
+
+	featureID       = 1
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+	valueDouble = 3
+	valueInt    = 4
+	valueBool   = 7
+
+	cmdMoveTo = 1
+	cmdLineTo = 2
+
+	mvtVersion = 2
+)
+
+// Feature a point or a line string in tile coordinates, Properties values are strings,
+// integers, floats or booleans
+type Feature struct {
+	ID         uint64
+	Type       GeomType
+	Geometry   [][2]int
+	Properties map[string]interface{}
+}
+
+// Layer named features of a vector tile
+type Layer struct {
+	Name     string
+	Features []Feature
+}
+
+// EncodeMVT encodes layers as a Mapbox Vector Tile, line strings with less than two distinct
+// points are left out
+func EncodeMVT(layers []Layer) []byte {
+	var tile pbuf
+
+	for _, l := range layers {
+		tile.bytes(tileLayers, encodeLayer(l))
+	}
+
+	return tile
+}
+
+func encodeLayer(l Layer) []byte {
+	var (
+		layer    pbuf
+		keys     = map[string]uint32{}
+		values   = map[interface{}]uint32{}
+		vbufs    [][]byte
+		keyNames []string
+	)
+
+	layer.varint(layerVersion, mvtVersion)
+	layer.bytes(layerName, []byte(l.Name))
+
+	for _, f := range l.Features {
+		geometry := encodeGeometry(f.Type, f.Geometry)
+		if geometry == nil {
+			continue
+		}
+
+		names := make([]string, 0, len(f.Properties))
+		for k := range f.Properties {
+			names = append(names, k)
+		}
+
+		// stable keys & values make the same features give the same tile
+		sort.Strings(names)
+
+		tags := make([]uint32, 0, 2*len(names))
+
+		for _, k := range names {
+			v, ok := encodeValue(f.Properties[k])
+			if !ok {
+				continue
+			}
+
+			ki, ok := keys[k]
+			if !ok {
+				ki = uint32(len(keyNames))
+				keys[k] = ki
+				keyNames = append(keyNames, k)
+			}
+
+			vk := valueKey(f.Properties[k])
+
+			vi, ok := values[vk]
+			if !ok {
+				vi = uint32(len(vbufs))
+				values[vk] = vi
+				vbufs = append(vbufs, v)
+			}
+
+			tags = append(tags, ki, vi)
+		}
+
+		var feature pbuf
+		if f.ID != 0 {
+			feature.varint(featureID, f.ID)
+		}
+
+		if len(tags) > 0 {
+			feature.packed(featureTags, tags)
+		}
+
+		feature.varint(featureType, uint64(f.Type))
+		feature.packed(featureGeometry, geometry)
+
+		layer.bytes(layerFeatures, feature)
+	}
+
+	for _, k := range keyNames {
+		layer.bytes(layerKeys, []byte(k))
+	}
+
+	for _, v := range vbufs {
+		layer.bytes(layerValues, v)
+	}
+
+	layer.varint(layerExtent, TileExtent)
+
+	return layer
+}
+
+func encodeGeometry(t GeomType, points [][2]int) []uint32 {
+	switch t {
+	case GeomPoint:
+		if len(points) == 0 {
+			return nil
+		}
+
+		return []uint32{command(cmdMoveTo, 1), zigzag(points[0][0]), zigzag(points[0][1])}
+	case GeomLineString:
+		geometry := []uint32{command(cmdMoveTo, 1), 0, 0, 0}
+		cursor := [2]int{}
+		count := 0
+
+		for i, p := range points {
+			if i > 0 && p == cursor {
+				continue
+			}
+
+			if i == 0 {
+				geometry[1], geometry[2] = zigzag(p[0]), zigzag(p[1])
+			} else {
+				geometry = append(geometry, zigzag(p[0]-cursor[0]), zigzag(p[1]-cursor[1]))
+				count++
+			}
+
+			cursor = p
+		}
+
+		if count == 0 {
+			return nil
+		}
+
+		geometry[3] = command(cmdLineTo, count)
+
+		return geometry
+	default:
+		return nil
+	}
+}
+
+// valueKey dedupes values by type and value, so 1 and 1.0 stay different values
+func valueKey(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+func encodeValue(v interface{}) ([]byte, bool) {
+	var value pbuf
+
+	switch v := v.(type) {
+	case string:
+		value.bytes(valueString, []byte(v))
+	case int:
+		value.varint(valueInt, uint64(v))
+	case int64:
+		value.varint(valueInt, uint64(v))
+	case float32:
+		value.fixed64(valueDouble, math.Float64bits(float64(v)))
+	case float64:
+		value.fixed64(valueDouble, math.Float64bits(v))
+	case bool:
+		b := uint64(0)
+		if v {
+			b = 1
+		}
+
+		value.varint(valueBool, b)
+	default:
+		return nil, false
+	}
+
+	return value, true
+}
+
+func command(id, count int) uint32 {
+	return uint32(id&0x7) | uint32(count)<<3
+}
+
+func zigzag(n int) uint32 {
+	v := int32(n)
+
+	return uint32((v << 1) ^ (v >> 31))
+}
+
+// pbuf appends protobuf fields
+type pbuf []byte
+
+func (b *pbuf) key(field, wire int) {
+	*b = binary.AppendUvarint(*b, uint64(field<<3|wire))
+}
+
+func (b *pbuf) varint(field int, v uint64) {
+	b.key(field, wireVarint)
+	*b = binary.AppendUvarint(*b, v)
+}
+
+func (b *pbuf) fixed64(field int, v uint64) {
+	b.key(field, wireFixed64)
+	*b = binary.LittleEndian.AppendUint64(*b, v)
+}
+
+func (b *pbuf) bytes(field int, v []byte) {
+	b.key(field, wireBytes)
+	*b = binary.AppendUvarint(*b, uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *pbuf) packed(field int, values []uint32) {
+	var p []byte
+	for _, v := range values {
+		p = binary.AppendUvarint(p, uint64(v))
+	}
+
+	b.bytes(field, p)
+}
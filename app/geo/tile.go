@@ -0,0 +1,66 @@
+package geo
+
+import "math"
+
+const (
+	// TileExtent tile coordinates of vector tiles run from 0 to TileExtent
+	TileExtent = 4096
+	// MaxZoom deepest zoom level of slippy map tiles
+	MaxZoom = 22
+
+	maxMercatorLat = 85.0511287798
+)
+
+// Tile a slippy map(web mercator) tile
+type Tile struct {
+	Z int
+	X int
+	Y int
+}
+
+// Valid reports whether the tile exists at its zoom level
+func (t Tile) Valid() bool {
+	if t.Z < 0 || t.Z > MaxZoom {
+		return false
+	}
+
+	n := 1 << t.Z
+
+	return t.X >= 0 && t.X < n && t.Y >= 0 && t.Y < n
+}
+
+// Bounds the box covered by the tile, buffered by buffer tile coordinates on every side
+func (t Tile) Bounds(buffer int) Box {
+	n := float64(int(1) << t.Z)
+	b := float64(buffer) / TileExtent
+
+	box := Box{
+		MinLat: tileLat(float64(t.Y)+1+b, n),
+		MinLon: (float64(t.X)-b)/n*360 - 180,
+		MaxLat: tileLat(float64(t.Y)-b, n),
+		MaxLon: (float64(t.X)+1+b)/n*360 - 180,
+	}
+
+	box.MinLon = math.Max(box.MinLon, -180)
+	box.MaxLon = math.Min(box.MaxLon, 180)
+
+	return box
+}
+
+// Project the tile coordinate of a coordinate, those outside the tile are below 0 or above TileExtent
+func (t Tile) Project(lat, lon float64) (x, y int) {
+	n := float64(int(1) << t.Z)
+	lat = math.Max(math.Min(lat, maxMercatorLat), -maxMercatorLat)
+	rLat := radians(lat)
+
+	wx := (lon + 180) / 360 * n
+	wy := (1 - math.Log(math.Tan(rLat)+1/math.Cos(rLat))/math.Pi) / 2 * n
+
+	return int(math.Round((wx - float64(t.X)) * TileExtent)), int(math.Round((wy - float64(t.Y)) * TileExtent))
+}
+
+func tileLat(y, n float64) float64 {
+	y = math.Max(math.Min(y, n), 0)
+
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
@@ -0,0 +1,84 @@
+package geo_test
+
+import (
+	"ot-recorder/app/geo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTile(t *testing.T) {
+	world := geo.Tile{}
+	assert.True(t, world.Valid())
+
+	x, y := world.Project(0, 0)
+	assert.Equal(t, geo.TileExtent/2, x)
+	assert.Equal(t, geo.TileExtent/2, y)
+
+	box := world.Bounds(0)
+	assert.InDelta(t, 85.0511, box.MaxLat, 0.0001)
+	assert.InDelta(t, -85.0511, box.MinLat, 0.0001)
+	assert.Equal(t, -180.0, box.MinLon)
+	assert.Equal(t, 180.0, box.MaxLon)
+
+	// Dhaka at zoom 12
+	tile := geo.Tile{Z: 12, X: 3076, Y: 1768}
+	assert.True(t, tile.Valid())
+	assert.True(t, tile.Bounds(0).Contains(23.8103, 90.4125))
+
+	x, y = tile.Project(23.8103, 90.4125)
+	assert.True(t, x >= 0 && x <= geo.TileExtent)
+	assert.True(t, y >= 0 && y <= geo.TileExtent)
+
+	buffered := tile.Bounds(256)
+	assert.Less(t, buffered.MinLat, tile.Bounds(0).MinLat)
+	assert.Greater(t, buffered.MaxLon, tile.Bounds(0).MaxLon)
+
+	assert.False(t, geo.Tile{Z: 1, X: 2}.Valid())
+	assert.False(t, geo.Tile{Z: geo.MaxZoom + 1}.Valid())
+}
+
+func TestEncodeMVT(t *testing.T) {
+	tile := geo.EncodeMVT([]geo.Layer{{
+		Name:     "p",
+		Features: []geo.Feature{{Type: geo.GeomPoint, Geometry: [][2]int{{25, 17}}}},
+	}})
+
+	assert.Equal(t, []byte{
+		0x1a, 0x11, // layer
+		0x78, 0x02, // version 2
+		0x0a, 0x01, 'p', // name
+		0x12, 0x07, 0x18, 0x01, 0x22, 0x03, 0x09, 0x32, 0x22, // point feature, MoveTo(25, 17)
+		0x28, 0x80, 0x20, // extent 4096
+	}, tile)
+}
+
+func TestEncodeMVTLineString(t *testing.T) {
+	tile := geo.EncodeMVT([]geo.Layer{{
+		Name: "l",
+		Features: []geo.Feature{
+			{
+				ID:         7,
+				Type:       geo.GeomLineString,
+				Geometry:   [][2]int{{2, 2}, {2, 10}, {2, 10}, {10, 10}},
+				Properties: map[string]interface{}{"device": "phone"},
+			},
+			// a single distinct point is no line
+			{Type: geo.GeomLineString, Geometry: [][2]int{{1, 1}, {1, 1}}},
+		},
+	}})
+
+	assert.Equal(t, []byte{
+		0x1a, 0x2d,
+		0x78, 0x02,
+		0x0a, 0x01, 'l',
+		0x12, 0x12,
+		0x08, 0x07, // id
+		0x12, 0x02, 0x00, 0x00, // tags device=phone
+		0x18, 0x02,
+		0x22, 0x08, 0x09, 0x04, 0x04, 0x12, 0x00, 0x10, 0x10, 0x00, // MoveTo(2, 2) LineTo(2, 10)(10, 10)
+		0x1a, 0x06, 'd', 'e', 'v', 'i', 'c', 'e',
+		0x22, 0x07, 0x0a, 0x05, 'p', 'h', 'o', 'n', 'e',
+		0x28, 0x80, 0x20,
+	}, tile)
+}
@@ -0,0 +1,152 @@
+// Package locale formats times, units and labels of locations per the preferences of a user
+package locale
+
+import (
+	"fmt"
+	"math"
+	"ot-recorder/app/model"
+	"time"
+)
+
+const (
+	dateTime24h = "2006-01-02 15:04:05"
+	dateTime12h = "2006-01-02 03:04:05 PM"
+
+	mphPerKmh    = 0.621371
+	feetPerMeter = 3.28084
+
+	// DefaultLanguage language of the labels when the user has none or an unknown one
+	DefaultLanguage = "en"
+)
+
+// labels of telegram replies by language
+//
+//nolint:gochecknoglobals
+var labels = map[string]map[string]string{
+	"en": {
+		"username": "Username", "device": "Device", "datetime": "DateTime", "latitude": "Latitude",
+		"longitude": "Longitude", "place": "Place", "at": "at", "accuracy": "Accuracy", "altitude": "Altitude",
+		"battery": "BatteryLevel", "mode": "Mode", "speed": "Speed", "map": "View in map",
+	},
+	"de": {
+		"username": "Benutzer", "device": "Gerät", "datetime": "Zeit", "latitude": "Breitengrad",
+		"longitude": "Längengrad", "place": "Ort", "at": "bei", "accuracy": "Genauigkeit", "altitude": "Höhe",
+		"battery": "Akku", "mode": "Modus", "speed": "Geschwindigkeit", "map": "Auf der Karte ansehen",
+	},
+	"es": {
+		"username": "Usuario", "device": "Dispositivo", "datetime": "Fecha", "latitude": "Latitud",
+		"longitude": "Longitud", "place": "Lugar", "at": "en", "accuracy": "Precisión", "altitude": "Altitud",
+		"battery": "Batería", "mode": "Modo", "speed": "Velocidad", "map": "Ver en el mapa",
+	},
+	"fr": {
+		"username": "Utilisateur", "device": "Appareil", "datetime": "Date", "latitude": "Latitude",
+		"longitude": "Longitude", "place": "Lieu", "at": "à", "accuracy": "Précision", "altitude": "Altitude",
+		"battery": "Batterie", "mode": "Mode", "speed": "Vitesse", "map": "Voir sur la carte",
+	},
+}
+
+// Supported tells whether labels of the language exist
+func Supported(language string) bool {
+	_, ok := labels[language]
+	return ok
+}
+
+// WithDefaults fills the unset preferences, timeZone is the one of the server
+func WithDefaults(pref model.Preference, timeZone string) model.Preference {
+	if pref.TimeZone == "" {
+		pref.TimeZone = timeZone
+	}
+
+	if pref.Units == "" {
+		pref.Units = model.UnitsMetric
+	}
+
+	if pref.Clock == "" {
+		pref.Clock = model.Clock24h
+	}
+
+	if pref.Language == "" {
+		pref.Language = DefaultLanguage
+	}
+
+	return pref
+}
+
+// Printer formats values per the preferences of a user
+type Printer struct {
+	loc      *time.Location
+	imperial bool
+	clock12h bool
+	language string
+}
+
+// New a printer for preferences with defaults, an unknown time zone is UTC
+func New(pref model.Preference) Printer {
+	loc, err := time.LoadLocation(pref.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	language := pref.Language
+	if !Supported(language) {
+		language = DefaultLanguage
+	}
+
+	return Printer{
+		loc:      loc,
+		imperial: pref.Units == model.UnitsImperial,
+		clock12h: pref.Clock == model.Clock12h,
+		language: language,
+	}
+}
+
+// DateTime unix seconds as local date & time, like 2022-11-22 21:34:55 or 2022-11-22 09:34:55 PM
+func (p Printer) DateTime(epoch int64) string {
+	layout := dateTime24h
+	if p.clock12h {
+		layout = dateTime12h
+	}
+
+	return time.Unix(epoch, 0).In(p.loc).Format(layout)
+}
+
+// ISO unix seconds as ISO-8601 local time with the offset, like 2022-11-22T21:34:55+06:00
+func (p Printer) ISO(epoch int64) string {
+	return time.Unix(epoch, 0).In(p.loc).Format(time.RFC3339)
+}
+
+// Speed km/h as km/h or mph
+func (p Printer) Speed(kmh int16) string {
+	if p.imperial {
+		return fmt.Sprintf("%.0f mph", math.Round(float64(kmh)*mphPerKmh))
+	}
+
+	return fmt.Sprintf("%d km/h", kmh)
+}
+
+// Length meters as meters or feet
+func (p Printer) Length(meters int16) string {
+	if p.imperial {
+		return fmt.Sprintf("%.0f ft", math.Round(float64(meters)*feetPerMeter))
+	}
+
+	return fmt.Sprintf("%d m", meters)
+}
+
+// Percent with the spacing of the language, 40% or 40 %
+func (p Printer) Percent(v int8) string {
+	if p.language == "de" || p.language == "fr" {
+		return fmt.Sprintf("%d %%", v)
+	}
+
+	return fmt.Sprintf("%d%%", v)
+}
+
+// Label of key in the language, the english one when it has none
+func (p Printer) Label(key string) string {
+	if label, ok := labels[p.language][key]; ok {
+		return label
+	}
+
+	return labels[DefaultLanguage][key]
+}
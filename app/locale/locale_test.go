@@ -0,0 +1,44 @@
+package locale_test
+
+import (
+	"ot-recorder/app/locale"
+	"ot-recorder/app/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDefaults(t *testing.T) {
+	pref := locale.WithDefaults(model.Preference{Username: "dev", Units: model.UnitsImperial}, "Asia/Dhaka")
+	assert.Equal(t, model.Preference{
+		Username: "dev", TimeZone: "Asia/Dhaka", Units: model.UnitsImperial, Clock: model.Clock24h, Language: "en",
+	}, pref)
+}
+
+func TestPrinter(t *testing.T) {
+	const epoch = 1669131295 // 2022-11-22 15:34:55 UTC
+
+	p := locale.New(locale.WithDefaults(model.Preference{}, "UTC"))
+	assert.Equal(t, "2022-11-22 15:34:55", p.DateTime(epoch))
+	assert.Equal(t, "2022-11-22T15:34:55Z", p.ISO(epoch))
+	assert.Equal(t, "12 km/h", p.Speed(12))
+	assert.Equal(t, "13 m", p.Length(13))
+	assert.Equal(t, "40%", p.Percent(40))
+	assert.Equal(t, "Username", p.Label("username"))
+
+	p = locale.New(model.Preference{TimeZone: "Asia/Dhaka", Units: model.UnitsImperial, Clock: model.Clock12h,
+		Language: "de"})
+	assert.Equal(t, "2022-11-22 09:34:55 PM", p.DateTime(epoch))
+	assert.Equal(t, "2022-11-22T21:34:55+06:00", p.ISO(epoch))
+	assert.Equal(t, "7 mph", p.Speed(12))
+	assert.Equal(t, "43 ft", p.Length(13))
+	assert.Equal(t, "40 %", p.Percent(40))
+	assert.Equal(t, "Benutzer", p.Label("username"))
+
+	// unknown time zones & languages fall back
+	p = locale.New(model.Preference{TimeZone: "Mars/Olympus", Language: "xx"})
+	assert.Equal(t, "2022-11-22T15:34:55Z", p.ISO(epoch))
+	assert.Equal(t, "Username", p.Label("username"))
+	assert.False(t, locale.Supported("xx"))
+	assert.True(t, locale.Supported("fr"))
+}
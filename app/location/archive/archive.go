@@ -0,0 +1,156 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"ot-recorder/app/model"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	dirMode    = 0o750
+	fileMode   = 0o600
+	dayLayout  = "2006-01-02"
+	fileSuffix = ".ndjson.gz"
+)
+
+// Archive is an append-only archive of received payloads in gzipped daily files of newline
+// delimited JSON, named by the UTC day they were received. Every payload is written as its own
+// gzip member, so a crash loses at most the payload being written and the files stay readable.
+type Archive struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// New creates dir when it doesn't exist
+func New(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return nil, err
+	}
+
+	return &Archive{dir: dir}, nil
+}
+
+// Append writes the payload to the file of the day it was received, a body which isn't
+// valid JSON is kept as a JSON string
+func (a *Archive) Append(_ context.Context, payload *model.Payload) error {
+	p := *payload
+	if !json.Valid(p.Body) {
+		body, err := json.Marshal(string(p.Body))
+		if err != nil {
+			return err
+		}
+
+		p.Body = body
+	}
+
+	line, err := json.Marshal(&p)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(a.file(time.Unix(payload.ReceivedAt, 0)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, fileMode)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// Read calls fn with the payloads received between from & to in the order they were archived,
+// days without a file are skipped. It stops at the first error of fn.
+func (a *Archive) Read(from, to time.Time, fn func(payload *model.Payload) error) error {
+	from, to = from.UTC(), to.UTC()
+
+	for day := from.Truncate(24 * time.Hour); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if err := a.readFile(a.file(day), from.Unix(), to.Unix(), fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *Archive) readFile(name string, from, to int64, fn func(payload *model.Payload) error) error {
+	f, err := os.Open(name) //nolint:gosec
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer zr.Close()
+
+	reader := bufio.NewReader(zr)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.ErrUnexpectedEOF) || (errors.Is(err, io.EOF) && len(line) > 0) {
+			// last payload of a crash may be partially written
+			logrus.Warnf("archive: skip truncated payload at the end of %s", name)
+			return nil
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		var payload model.Payload
+		if err := json.Unmarshal(line, &payload); err != nil {
+			logrus.Warnf("archive: skip invalid payload in %s: %v", name, err)
+			continue
+		}
+
+		if payload.ReceivedAt < from || payload.ReceivedAt > to {
+			continue
+		}
+
+		if err := fn(&payload); err != nil {
+			return err
+		}
+	}
+}
+
+func (a *Archive) file(day time.Time) string {
+	return filepath.Join(a.dir, day.UTC().Format(dayLayout)+fileSuffix)
+}
@@ -0,0 +1,85 @@
+package archive_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"ot-recorder/app/location/archive"
+	"ot-recorder/app/model"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchive(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+
+	a, err := archive.New(dir)
+	assert.NoError(t, err)
+
+	day := time.Date(2024, 3, 9, 23, 59, 0, 0, time.UTC)
+	payloads := []*model.Payload{
+		{ReceivedAt: day.Unix(), Channel: model.ChannelPing, Username: "dev", Device: "phone", IP: "127.0.0.1",
+			Body: json.RawMessage(`{"_type":"location","tst":1,"lat":23.1,"lon":90.1}`)},
+		{ReceivedAt: day.Unix() + 30, Channel: model.ChannelPingBatch, Username: "dev", Device: "phone",
+			Body: []byte("{\"_type\":\"location\"}\n{\"_type\":\"location\"}\n")},
+		{ReceivedAt: day.Unix() + 120, Channel: model.ChannelPing, Username: "mom", Device: "tablet",
+			Body: json.RawMessage(`{"_type":"transition"}`)},
+	}
+
+	for _, p := range payloads {
+		assert.NoError(t, a.Append(context.TODO(), p))
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.ndjson.gz"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "2024-03-09.ndjson.gz"),
+		filepath.Join(dir, "2024-03-10.ndjson.gz"),
+	}, files)
+
+	read := func(from, to time.Time) []*model.Payload {
+		var got []*model.Payload
+		assert.NoError(t, a.Read(from, to, func(p *model.Payload) error {
+			got = append(got, p)
+			return nil
+		}))
+
+		return got
+	}
+
+	t.Run("every day", func(t *testing.T) {
+		got := read(day.Add(-48*time.Hour), day.Add(48*time.Hour))
+		assert.Len(t, got, 3)
+		assert.Equal(t, "dev", got[0].Username)
+		assert.JSONEq(t, string(payloads[0].Body), string(got[0].Body))
+		// not valid JSON, kept as a string
+		assert.JSONEq(t, `"{\"_type\":\"location\"}\n{\"_type\":\"location\"}\n"`, string(got[1].Body))
+		assert.Equal(t, "tablet", got[2].Device)
+	})
+
+	t.Run("between", func(t *testing.T) {
+		got := read(day.Add(time.Second), day.Add(time.Minute))
+		assert.Len(t, got, 1)
+		assert.Equal(t, model.ChannelPingBatch, got[0].Channel)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		name := filepath.Join(dir, "2024-03-10.ndjson.gz")
+		before, err := os.Stat(name)
+		assert.NoError(t, err)
+
+		assert.NoError(t, a.Append(context.TODO(), &model.Payload{ReceivedAt: day.Unix() + 180,
+			Channel: model.ChannelPing, Body: json.RawMessage(`{"_type":"location","tst":2,"lat":23.2,"lon":90.2}`)}))
+		after, err := os.Stat(name)
+		assert.NoError(t, err)
+
+		// a crash while the last payload was written
+		assert.NoError(t, os.Truncate(name, before.Size()+(after.Size()-before.Size())/2))
+
+		got := read(day, day.Add(48*time.Hour))
+		assert.Len(t, got, 3)
+	})
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"errors"
+	"ot-recorder/app/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultHistoryBucket = 15 * time.Minute
+	minHistoryBucket     = time.Minute
+	maxHistoryBucket     = 24 * time.Hour
+)
+
+// BatteryHistory returns the battery levels & status of a user per device in buckets(default 15m)
+func (u *LocationHandler) BatteryHistory(c echo.Context) error {
+	query, err := parseLocationQuery(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	bucket, err := parseBucket(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	buckets, err := u.LUseCase.BatteryHistory(c.Request().Context(), query, bucket)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", buckets))
+}
+
+// NetworkHistory returns the wifi & ip of a user per device in buckets(default 15m)
+func (u *LocationHandler) NetworkHistory(c echo.Context) error {
+	query, err := parseLocationQuery(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	bucket, err := parseBucket(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	buckets, err := u.LUseCase.NetworkHistory(c.Request().Context(), query, bucket)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", buckets))
+}
+
+// parseBucket reads the bucket query param, a duration like 5m or 1h, in seconds
+func parseBucket(c echo.Context) (int64, error) {
+	bucket := defaultHistoryBucket
+
+	if v := c.QueryParam("bucket"); v != "" {
+		var err error
+		if bucket, err = time.ParseDuration(v); err != nil || bucket < minHistoryBucket || bucket > maxHistoryBucket {
+			return 0, errors.New("bucket must be a duration between 1m and 24h")
+		}
+	}
+
+	return int64(bucket.Seconds()), nil
+}
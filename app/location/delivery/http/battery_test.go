@@ -0,0 +1,66 @@
+package http_test
+
+import (
+	"net/http"
+	lHttp "ot-recorder/app/location/delivery/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatteryHistory(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("BatteryHistory", mock.Anything, model.LocationQuery{
+			Username: "dev", Device: "phone", From: 100, To: 7300, Limit: 5000,
+		}, int64(3600)).Return([]model.BatteryBucket{
+			{Device: "phone", From: 0, Points: 3, Min: 38, Max: 40, Avg: 39, Unplugged: 3},
+		}, nil).Once()
+
+		c, rec := buildEchoRequest(t, BaseURLV1+"/history/battery?username=dev&device=phone&from=100&to=7300&bucket=1h",
+			echo.GET, nil, false, "")
+
+		handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+		assert.NoError(t, handler.BatteryHistory(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(),
+			`{"device":"phone","from":0,"points":3,"min":38,"max":40,"avg":39,"unplugged":3,"charging":0}`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	for name, query := range map[string]string{
+		"username missing": "?bucket=1h",
+		"invalid bucket":   "?username=dev&bucket=hourly",
+		"bucket too small": "?username=dev&bucket=30s",
+		"bucket too big":   "?username=dev&bucket=48h",
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, rec := buildEchoRequest(t, BaseURLV1+"/history/battery"+query, echo.GET, nil, false, "")
+
+			handler := lHttp.LocationHandler{LUseCase: new(mocks.LocationUsecase)}
+			assert.NoError(t, handler.BatteryHistory(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestNetworkHistory(t *testing.T) {
+	mockUsecase := new(mocks.LocationUsecase)
+	mockUsecase.On("NetworkHistory", mock.Anything, mock.MatchedBy(func(q model.LocationQuery) bool {
+		return q.Username == "dev" && q.To-q.From == 24*60*60
+	}), int64(900)).Return([]model.NetworkBucket{
+		{Device: "phone", WifiName: "home", IPAddress: "10.0.0.2", Points: 4, FirstSeen: 60, LastSeen: 600},
+	}, nil).Once()
+
+	c, rec := buildEchoRequest(t, BaseURLV1+"/history/network?username=dev", echo.GET, nil, false, "")
+
+	handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+	assert.NoError(t, handler.NetworkHistory(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"wifi_name":"home","wifi_mac":"","ip_address":"10.0.0.2","points":4`)
+	mockUsecase.AssertExpectations(t)
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"errors"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultHistoryRange = 24 * time.Hour
+	defaultHistoryLimit = 5000
+	maxHistoryLimit     = 50000
+)
+
+// History returns the track of a user, optionally of one device, between from & to(unix seconds)
+func (u *LocationHandler) History(c echo.Context) error {
+	query, err := parseLocationQuery(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	points, err := u.LUseCase.History(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", points))
+}
+
+// Trips returns the movements of a user between stays
+func (u *LocationHandler) Trips(c echo.Context) error {
+	query, err := parseLocationQuery(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	trips, err := u.LUseCase.Trips(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", trips))
+}
+
+// Stays returns where a user stayed for a while
+func (u *LocationHandler) Stays(c echo.Context) error {
+	query, err := parseLocationQuery(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	stays, err := u.LUseCase.Stays(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", stays))
+}
+
+// parseLocationQuery reads username, device, from, to & limit query params,
+// the default range is the last day
+func parseLocationQuery(c echo.Context) (model.LocationQuery, error) {
+	query := model.LocationQuery{
+		Username: c.QueryParam("username"),
+		Device:   c.QueryParam("device"),
+	}
+
+	if query.Username == "" {
+		return query, errors.New("username is required")
+	}
+
+	var err error
+	query.From, query.To, query.Limit, err = parseRangeAndLimit(c)
+
+	return query, err
+}
+
+// parseRangeAndLimit reads from, to(unix seconds, default the last day) & limit query params
+func parseRangeAndLimit(c echo.Context) (from, to int64, limit int, err error) {
+	to = time.Now().Unix()
+	limit = defaultHistoryLimit
+
+	if v := c.QueryParam("to"); v != "" {
+		if to, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, 0, errors.New("to must be unix seconds")
+		}
+	}
+
+	from = to - int64(defaultHistoryRange.Seconds())
+
+	if v := c.QueryParam("from"); v != "" {
+		if from, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, 0, errors.New("from must be unix seconds")
+		}
+	}
+
+	if from > to {
+		return 0, 0, 0, errors.New("from must be before to")
+	}
+
+	if v := c.QueryParam("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 || limit > maxHistoryLimit {
+			return 0, 0, 0, errors.New("limit must be between 1 and " + strconv.Itoa(maxHistoryLimit))
+		}
+	}
+
+	return from, to, limit, nil
+}
@@ -0,0 +1,83 @@
+package http_test
+
+import (
+	"errors"
+	"net/http"
+	lHttp "ot-recorder/app/location/delivery/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("History", mock.Anything, model.LocationQuery{
+			Username: "dev", Device: "phone", From: 100, To: 200, Limit: 10,
+		}).Return([]model.TrackPoint{{Device: "phone", CreatedAt: 150, Lat: 23, Lon: 90}}, nil).Once()
+
+		c, rec := buildEchoRequest(t, BaseURLV1+"/history?username=dev&device=phone&from=100&to=200&limit=10",
+			echo.GET, nil, false, "")
+
+		handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+		assert.NoError(t, handler.History(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"created_at":150`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("default range is the last day", func(t *testing.T) {
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("History", mock.Anything, mock.MatchedBy(func(q model.LocationQuery) bool {
+			return q.To-q.From == 24*60*60 && q.Limit == 5000 && q.Device == ""
+		})).Return([]model.TrackPoint{}, nil).Once()
+
+		c, rec := buildEchoRequest(t, BaseURLV1+"/history?username=dev", echo.GET, nil, false, "")
+
+		handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+		assert.NoError(t, handler.History(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	for name, query := range map[string]string{
+		"username missing": "?from=1",
+		"invalid from":     "?username=dev&from=yesterday",
+		"from after to":    "?username=dev&from=200&to=100",
+		"limit too big":    "?username=dev&limit=1000000",
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, rec := buildEchoRequest(t, BaseURLV1+"/history"+query, echo.GET, nil, false, "")
+
+			handler := lHttp.LocationHandler{LUseCase: new(mocks.LocationUsecase)}
+			assert.NoError(t, handler.History(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestTripsAndStays(t *testing.T) {
+	mockUsecase := new(mocks.LocationUsecase)
+	mockUsecase.On("Trips", mock.Anything, mock.AnythingOfType("model.LocationQuery")).
+		Return([]model.Trip{{Device: "phone", Distance: 1200}}, nil).Once()
+	mockUsecase.On("Stays", mock.Anything, mock.AnythingOfType("model.LocationQuery")).
+		Return(nil, response.WrapError(errors.New("internal server error"), http.StatusInternalServerError)).Once()
+
+	handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+
+	c, rec := buildEchoRequest(t, BaseURLV1+"/trips?username=dev", echo.GET, nil, false, "")
+	assert.NoError(t, handler.Trips(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"distance":1200`)
+
+	c, rec = buildEchoRequest(t, BaseURLV1+"/stays?username=dev", echo.GET, nil, false, "")
+	assert.NoError(t, handler.Stays(c))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	mockUsecase.AssertExpectations(t)
+}
@@ -1,31 +1,70 @@
 package http
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"ot-recorder/app/model"
 	"ot-recorder/app/response"
 	"ot-recorder/app/validation"
 	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/metrics"
+	"ot-recorder/infrastructure/tracing"
+	"time"
 
-	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/labstack/echo/v4"
 )
 
+var tracer = otel.Tracer("ot-recorder/app/location/delivery/http") //nolint:gochecknoglobals
+
 // LocationHandler represent the http handler for Location
 type LocationHandler struct {
 	LUseCase model.LocationUsecase
+	// Archive keeps received payloads for replay, nil when disabled
+	Archive model.PayloadArchive
+	// Commanders give the commands returned to devices in the response of their pings
+	Commanders []model.DeviceCommander
 }
 
-func NewUserHandler(e *echo.Echo, us model.LocationUsecase) {
+func NewUserHandler(
+	e *echo.Echo,
+	us model.LocationUsecase,
+	archive model.PayloadArchive,
+	commanders ...model.DeviceCommander,
+) {
 	handler := &LocationHandler{
-		LUseCase: us,
+		LUseCase:   us,
+		Archive:    archive,
+		Commanders: commanders,
 	}
 
 	v1 := e.Group("/api/v1")
 	v1.POST("/ping", handler.Ping)
+	v1.POST("/ping/batch", handler.PingBatch)
 	v1.GET("/last-location", handler.LastLocation)
+	v1.GET("/last-locations", handler.LastLocations)
+	v1.GET("/users", handler.Users)
+	v1.GET("/users/:user/devices", handler.Devices)
+	v1.GET("/history", handler.History)
+	v1.GET("/history/battery", handler.BatteryHistory)
+	v1.GET("/history/network", handler.NetworkHistory)
+	v1.GET("/trips", handler.Trips)
+	v1.GET("/stays", handler.Stays)
+	v1.GET("/locations/within", handler.Within)
+	v1.GET("/locations/nearby", handler.Nearby)
+	v1.GET("/heatmap", handler.Heatmap)
+	// y carries the .mvt extension, echo params run up to the next slash
+	v1.GET("/tiles/:z/:x/:y", handler.Tile)
+	v1.GET("/stream", handler.Stream)
+	v1.GET("/stream/ws", handler.StreamWS)
 
 	hooks := e.Group("/hooks")
 	hooks.POST("/telegram", handler.TelegramHook)
@@ -34,45 +73,267 @@ func NewUserHandler(e *echo.Echo, us model.LocationUsecase) {
 func (u *LocationHandler) Ping(c echo.Context) error {
 	req := c.Request()
 
-	if len(req.Header.Get("x-limit-u")) == 0 ||
-		len(req.Header.Get("x-limit-d")) == 0 ||
-		len(req.Header.Get("X-Real-IP")) == 0 {
-		msg := "[x-limit-u, x-limit-d, X-Real-IP] any one of these headers are missing!" +
-			"\n Set username & device id in app settings. Also set X-Real-IP header in Poxy settings"
+	ctx, span := tracer.Start(req.Context(), "LocationHandler.Ping")
+	defer span.End()
+
+	if err := checkPingHeaders(req.Header); err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	var (
+		pingReq PingRequest
+		raw     []byte
+		err     error
+	)
+
+	if u.Archive != nil || config.Get().App.StoreRaw {
+		if raw, err = keepBody(req); err == nil {
+			u.archive(ctx, model.ChannelPing, req.Header, raw)
+		}
+	}
 
-		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New(msg)))
+	_, bindSpan := tracer.Start(ctx, "bind")
+	if err == nil {
+		err = c.Bind(&pingReq)
 	}
+	tracing.End(bindSpan, err)
 
-	var pingReq PingRequest
-	err := c.Bind(&pingReq)
 	if err != nil {
 		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
 	}
 
+	span.SetAttributes(attribute.String("ping.type", pingReq.Type))
+	metrics.Pings.WithLabelValues(req.Header.Get("x-limit-u"), req.Header.Get("x-limit-d"), pingReq.Type).Inc()
+
 	if pingReq.Type == "location" {
-		if ok, err := validation.Validate(&pingReq); !ok {
+		_, validateSpan := tracer.Start(ctx, "validate")
+		ok, err := validation.Validate(&pingReq)
+		tracing.End(validateSpan, err)
+
+		if !ok {
+			metrics.ValidationFailures.WithLabelValues("ping").Inc()
+
 			valErrors, valErr := validation.FormatErrors(err)
 			if valErr != nil {
-				logrus.Error(valErr)
+				logger.FromContext(ctx).Error(valErr)
 				return c.JSON(response.RespondError(response.ErrBadRequest, valErr))
 			}
-			logrus.Error(valErrors)
+			logger.FromContext(ctx).Error(valErrors)
 
 			return c.JSON(response.RespondValidationError(response.ErrBadRequest, valErrors))
 		}
 
-		location := mapLocationRequestToModel(&pingReq, &req.Header)
+		location := mapLocationRequestToModel(&pingReq, &req.Header, raw)
 
-		ctx := c.Request().Context()
 		err = u.LUseCase.Ping(ctx, location)
 		if err != nil {
 			return c.JSON(response.RespondError(err))
 		}
 	}
 
+	if commands := u.commands(ctx, req.Header, pingReq.Tst); len(commands) > 0 {
+		return c.JSON(http.StatusOK, commands)
+	}
+
 	return c.JSON(response.RespondEmpty())
 }
 
+// commands returns the commands of the commanders for the device of the ping with time tst,
+// the OwnTracks HTTP mode takes a JSON array of messages in the response
+func (u *LocationHandler) commands(ctx context.Context, headers http.Header, tst int64) []interface{} {
+	var commands []interface{}
+	for _, commander := range u.Commanders {
+		commands = append(commands, commander.Commands(ctx, headers.Get("x-limit-u"), headers.Get("x-limit-d"), tst)...)
+	}
+
+	return commands
+}
+
+// PingBatch stores a JSON array or newline delimited JSON of OwnTracks messages in one
+// transaction and responds with the outcome of every message & the commands of the device
+func (u *LocationHandler) PingBatch(c echo.Context) error {
+	req := c.Request()
+
+	ctx, span := tracer.Start(req.Context(), "LocationHandler.PingBatch")
+	defer span.End()
+
+	if err := checkPingHeaders(req.Header); err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	var body io.Reader = req.Body
+
+	if u.Archive != nil {
+		raw, err := keepBody(req)
+		if err != nil {
+			return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+		}
+
+		u.archive(ctx, model.ChannelPingBatch, req.Header, raw)
+		body = bytes.NewReader(raw)
+	}
+
+	_, decodeSpan := tracer.Start(ctx, "decode")
+	items, err := decodeBatchPing(body, req.Header.Get(echo.HeaderContentType))
+	tracing.End(decodeSpan, err)
+
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+	}
+
+	if len(items) == 0 {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("batch is empty")))
+	}
+
+	span.SetAttributes(attribute.Int("batch.size", len(items)))
+
+	res := &BatchPingResponse{Results: make([]BatchItemResult, len(items))}
+
+	_, validateSpan := tracer.Start(ctx, "validate")
+	locations, indexes := validateBatchPing(items, &req.Header, res)
+	validateSpan.End()
+
+	if len(locations) > 0 {
+		results, err := u.LUseCase.PingBatch(ctx, locations)
+		if err != nil {
+			return c.JSON(response.RespondError(err))
+		}
+
+		for i, r := range results {
+			if errors.Is(r, model.ErrDuplicateLocation) {
+				res.set(indexes[i], BatchStatusDuplicate, nil)
+			} else {
+				res.set(indexes[i], BatchStatusCreated, nil)
+			}
+		}
+	}
+
+	// a resent batch has the same newest location, the commands are sent again for it
+	var tst int64
+	for _, l := range locations {
+		if l.CreatedAt > tst {
+			tst = l.CreatedAt
+		}
+	}
+
+	res.Commands = u.commands(ctx, req.Header, tst)
+
+	return c.JSON(response.RespondSuccess("request success", res))
+}
+
+// validateBatchPing returns valid locations with their index in the batch,
+// invalid and non location messages are recorded in the response
+func validateBatchPing(
+	items []json.RawMessage,
+	headers *http.Header,
+	res *BatchPingResponse,
+) (locations []*model.Location, indexes []int) {
+	for i, item := range items {
+		var pingReq PingRequest
+		if err := json.Unmarshal(item, &pingReq); err != nil {
+			metrics.ValidationFailures.WithLabelValues("ping_batch").Inc()
+			res.set(i, BatchStatusInvalid, err.Error())
+
+			continue
+		}
+
+		metrics.Pings.WithLabelValues(headers.Get("x-limit-u"), headers.Get("x-limit-d"), pingReq.Type).Inc()
+
+		if pingReq.Type != "location" {
+			res.set(i, BatchStatusSkipped, nil)
+			continue
+		}
+
+		if ok, err := validation.Validate(&pingReq); !ok {
+			metrics.ValidationFailures.WithLabelValues("ping_batch").Inc()
+
+			valErrors, valErr := validation.FormatErrors(err)
+			if valErr != nil {
+				res.set(i, BatchStatusInvalid, valErr.Error())
+			} else {
+				res.set(i, BatchStatusInvalid, valErrors)
+			}
+
+			continue
+		}
+
+		locations = append(locations, mapLocationRequestToModel(&pingReq, headers, item))
+		indexes = append(indexes, i)
+	}
+
+	return locations, indexes
+}
+
+// PayloadLocations maps an archived payload to its valid locations the way its channel did when
+// it was received, skipped is the number of other & invalid messages
+func PayloadLocations(payload *model.Payload) (locations []*model.Location, skipped int, err error) {
+	headers := http.Header{}
+	headers.Set("x-limit-u", payload.Username)
+	headers.Set("x-limit-d", payload.Device)
+	headers.Set("X-Real-IP", payload.IP)
+
+	var items []json.RawMessage
+
+	switch payload.Channel {
+	case model.ChannelPing:
+		items = []json.RawMessage{payload.Body}
+	case model.ChannelPingBatch:
+		var body string
+		if err := json.Unmarshal(payload.Body, &body); err == nil {
+			// kept as a string when it wasn't valid JSON, e.g. newline delimited
+			payload.Body = json.RawMessage(body)
+		}
+
+		if items, err = decodeBatchPing(bytes.NewReader(payload.Body), ""); err != nil {
+			return nil, 1, nil
+		}
+	default:
+		return nil, 0, fmt.Errorf("unknown channel %q", payload.Channel)
+	}
+
+	res := &BatchPingResponse{Results: make([]BatchItemResult, len(items))}
+	locations, _ = validateBatchPing(items, &headers, res)
+
+	return locations, res.Skipped + res.Invalid, nil
+}
+
+// archive appends the received body to the payload archive, failures are logged
+// & counted, so pings are stored even when the archive is not writable
+func (u *LocationHandler) archive(ctx context.Context, channel string, headers http.Header, body []byte) {
+	if u.Archive == nil {
+		return
+	}
+
+	ctx, span := tracer.Start(ctx, "archive")
+
+	err := u.Archive.Append(ctx, &model.Payload{
+		ReceivedAt: time.Now().Unix(),
+		Channel:    channel,
+		Username:   headers.Get("x-limit-u"),
+		Device:     headers.Get("x-limit-d"),
+		IP:         headers.Get("X-Real-IP"),
+		Body:       body,
+	})
+	tracing.End(span, err)
+
+	if err != nil {
+		metrics.ArchiveFailures.Inc()
+		logger.FromContext(ctx).Errorf("archive %s payload: %v", channel, err)
+	}
+}
+
+// keepBody reads the request body & puts it back for binding
+func keepBody(req *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
 func (u *LocationHandler) LastLocation(c echo.Context) error {
 	ctx := c.Request().Context()
 
@@ -98,7 +359,7 @@ func (u *LocationHandler) TelegramHook(c echo.Context) error {
 	var req model.TelegramRequest
 	err := c.Bind(&req)
 	if err != nil {
-		logrus.Error(err)
+		logger.FromContext(ctx).Error(err)
 		return c.JSON(response.RespondError(response.ErrUnprocessableEntity))
 	}
 
@@ -2,14 +2,18 @@ package http_test
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	lHttp "ot-recorder/app/location/delivery/http"
 	"ot-recorder/app/model"
 	"ot-recorder/app/model/mocks"
 	"ot-recorder/app/response"
 	"ot-recorder/infrastructure/config"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -17,6 +21,10 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 var BaseURLV1 = "/api/v1"
@@ -75,6 +83,203 @@ func TestPing(t *testing.T) {
 	})
 }
 
+func TestPingAllFields(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "config.yml")
+	assert.NoError(t, os.WriteFile(cfgFile, []byte("app:\n  store_raw: true\n"), 0o600))
+	assert.NoError(t, config.Load(cfgFile))
+
+	j := `{"_type":"location","_id":"3b0e1f2a","tst":1700000000,"created_at":1700000005,"lat":23.0,"lon":90.0,` +
+		`"cog":270,"rad":150,"p":100.93,"poi":"Cafe","tag":"lunch","conn":"w","topic":"owntracks/dev/phone",` +
+		`"inregions":["home"],"inrids":["5f2d8c"],"motionactivities":["walking"],"future":true}`
+
+	var location *model.Location
+
+	mockUsecase := new(mocks.LocationUsecase)
+	mockUsecase.On("Ping", mock.Anything, mock.AnythingOfType("*model.Location")).
+		Run(func(args mock.Arguments) { location = args.Get(1).(*model.Location) }).
+		Return(nil)
+
+	c, rec := buildEchoRequest(t, BaseURLV1+"/ping", echo.POST, strings.NewReader(j), true, "")
+
+	handler := lHttp.LocationHandler{
+		LUseCase: mockUsecase,
+	}
+	assert.NoError(t, handler.Ping(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, int16(270), location.Cog)
+	assert.Equal(t, int32(150), location.Rad)
+	assert.Equal(t, 100.93, location.P)
+	assert.Equal(t, "Cafe", location.Poi)
+	assert.Equal(t, "lunch", location.Tag)
+	assert.Equal(t, "w", location.Conn)
+	assert.Equal(t, "owntracks/dev/phone", location.Topic)
+	assert.Equal(t, "3b0e1f2a", location.MsgID)
+	assert.Equal(t, int64(1700000005), location.MsgCreatedAt)
+	assert.Equal(t, model.StringList{"home"}, location.InRegions)
+	assert.Equal(t, model.StringList{"5f2d8c"}, location.InRids)
+	assert.Equal(t, model.StringList{"walking"}, location.MotionActivities)
+	assert.JSONEq(t, j, string(location.Raw))
+}
+
+func TestPingArchive(t *testing.T) {
+	body := fmt.Sprintf(`{"_type":"location","tst":%d,"lat":23.0,"lon":90.0}`, time.Now().Unix())
+
+	t.Run("ping", func(t *testing.T) {
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("Ping", mock.Anything, mock.AnythingOfType("*model.Location")).Return(nil).Once()
+
+		// a failing archive doesn't fail the ping
+		mockArchive := new(mocks.PayloadArchive)
+		mockArchive.On("Append", mock.Anything, mock.MatchedBy(func(p *model.Payload) bool {
+			return p.Channel == model.ChannelPing && p.Username == "dev" && string(p.Body) == body
+		})).Return(errors.New("disk full")).Once()
+
+		c, rec := buildEchoRequest(t, BaseURLV1+"/ping", echo.POST, strings.NewReader(body), true, "")
+
+		handler := lHttp.LocationHandler{LUseCase: mockUsecase, Archive: mockArchive}
+		assert.NoError(t, handler.Ping(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockArchive.AssertExpectations(t)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		batch := "[" + body + "]"
+
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("PingBatch", mock.Anything, mock.Anything).Return([]error{nil}, nil).Once()
+
+		mockArchive := new(mocks.PayloadArchive)
+		mockArchive.On("Append", mock.Anything, mock.MatchedBy(func(p *model.Payload) bool {
+			return p.Channel == model.ChannelPingBatch && string(p.Body) == batch
+		})).Return(nil).Once()
+
+		c, rec := buildEchoRequest(t, BaseURLV1+"/ping/batch", echo.POST, strings.NewReader(batch), true, "")
+
+		handler := lHttp.LocationHandler{LUseCase: mockUsecase, Archive: mockArchive}
+		assert.NoError(t, handler.PingBatch(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"created":1`)
+		mockArchive.AssertExpectations(t)
+	})
+}
+
+func TestPingCommands(t *testing.T) {
+	tst := time.Now().Unix()
+	body := fmt.Sprintf(`{"_type":"location","tst":%d,"lat":23.0,"lon":90.0}`, tst)
+
+	mockUsecase := new(mocks.LocationUsecase)
+	mockUsecase.On("Ping", mock.Anything, mock.AnythingOfType("*model.Location")).Return(nil).Twice()
+
+	mockCommander := new(mocks.DeviceCommander)
+	mockCommander.On("Commands", mock.Anything, "dev", "phoneAndroid", tst).
+		Return([]interface{}{map[string]string{"_type": "cmd", "action": "reportLocation"}}).Once()
+	mockCommander.On("Commands", mock.Anything, "dev", "phoneAndroid", tst).Return(nil).Once()
+
+	// commands of every commander in order
+	mockWaypoints := new(mocks.DeviceCommander)
+	mockWaypoints.On("Commands", mock.Anything, "dev", "phoneAndroid", tst).
+		Return([]interface{}{map[string]string{"_type": "cmd", "action": "setWaypoints"}}).Once()
+	mockWaypoints.On("Commands", mock.Anything, "dev", "phoneAndroid", tst).Return(nil).Once()
+
+	handler := lHttp.LocationHandler{
+		LUseCase:   mockUsecase,
+		Commanders: []model.DeviceCommander{mockCommander, mockWaypoints},
+	}
+
+	c, rec := buildEchoRequest(t, BaseURLV1+"/ping", echo.POST, strings.NewReader(body), true, "")
+	assert.NoError(t, handler.Ping(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"_type":"cmd","action":"reportLocation"},{"_type":"cmd","action":"setWaypoints"}]`,
+		rec.Body.String())
+
+	// without commands the empty array
+	c, rec = buildEchoRequest(t, BaseURLV1+"/ping", echo.POST, strings.NewReader(body), true, "")
+	assert.NoError(t, handler.Ping(c))
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	mockCommander.AssertExpectations(t)
+	mockWaypoints.AssertExpectations(t)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPayloadLocations(t *testing.T) {
+	ndjson, err := json.Marshal("{\"_type\":\"location\",\"tst\":1,\"lat\":23.1,\"lon\":90.1}\n" +
+		"{\"_type\":\"location\",\"lat\":23.2}\n{\"_type\":\"waypoint\"}\n")
+	assert.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		payload   model.Payload
+		locations int
+		skipped   int
+	}{
+		"ping": {
+			payload: model.Payload{Channel: model.ChannelPing, Username: "dev", Device: "phone", IP: "127.0.0.1",
+				Body: json.RawMessage(`{"_type":"location","tst":1,"lat":23.1,"lon":90.1}`)},
+			locations: 1,
+		},
+		"invalid ping": {
+			payload: model.Payload{Channel: model.ChannelPing, Username: "dev", Device: "phone",
+				Body: json.RawMessage(`"{broken"`)},
+			skipped: 1,
+		},
+		"ndjson batch": {
+			payload:   model.Payload{Channel: model.ChannelPingBatch, Username: "dev", Device: "phone", Body: ndjson},
+			locations: 1,
+			skipped:   2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			payload := tc.payload
+
+			locations, skipped, err := lHttp.PayloadLocations(&payload)
+			assert.NoError(t, err)
+			assert.Len(t, locations, tc.locations)
+			assert.Equal(t, tc.skipped, skipped)
+
+			for _, l := range locations {
+				assert.Equal(t, "dev", l.Username)
+				assert.Equal(t, "phone", l.Device)
+			}
+		})
+	}
+
+	_, _, err = lHttp.PayloadLocations(&model.Payload{Channel: "mqtt"})
+	assert.Error(t, err)
+}
+
+func TestPingTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	mockUsecase := new(mocks.LocationUsecase)
+	mockUsecase.On("Ping", mock.Anything, mock.AnythingOfType("*model.Location")).Return(nil)
+
+	j := fmt.Sprintf(`{"_type":"location","tst":%d,"lat":23.0,"lon":90.0}`, time.Now().Unix())
+	c, rec := buildEchoRequest(t, BaseURLV1+"/ping", echo.POST, strings.NewReader(j), true, "")
+
+	// incoming W3C trace context, extracted by the otelecho middleware in the server
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := c.Request()
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	c.SetRequest(req.WithContext(propagation.TraceContext{}.Extract(req.Context(), propagation.HeaderCarrier(req.Header))))
+
+	handler := lHttp.LocationHandler{
+		LUseCase: mockUsecase,
+	}
+	assert.NoError(t, handler.Ping(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+	}
+
+	assert.ElementsMatch(t, []string{"bind", "validate", "LocationHandler.Ping"}, names)
+}
+
 func TestPingNonPingRequest(t *testing.T) {
 	mockUsecase := new(mocks.LocationUsecase)
 	mockUsecase.On("Ping", mock.Anything, mock.AnythingOfType("*model.Location")).Return(nil)
@@ -115,6 +320,96 @@ func TestPingNonPingRequest(t *testing.T) {
 	})
 }
 
+func TestPingBatch(t *testing.T) {
+	endPoint := BaseURLV1 + "/ping/batch"
+	tst := time.Now().Unix()
+	batch := fmt.Sprintf(`[
+  {"_type":"location","tst":%d,"lat":23.1,"lon":90.1,"batt":40},
+  {"_type":"location","tst":%d,"lat":23.1,"lon":90.1,"batt":40},
+  {"_type":"location","lat":23.2},
+  {"_type":"waypoint","desc":"home","tst":%d},
+  "broken"
+]`, tst, tst+1, tst)
+
+	t.Run("json array", func(t *testing.T) {
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("PingBatch", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+			return len(ls) == 2 && ls[0].Username == "dev" && ls[1].CreatedAt == tst+1
+		})).Return([]error{nil, model.ErrDuplicateLocation}, nil).Once()
+
+		// answered with the time of the newest location, the same when the batch is resent
+		mockCommander := new(mocks.DeviceCommander)
+		mockCommander.On("Commands", mock.Anything, "dev", "phoneAndroid", tst+1).
+			Return([]interface{}{map[string]string{"_type": "cmd", "action": "reportLocation"}}).Once()
+
+		c, rec := buildEchoRequest(t, endPoint, echo.POST, strings.NewReader(batch), true, "")
+
+		handler := lHttp.LocationHandler{
+			LUseCase:   mockUsecase,
+			Commanders: []model.DeviceCommander{mockCommander},
+		}
+		assert.NoError(t, handler.PingBatch(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var r struct {
+			Data lHttp.BatchPingResponse `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &r))
+		assert.Equal(t, 1, r.Data.Created)
+		assert.Equal(t, 1, r.Data.Duplicates)
+		assert.Equal(t, 2, r.Data.Invalid)
+		assert.Equal(t, 1, r.Data.Skipped)
+		assert.Equal(t, lHttp.BatchStatusDuplicate, r.Data.Results[1].Status)
+		assert.Equal(t, lHttp.BatchStatusInvalid, r.Data.Results[2].Status)
+		assert.NotNil(t, r.Data.Results[2].Errors)
+		assert.Equal(t, []interface{}{map[string]interface{}{"_type": "cmd", "action": "reportLocation"}},
+			r.Data.Commands)
+		mockUsecase.AssertExpectations(t)
+		mockCommander.AssertExpectations(t)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		ndjson := fmt.Sprintf("{\"_type\":\"location\",\"tst\":%d,\"lat\":23.1,\"lon\":90.1}\n\n"+
+			"{\"_type\":\"location\",\"tst\":%d,\"lat\":23.2,\"lon\":90.2}\n", tst, tst+1)
+
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("PingBatch", mock.Anything, mock.AnythingOfType("[]*model.Location")).
+			Return([]error{nil, nil}, nil).Once()
+
+		c, rec := buildEchoRequest(t, endPoint, echo.POST, strings.NewReader(ndjson), true, "")
+		c.Request().Header.Set(echo.HeaderContentType, "application/x-ndjson")
+
+		handler := lHttp.LocationHandler{
+			LUseCase: mockUsecase,
+		}
+		assert.NoError(t, handler.PingBatch(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"created":2`)
+		assert.NotContains(t, rec.Body.String(), `"commands"`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("headers missing", func(t *testing.T) {
+		c, rec := buildEchoRequest(t, endPoint, echo.POST, strings.NewReader(batch), false, "")
+
+		handler := lHttp.LocationHandler{
+			LUseCase: new(mocks.LocationUsecase),
+		}
+		assert.NoError(t, handler.PingBatch(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		c, rec := buildEchoRequest(t, endPoint, echo.POST, strings.NewReader(" []"), true, "")
+
+		handler := lHttp.LocationHandler{
+			LUseCase: new(mocks.LocationUsecase),
+		}
+		assert.NoError(t, handler.PingBatch(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
 func TestLastLocationSuccess(t *testing.T) {
 	endPoint := BaseURLV1 + "/last-location"
 	mockLoc := model.LocationDetails{
@@ -1,46 +1,194 @@
 package http
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/config"
+	"strings"
+)
+
+const (
+	BatchStatusCreated   = "created"
+	BatchStatusDuplicate = "duplicate"
+	BatchStatusInvalid   = "invalid"
+	BatchStatusSkipped   = "skipped"
+
+	mimeNDJSON        = "application/x-ndjson"
+	maxNDJSONLineSize = 1 << 20
 )
 
 type PingRequest struct {
-	Type  string  `json:"_type" validate:"required"`
-	Tst   int64   `json:"tst" validate:"required"`
-	Acc   int16   `json:"acc"`
-	Alt   int16   `json:"alt"`
-	Batt  int8    `json:"batt"`
-	Bs    int8    `json:"bs"`
-	Lat   float64 `json:"lat" validate:"required"`
-	Lon   float64 `json:"lon" validate:"required"`
-	M     int8    `json:"m"`
-	T     string  `json:"t"`
-	Tid   string  `json:"tid"`
-	Vac   int16   `json:"vac"`
-	Vel   int16   `json:"vel"`
-	Bssid string  `json:"BSSID"`
-	Ssid  string  `json:"SSID"`
-}
-
-func mapLocationRequestToModel(req *PingRequest, headers *http.Header) *model.Location {
-	return &model.Location{
-		Username:  headers.Get("x-limit-u"),
-		Device:    headers.Get("x-limit-d"),
-		CreatedAt: req.Tst,
-		Acc:       req.Acc,
-		Alt:       req.Alt,
-		Batt:      req.Batt,
-		Bs:        req.Bs,
-		Lat:       req.Lat,
-		Lon:       req.Lon,
-		M:         req.M,
-		T:         req.T,
-		Tid:       req.Tid,
-		Vac:       req.Vac,
-		Vel:       req.Vel,
-		Bssid:     req.Bssid,
-		Ssid:      req.Ssid,
-		IP:        headers.Get("X-Real-IP"),
+	Type             string   `json:"_type" validate:"required"`
+	Tst              int64    `json:"tst" validate:"required"`
+	Acc              int16    `json:"acc"`
+	Alt              int16    `json:"alt"`
+	Batt             int8     `json:"batt"`
+	Bs               int8     `json:"bs"`
+	Lat              float64  `json:"lat" validate:"required"`
+	Lon              float64  `json:"lon" validate:"required"`
+	M                int8     `json:"m"`
+	T                string   `json:"t"`
+	Tid              string   `json:"tid"`
+	Vac              int16    `json:"vac"`
+	Vel              int16    `json:"vel"`
+	Bssid            string   `json:"BSSID"`
+	Ssid             string   `json:"SSID"`
+	Cog              int16    `json:"cog"`
+	Rad              int32    `json:"rad"`
+	P                float64  `json:"p"`
+	Poi              string   `json:"poi" validate:"max=255"`
+	Tag              string   `json:"tag" validate:"max=255"`
+	Conn             string   `json:"conn" validate:"max=1"`
+	Topic            string   `json:"topic" validate:"max=255"`
+	ID               string   `json:"_id" validate:"max=100"`
+	CreatedAt        int64    `json:"created_at"`
+	InRegions        []string `json:"inregions"`
+	InRids           []string `json:"inrids"`
+	MotionActivities []string `json:"motionactivities"`
+}
+
+// mapLocationRequestToModel raw is the message as received, it's only kept with app.store_raw
+func mapLocationRequestToModel(req *PingRequest, headers *http.Header, raw json.RawMessage) *model.Location {
+	l := &model.Location{
+		Username:         headers.Get("x-limit-u"),
+		Device:           headers.Get("x-limit-d"),
+		CreatedAt:        req.Tst,
+		Acc:              req.Acc,
+		Alt:              req.Alt,
+		Batt:             req.Batt,
+		Bs:               req.Bs,
+		Lat:              req.Lat,
+		Lon:              req.Lon,
+		M:                req.M,
+		T:                req.T,
+		Tid:              req.Tid,
+		Vac:              req.Vac,
+		Vel:              req.Vel,
+		Bssid:            req.Bssid,
+		Ssid:             req.Ssid,
+		IP:               headers.Get("X-Real-IP"),
+		Cog:              req.Cog,
+		Rad:              req.Rad,
+		P:                req.P,
+		Poi:              req.Poi,
+		Tag:              req.Tag,
+		Conn:             req.Conn,
+		Topic:            req.Topic,
+		MsgID:            req.ID,
+		MsgCreatedAt:     req.CreatedAt,
+		InRegions:        req.InRegions,
+		InRids:           req.InRids,
+		MotionActivities: req.MotionActivities,
+	}
+
+	if config.Get().App.StoreRaw && json.Valid(raw) {
+		l.Raw = raw
+	}
+
+	return l
+}
+
+// BatchItemResult outcome of one message of a batch ping
+type BatchItemResult struct {
+	Index  int         `json:"index"`
+	Status string      `json:"status"`
+	Errors interface{} `json:"errors,omitempty"`
+}
+
+type BatchPingResponse struct {
+	Created    int               `json:"created"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+	Skipped    int               `json:"skipped"`
+	Results    []BatchItemResult `json:"results"`
+	Commands   []interface{}     `json:"commands,omitempty"`
+}
+
+func (r *BatchPingResponse) set(index int, status string, errs interface{}) {
+	r.Results[index] = BatchItemResult{Index: index, Status: status, Errors: errs}
+
+	switch status {
+	case BatchStatusCreated:
+		r.Created++
+	case BatchStatusDuplicate:
+		r.Duplicates++
+	case BatchStatusInvalid:
+		r.Invalid++
+	case BatchStatusSkipped:
+		r.Skipped++
+	}
+}
+
+// decodeBatchPing reads a JSON array or newline delimited JSON messages. Items are kept raw,
+// so one malformed message doesn't reject the whole batch.
+func decodeBatchPing(body io.Reader, contentType string) ([]json.RawMessage, error) {
+	reader := bufio.NewReader(body)
+
+	if !strings.HasPrefix(contentType, mimeNDJSON) {
+		first, err := peekFirstNonSpace(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		if first == '[' {
+			var items []json.RawMessage
+			if err := json.NewDecoder(reader).Decode(&items); err != nil {
+				return nil, err
+			}
+
+			return items, nil
+		}
+	}
+
+	var items []json.RawMessage
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxNDJSONLineSize)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		items = append(items, append(json.RawMessage{}, line...))
 	}
+
+	return items, scanner.Err()
+}
+
+func peekFirstNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.Peek(1)
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			return b[0], nil
+		}
+
+		_, _ = reader.ReadByte()
+	}
+}
+
+func checkPingHeaders(headers http.Header) error {
+	if len(headers.Get("x-limit-u")) == 0 ||
+		len(headers.Get("x-limit-d")) == 0 ||
+		len(headers.Get("X-Real-IP")) == 0 {
+		return fmt.Errorf("%s%s", "[x-limit-u, x-limit-d, X-Real-IP] any one of these headers are missing!",
+			"\n Set username & device id in app settings. Also set X-Real-IP header in Poxy settings")
+	}
+
+	return nil
 }
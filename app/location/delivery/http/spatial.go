@@ -0,0 +1,128 @@
+package http
+
+import (
+	"errors"
+	"ot-recorder/app/geo"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	bboxParts = 4
+	maxRadius = 50000 // meters
+)
+
+// Within returns locations inside bbox=minLon,minLat,maxLon,maxLat between from & to,
+// of every user or of username
+func (u *LocationHandler) Within(c echo.Context) error {
+	query, err := parseSpatialQuery(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	box, err := parseBBox(c.QueryParam("bbox"))
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	query.MinLat, query.MinLon, query.MaxLat, query.MaxLon = box.MinLat, box.MinLon, box.MaxLat, box.MaxLon
+
+	points, err := u.LUseCase.Within(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", points))
+}
+
+// Nearby returns locations within radius meters of lat & lon between from & to,
+// of every user or of username
+func (u *LocationHandler) Nearby(c echo.Context) error {
+	query, err := parseSpatialQuery(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	if query.Lat, err = parseCoordinate(c.QueryParam("lat"), 90); err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("lat must be between -90 and 90")))
+	}
+
+	if query.Lon, err = parseCoordinate(c.QueryParam("lon"), 180); err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("lon must be between -180 and 180")))
+	}
+
+	query.Radius, err = strconv.ParseFloat(c.QueryParam("radius"), 64)
+	if err != nil || query.Radius <= 0 || query.Radius > maxRadius {
+		return c.JSON(response.RespondError(response.ErrBadRequest,
+			errors.New("radius must be between 1 and "+strconv.Itoa(maxRadius)+" meters")))
+	}
+
+	box := geo.BoundingBox(query.Lat, query.Lon, query.Radius)
+	query.MinLat, query.MinLon, query.MaxLat, query.MaxLon = box.MinLat, box.MinLon, box.MaxLat, box.MaxLon
+
+	points, err := u.LUseCase.Within(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", points))
+}
+
+func parseSpatialQuery(c echo.Context) (model.SpatialQuery, error) {
+	query := model.SpatialQuery{Username: c.QueryParam("username"), Device: c.QueryParam("device")}
+
+	var err error
+	query.From, query.To, query.Limit, err = parseRangeAndLimit(c)
+
+	return query, err
+}
+
+// parseBBox reads minLon,minLat,maxLon,maxLat, the order of GeoJSON & OpenStreetMap
+func parseBBox(bbox string) (geo.Box, error) {
+	errInvalid := errors.New("bbox must be minLon,minLat,maxLon,maxLat")
+
+	parts := strings.Split(bbox, ",")
+	if len(parts) != bboxParts {
+		return geo.Box{}, errInvalid
+	}
+
+	var values [bboxParts]float64
+
+	for i, part := range parts {
+		limit := 180.0
+		if i%2 == 1 {
+			limit = 90
+		}
+
+		v, err := parseCoordinate(strings.TrimSpace(part), limit)
+		if err != nil {
+			return geo.Box{}, errInvalid
+		}
+
+		values[i] = v
+	}
+
+	box := geo.Box{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+	if box.MinLat > box.MaxLat || box.MinLon > box.MaxLon {
+		return geo.Box{}, errors.New("bbox minimums must not be greater than maximums")
+	}
+
+	return box, nil
+}
+
+func parseCoordinate(v string, limit float64) (float64, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if f < -limit || f > limit {
+		return 0, errors.New("coordinate out of range")
+	}
+
+	return f, nil
+}
@@ -0,0 +1,81 @@
+package http_test
+
+import (
+	"net/http"
+	lHttp "ot-recorder/app/location/delivery/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWithin(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("Within", mock.Anything, model.SpatialQuery{
+			Username: "dev", From: 100, To: 200, MinLat: 23.8, MinLon: 90.4, MaxLat: 23.82, MaxLon: 90.43, Limit: 5000,
+		}).Return([]model.SpatialPoint{{Username: "dev", TrackPoint: model.TrackPoint{Device: "phone"}}}, nil).Once()
+
+		c, rec := buildEchoRequest(t, BaseURLV1+"/locations/within?bbox=90.4,23.8,90.43,23.82&from=100&to=200&username=dev",
+			echo.GET, nil, false, "")
+
+		handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+		assert.NoError(t, handler.Within(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `{"username":"dev","device":"phone"`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	for name, query := range map[string]string{
+		"bbox missing":       "",
+		"bbox incomplete":    "?bbox=90.4,23.8,90.43",
+		"bbox out of range":  "?bbox=90.4,23.8,90.43,91",
+		"bbox min after max": "?bbox=90.43,23.8,90.4,23.82",
+		"invalid from":       "?bbox=90.4,23.8,90.43,23.82&from=yesterday",
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, rec := buildEchoRequest(t, BaseURLV1+"/locations/within"+query, echo.GET, nil, false, "")
+
+			handler := lHttp.LocationHandler{LUseCase: new(mocks.LocationUsecase)}
+			assert.NoError(t, handler.Within(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestNearby(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("Within", mock.Anything, mock.MatchedBy(func(q model.SpatialQuery) bool {
+			return q.Lat == 23.8103 && q.Lon == 90.4125 && q.Radius == 500 &&
+				q.MinLat < 23.8103 && q.MaxLat > 23.8103 && q.MinLon < 90.4125 && q.MaxLon > 90.4125 &&
+				q.To-q.From == 24*60*60
+		})).Return([]model.SpatialPoint{}, nil).Once()
+
+		c, rec := buildEchoRequest(t, BaseURLV1+"/locations/nearby?lat=23.8103&lon=90.4125&radius=500",
+			echo.GET, nil, false, "")
+
+		handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+		assert.NoError(t, handler.Nearby(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	for name, query := range map[string]string{
+		"lat missing":      "?lon=90.4125&radius=500",
+		"lon out of range": "?lat=23.8103&lon=190&radius=500",
+		"radius missing":   "?lat=23.8103&lon=90.4125",
+		"radius too big":   "?lat=23.8103&lon=90.4125&radius=100000",
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, rec := buildEchoRequest(t, BaseURLV1+"/locations/nearby"+query, echo.GET, nil, false, "")
+
+			handler := lHttp.LocationHandler{LUseCase: new(mocks.LocationUsecase)}
+			assert.NoError(t, handler.Nearby(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/logger"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+const sseHeader = "HTTP/1.1 200 OK\r\n" +
+	"Content-Type: text/event-stream\r\n" +
+	"Cache-Control: no-cache\r\n" +
+	"Connection: close\r\n" +
+	"X-Accel-Buffering: no\r\n\r\n"
+
+// Stream pushes every accepted location the viewer(x-limit-u) may see as Server-Sent Events.
+// The connection is hijacked, so the stream isn't cut by the server write timeout.
+func (u *LocationHandler) Stream(c echo.Context) error {
+	req := c.Request()
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	locations, err := u.LUseCase.Subscribe(ctx, req.Header.Get("x-limit-u"))
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	conn, rw, err := c.Response().Hijack()
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	// clear the deadlines of the server timeouts, the stream ends when the client goes away
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	go cancelOnClose(rw.Reader, cancel)
+
+	if _, err := rw.WriteString(sseHeader); err != nil {
+		return nil
+	}
+
+	keepAlive := time.NewTicker(config.Get().Stream.KeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		if err := rw.Flush(); err != nil {
+			return nil
+		}
+
+		select {
+		case l, ok := <-locations:
+			if !ok {
+				return nil
+			}
+
+			if err := writeEvent(rw.Writer, l); err != nil {
+				logger.FromContext(ctx).Errorln(err)
+				return nil
+			}
+		case <-keepAlive.C:
+			if _, err := rw.WriteString(": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func writeEvent(w *bufio.Writer, l *model.LocationDetails) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.WriteString("event: location\ndata: "); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	_, err = w.WriteString("\n\n")
+
+	return err
+}
+
+// cancelOnClose reads the client side of a stream until it is closed
+func cancelOnClose(r io.Reader, cancel context.CancelFunc) {
+	_, _ = io.Copy(io.Discard, r)
+	cancel()
+}
+
+// StreamWS pushes the same locations as Stream over a WebSocket, one JSON text message per location
+func (u *LocationHandler) StreamWS(c echo.Context) error {
+	req := c.Request()
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	locations, err := u.LUseCase.Subscribe(ctx, req.Header.Get("x-limit-u"))
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	server := websocket.Server{
+		Handshake: checkSameOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			if err := ws.SetDeadline(time.Time{}); err != nil {
+				return
+			}
+
+			go func() {
+				// nothing is expected from the client, receive to notice when it goes away
+				var msg string
+				for websocket.Message.Receive(ws, &msg) == nil {
+				}
+				cancel()
+			}()
+
+			for l := range locations {
+				if err := websocket.JSON.Send(ws, l); err != nil {
+					return
+				}
+			}
+		},
+	}
+
+	server.ServeHTTP(c.Response(), req)
+
+	return nil
+}
+
+var errCrossOrigin = errors.New("cross origin websocket")
+
+// checkSameOrigin rejects browser connections from other sites, they would
+// reuse the credentials of the viewer; clients without Origin are allowed
+func checkSameOrigin(cfg *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+
+	host := req.Host
+	if h, _, err := net.SplitHostPort(req.Host); err == nil {
+		host = h
+	}
+
+	if u.Hostname() != host {
+		return errCrossOrigin
+	}
+
+	cfg.Origin = u
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	lHttp "ot-recorder/app/location/delivery/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/websocket"
+)
+
+func newStreamServer(t *testing.T, locations chan *model.LocationDetails, err error) *httptest.Server {
+	config.LoadTestValues()
+
+	mockUsecase := new(mocks.LocationUsecase)
+	mockUsecase.On("Subscribe", mock.Anything, "dev").Return((<-chan *model.LocationDetails)(locations), err)
+
+	e := echo.New()
+	lHttp.NewUserHandler(e, mockUsecase, nil)
+
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestStream(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		locations := make(chan *model.LocationDetails, 1)
+		srv := newStreamServer(t, locations, nil)
+
+		req, err := http.NewRequestWithContext(context.TODO(), echo.GET, srv.URL+BaseURLV1+"/stream", nil)
+		assert.NoError(t, err)
+		req.Header.Set("x-limit-u", "dev")
+
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get(echo.HeaderContentType))
+
+		locations <- &model.LocationDetails{Username: "dev", Device: "phone", Latitude: 23, Longitude: 90}
+
+		reader := bufio.NewReader(res.Body)
+		event, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+		assert.Equal(t, "event: location\n", event)
+
+		data, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+
+		var l model.LocationDetails
+		assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), &l))
+		assert.Equal(t, "phone", l.Device)
+	})
+
+	t.Run("forbidden viewer", func(t *testing.T) {
+		srv := newStreamServer(t, nil, response.ErrForbidden)
+
+		req, err := http.NewRequestWithContext(context.TODO(), echo.GET, srv.URL+BaseURLV1+"/stream", nil)
+		assert.NoError(t, err)
+		req.Header.Set("x-limit-u", "dev")
+
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+}
+
+func TestStreamWS(t *testing.T) {
+	locations := make(chan *model.LocationDetails, 1)
+	srv := newStreamServer(t, locations, nil)
+
+	cfg, err := websocket.NewConfig(strings.Replace(srv.URL, "http", "ws", 1)+BaseURLV1+"/stream/ws", srv.URL)
+	assert.NoError(t, err)
+	cfg.Header.Set("x-limit-u", "dev")
+
+	ws, err := websocket.DialConfig(cfg)
+	assert.NoError(t, err)
+	defer ws.Close()
+
+	locations <- &model.LocationDetails{Username: "dev", Device: "phone"}
+
+	var l model.LocationDetails
+	assert.NoError(t, websocket.JSON.Receive(ws, &l))
+	assert.Equal(t, "phone", l.Device)
+
+	t.Run("cross origin", func(t *testing.T) {
+		cfg, err := websocket.NewConfig(strings.Replace(srv.URL, "http", "ws", 1)+BaseURLV1+"/stream/ws",
+			"http://evil.example")
+		assert.NoError(t, err)
+		cfg.Header.Set("x-limit-u", "dev")
+
+		_, err = websocket.DialConfig(cfg)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"ot-recorder/app/geo"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultHeatmapPrecision = 7 // geohash cells of about 150 x 150 meters
+	mvtContentType          = "application/vnd.mapbox-vector-tile"
+	mvtExtension            = ".mvt"
+)
+
+// Heatmap returns the number of locations of a user per geohash cell between from & to,
+// precision is the geohash length of the cells
+func (u *LocationHandler) Heatmap(c echo.Context) error {
+	query, err := parseLocationQuery(c)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	precision := defaultHeatmapPrecision
+
+	if v := c.QueryParam("precision"); v != "" {
+		if precision, err = strconv.Atoi(v); err != nil || precision < 1 || precision > geo.GeohashPrecision {
+			return c.JSON(response.RespondError(response.ErrBadRequest,
+				errors.New("precision must be between 1 and "+strconv.Itoa(geo.GeohashPrecision))))
+		}
+	}
+
+	cells, err := u.LUseCase.Heatmap(c.Request().Context(), query, precision)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", cells))
+}
+
+// Tile returns the locations inside the tile z/x/y.mvt between from & to as a Mapbox Vector Tile,
+// of every user or of username
+func (u *LocationHandler) Tile(c echo.Context) error {
+	query := model.LocationQuery{
+		Username: c.QueryParam("username"),
+		Device:   c.QueryParam("device"),
+	}
+
+	var err error
+	if query.From, query.To, query.Limit, err = parseRangeAndLimit(c); err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	tile, err := parseTile(c.Param("z"), c.Param("x"), c.Param("y"))
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, err))
+	}
+
+	data, err := u.LUseCase.Tile(c.Request().Context(), tile.Z, tile.X, tile.Y, query)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.Blob(http.StatusOK, mvtContentType, data)
+}
+
+// parseTile reads z, x & y.mvt path params of a tile
+func parseTile(z, x, y string) (geo.Tile, error) {
+	errInvalid := errors.New("tile must be /tiles/<z>/<x>/<y>.mvt, z between 0 and " + strconv.Itoa(geo.MaxZoom))
+
+	if !strings.HasSuffix(y, mvtExtension) {
+		return geo.Tile{}, errInvalid
+	}
+
+	var (
+		tile geo.Tile
+		err  error
+	)
+
+	if tile.Z, err = strconv.Atoi(z); err != nil {
+		return geo.Tile{}, errInvalid
+	}
+
+	if tile.X, err = strconv.Atoi(x); err != nil {
+		return geo.Tile{}, errInvalid
+	}
+
+	if tile.Y, err = strconv.Atoi(strings.TrimSuffix(y, mvtExtension)); err != nil {
+		return geo.Tile{}, errInvalid
+	}
+
+	if !tile.Valid() {
+		return geo.Tile{}, errors.New("tile does not exist at zoom " + z)
+	}
+
+	return tile, nil
+}
@@ -0,0 +1,83 @@
+package http_test
+
+import (
+	"net/http"
+	lHttp "ot-recorder/app/location/delivery/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHeatmap(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("Heatmap", mock.Anything, model.LocationQuery{
+			Username: "dev", Device: "phone", From: 100, To: 200, Limit: 5000,
+		}, 6).Return([]model.HeatmapCell{{Geohash: "wh0r3q", Lat: 23.81, Lon: 90.41, Count: 3}}, nil).Once()
+
+		c, rec := buildEchoRequest(t, BaseURLV1+"/heatmap?username=dev&device=phone&from=100&to=200&precision=6",
+			echo.GET, nil, false, "")
+
+		handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+		assert.NoError(t, handler.Heatmap(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `{"geohash":"wh0r3q","lat":23.81,"lon":90.41,"count":3}`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	for name, query := range map[string]string{
+		"username missing":  "?precision=6",
+		"invalid precision": "?username=dev&precision=fine",
+		"precision too big": "?username=dev&precision=10",
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, rec := buildEchoRequest(t, BaseURLV1+"/heatmap"+query, echo.GET, nil, false, "")
+
+			handler := lHttp.LocationHandler{LUseCase: new(mocks.LocationUsecase)}
+			assert.NoError(t, handler.Heatmap(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestTile(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.LocationUsecase)
+		mockUsecase.On("Tile", mock.Anything, 12, 3076, 1768, model.LocationQuery{
+			Username: "dev", From: 100, To: 200, Limit: 5000,
+		}).Return([]byte{0x1a, 0x00}, nil).Once()
+
+		c, rec := buildEchoRequest(t, BaseURLV1+"/tiles/12/3076/1768.mvt?username=dev&from=100&to=200",
+			echo.GET, nil, false, "")
+		c.SetParamNames("z", "x", "y")
+		c.SetParamValues("12", "3076", "1768.mvt")
+
+		handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+		assert.NoError(t, handler.Tile(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/vnd.mapbox-vector-tile", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, []byte{0x1a, 0x00}, rec.Body.Bytes())
+		mockUsecase.AssertExpectations(t)
+	})
+
+	for name, params := range map[string][]string{
+		"no extension":   {"12", "3076", "1768"},
+		"invalid zoom":   {"z", "3076", "1768.mvt"},
+		"zoom too deep":  {"23", "0", "0.mvt"},
+		"x out of range": {"1", "2", "0.mvt"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, rec := buildEchoRequest(t, BaseURLV1+"/tiles", echo.GET, nil, false, "")
+			c.SetParamNames("z", "x", "y")
+			c.SetParamValues(params...)
+
+			handler := lHttp.LocationHandler{LUseCase: new(mocks.LocationUsecase)}
+			assert.NoError(t, handler.Tile(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"ot-recorder/app/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Users returns every user with a location
+func (u *LocationHandler) Users(c echo.Context) error {
+	users, err := u.LUseCase.Users(c.Request().Context())
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", users))
+}
+
+// Devices returns the devices of a user with the time of their last location
+func (u *LocationHandler) Devices(c echo.Context) error {
+	devices, err := u.LUseCase.Devices(c.Request().Context(), c.Param("user"))
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", devices))
+}
+
+// LastLocations returns the last location of every device, of one user with the username query param
+func (u *LocationHandler) LastLocations(c echo.Context) error {
+	locations, err := u.LUseCase.LastLocations(c.Request().Context(), c.QueryParam("username"))
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	c.Echo().JSONSerializer = MyJSONSerializer{}
+
+	return c.JSON(response.RespondSuccess("request success", locations))
+}
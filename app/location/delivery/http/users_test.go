@@ -0,0 +1,63 @@
+package http_test
+
+import (
+	"net/http"
+	lHttp "ot-recorder/app/location/delivery/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUsers(t *testing.T) {
+	mockUsecase := new(mocks.LocationUsecase)
+	mockUsecase.On("Users", mock.Anything).Return([]string{"dev", "mom"}, nil).Once()
+
+	c, rec := buildEchoRequest(t, BaseURLV1+"/users", echo.GET, nil, false, "")
+
+	handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+	assert.NoError(t, handler.Users(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"data":["dev","mom"]`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestDevices(t *testing.T) {
+	mockUsecase := new(mocks.LocationUsecase)
+	mockUsecase.On("Devices", mock.Anything, "dev").
+		Return([]model.Device{{Name: "phone", LastSeen: 100}}, nil).Once()
+	mockUsecase.On("Devices", mock.Anything, "nobody").Return(nil, response.ErrNotFound).Once()
+
+	handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+
+	for user, code := range map[string]int{"dev": http.StatusOK, "nobody": http.StatusNotFound} {
+		c, rec := buildEchoRequest(t, BaseURLV1+"/users/"+user+"/devices", echo.GET, nil, false, "")
+		c.SetParamNames("user")
+		c.SetParamValues(user)
+
+		assert.NoError(t, handler.Devices(c))
+		assert.Equal(t, code, rec.Code)
+	}
+
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestLastLocations(t *testing.T) {
+	mockUsecase := new(mocks.LocationUsecase)
+	mockUsecase.On("LastLocations", mock.Anything, "dev").Return([]*model.LocationDetails{
+		{Username: "dev", Device: "phone"},
+		{Username: "dev", Device: "tablet"},
+	}, nil).Once()
+
+	c, rec := buildEchoRequest(t, BaseURLV1+"/last-locations?username=dev", echo.GET, nil, false, "")
+
+	handler := lHttp.LocationHandler{LUseCase: mockUsecase}
+	assert.NoError(t, handler.LastLocations(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"device":"tablet"`)
+	mockUsecase.AssertExpectations(t)
+}
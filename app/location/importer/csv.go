@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"ot-recorder/app/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV parses comma separated points. The first row is a header naming the columns,
+// column order is free and unknown columns are ignored.
+//
+//	tst         epoch seconds (required, or time)
+//	time        RFC3339 date time (required, or tst)
+//	lat, lon    decimal degrees (required)
+//	acc, alt, vac, vel, batt, bs, m   integers
+//	t, tid, ssid, bssid               strings
+type CSV struct {
+	r io.Reader
+}
+
+func NewCSVParser(r io.Reader) *CSV {
+	return &CSV{r: r}
+}
+
+func (p *CSV) Parse(h Handler) error {
+	reader := csv.NewReader(p.r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("csv: header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if err := checkCSVColumns(columns); err != nil {
+		return err
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("csv: %w", err)
+		}
+
+		l, err := csvRecordToLocation(columns, record)
+		if err != nil {
+			return fmt.Errorf("csv: line %d: %w", line, err)
+		}
+
+		if err := h(l); err != nil {
+			return err
+		}
+	}
+}
+
+func checkCSVColumns(columns map[string]int) error {
+	_, hasTst := columns["tst"]
+	_, hasTime := columns["time"]
+
+	if !hasTst && !hasTime {
+		return errors.New("csv: header must have a tst or time column")
+	}
+
+	for _, name := range []string{"lat", "lon"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("csv: header must have a %s column", name)
+		}
+	}
+
+	return nil
+}
+
+//nolint:gocyclo
+func csvRecordToLocation(columns map[string]int, record []string) (*model.Location, error) {
+	value := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	var (
+		l   = &model.Location{}
+		err error
+	)
+
+	if v := value("tst"); v != "" {
+		if l.CreatedAt, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("tst: %w", err)
+		}
+	} else if v := value("time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("time: %w", err)
+		}
+
+		l.CreatedAt = t.Unix()
+	}
+
+	if l.Lat, err = strconv.ParseFloat(value("lat"), 64); err != nil {
+		return nil, fmt.Errorf("lat: %w", err)
+	}
+
+	if l.Lon, err = strconv.ParseFloat(value("lon"), 64); err != nil {
+		return nil, fmt.Errorf("lon: %w", err)
+	}
+
+	int16Fields := map[string]*int16{"acc": &l.Acc, "alt": &l.Alt, "vac": &l.Vac, "vel": &l.Vel}
+	for name, field := range int16Fields {
+		if v := value(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+
+			*field = int16(n)
+		}
+	}
+
+	int8Fields := map[string]*int8{"batt": &l.Batt, "bs": &l.Bs, "m": &l.M}
+	for name, field := range int8Fields {
+		if v := value(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+
+			*field = int8(n)
+		}
+	}
+
+	l.T = value("t")
+	l.Tid = value("tid")
+	l.Ssid = value("ssid")
+	l.Bssid = value("bssid")
+
+	if err := validatePoint(l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
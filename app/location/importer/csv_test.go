@@ -0,0 +1,66 @@
+package importer_test
+
+import (
+	"ot-recorder/app/location/importer"
+	"ot-recorder/app/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVParse(t *testing.T) {
+	doc := `TST, lat, lon, acc, batt, ssid, unknown
+1669888800, 23.810332, 90.412518, 13, 40, home, x
+1669888830, 23.810500, 90.412600, , , ,`
+
+	var locations []*model.Location
+
+	err := importer.NewCSVParser(strings.NewReader(doc)).Parse(func(l *model.Location) error {
+		locations = append(locations, l)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, locations, 2)
+	assert.Equal(t, int64(1669888800), locations[0].CreatedAt)
+	assert.Equal(t, 90.412518, locations[0].Lon)
+	assert.Equal(t, int16(13), locations[0].Acc)
+	assert.Equal(t, int8(40), locations[0].Batt)
+	assert.Equal(t, "home", locations[0].Ssid)
+	assert.Equal(t, int16(0), locations[1].Acc)
+}
+
+func TestCSVParseTimeColumn(t *testing.T) {
+	doc := "time,lat,lon\n2022-12-01T16:00:00+06:00,23.8,90.4\n"
+
+	var locations []*model.Location
+
+	err := importer.NewCSVParser(strings.NewReader(doc)).Parse(func(l *model.Location) error {
+		locations = append(locations, l)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, locations, 1)
+	assert.Equal(t, int64(1669888800), locations[0].CreatedAt)
+}
+
+func TestCSVParseInvalid(t *testing.T) {
+	noop := func(l *model.Location) error { return nil }
+
+	t.Run("missing columns", func(t *testing.T) {
+		err := importer.NewCSVParser(strings.NewReader("lat,lon\n23.8,90.4\n")).Parse(noop)
+		assert.ErrorContains(t, err, "tst or time")
+	})
+
+	t.Run("bad number", func(t *testing.T) {
+		err := importer.NewCSVParser(strings.NewReader("tst,lat,lon\n1669888800,abc,90.4\n")).Parse(noop)
+		assert.ErrorContains(t, err, "line 2")
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		err := importer.NewCSVParser(strings.NewReader("tst,lat,lon\n1669888800,123.8,90.4\n")).Parse(noop)
+		assert.ErrorIs(t, err, importer.ErrInvalidPoint)
+	})
+}
@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"ot-recorder/app/model"
+	"time"
+)
+
+type gpxTrackPoint struct {
+	Lat   float64  `xml:"lat,attr"`
+	Lon   float64  `xml:"lon,attr"`
+	Ele   *float64 `xml:"ele"`
+	Time  string   `xml:"time"`
+	Speed *float64 `xml:"speed"`
+}
+
+// GPX parses the track points(trkpt) of a GPX 1.0/1.1 document
+type GPX struct {
+	r io.Reader
+}
+
+func NewGPXParser(r io.Reader) *GPX {
+	return &GPX{r: r}
+}
+
+func (p *GPX) Parse(h Handler) error {
+	decoder := xml.NewDecoder(p.r)
+	index := 0
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("gpx: %w", err)
+		}
+
+		se, ok := token.(xml.StartElement)
+		if !ok || se.Name.Local != "trkpt" {
+			continue
+		}
+
+		index++
+
+		var tp gpxTrackPoint
+		if err := decoder.DecodeElement(&tp, &se); err != nil {
+			return fmt.Errorf("gpx: trkpt #%d: %w", index, err)
+		}
+
+		l, err := tp.toLocation()
+		if err != nil {
+			return fmt.Errorf("gpx: trkpt #%d: %w", index, err)
+		}
+
+		if err := h(l); err != nil {
+			return err
+		}
+	}
+}
+
+func (tp *gpxTrackPoint) toLocation() (*model.Location, error) {
+	l := &model.Location{
+		Lat: tp.Lat,
+		Lon: tp.Lon,
+	}
+
+	if tp.Time != "" {
+		t, err := time.Parse(time.RFC3339, tp.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		l.CreatedAt = t.Unix()
+	}
+
+	if tp.Ele != nil {
+		l.Alt = toInt16(*tp.Ele)
+	}
+
+	if tp.Speed != nil {
+		l.Vel = toInt16(*tp.Speed * mpsToKmph)
+	}
+
+	if err := validatePoint(l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
@@ -0,0 +1,52 @@
+package importer_test
+
+import (
+	"ot-recorder/app/location/importer"
+	"ot-recorder/app/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const gpxDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <wpt lat="1.0" lon="1.0"><name>ignored</name></wpt>
+  <trk><name>walk</name><trkseg>
+    <trkpt lat="23.810332" lon="90.412518"><ele>12.4</ele><time>2022-12-01T10:00:00Z</time></trkpt>
+    <trkpt lat="23.810500" lon="90.412600"><time>2022-12-01T10:00:30.500Z</time><speed>2</speed></trkpt>
+  </trkseg></trk>
+</gpx>`
+
+func TestGPXParse(t *testing.T) {
+	var locations []*model.Location
+
+	err := importer.NewGPXParser(strings.NewReader(gpxDoc)).Parse(func(l *model.Location) error {
+		locations = append(locations, l)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, locations, 2)
+	assert.Equal(t, int64(1669888800), locations[0].CreatedAt)
+	assert.Equal(t, 23.810332, locations[0].Lat)
+	assert.Equal(t, int16(12), locations[0].Alt)
+	assert.Equal(t, int64(1669888830), locations[1].CreatedAt)
+	assert.Equal(t, int16(7), locations[1].Vel)
+}
+
+func TestGPXParseInvalid(t *testing.T) {
+	t.Run("missing time", func(t *testing.T) {
+		doc := `<gpx><trk><trkseg><trkpt lat="23.8" lon="90.4"></trkpt></trkseg></trk></gpx>`
+		err := importer.NewGPXParser(strings.NewReader(doc)).Parse(func(l *model.Location) error { return nil })
+
+		assert.ErrorIs(t, err, importer.ErrInvalidPoint)
+	})
+
+	t.Run("broken xml", func(t *testing.T) {
+		doc := `<gpx><trk><trkseg><trkpt lat="23.8" lon="90.4"><time>`
+		err := importer.NewGPXParser(strings.NewReader(doc)).Parse(func(l *model.Location) error { return nil })
+
+		assert.Error(t, err)
+	})
+}
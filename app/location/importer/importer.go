@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"ot-recorder/app/model"
+	"time"
+)
+
+const (
+	maxLat = 90
+	maxLon = 180
+	// msPerSecond for converting google millisecond epochs
+	msPerSecond = 1000
+	// mpsToKmph converts m/s (gpx & google) to km/h (owntracks)
+	mpsToKmph = 3.6
+	// BatchSize points stored per insert
+	BatchSize = 500
+)
+
+// ErrInvalidPoint returned when a parsed point has no time or invalid coordinates
+var ErrInvalidPoint = errors.New("invalid point")
+
+// Handler receives every location parsed from a source
+type Handler func(l *model.Location) error
+
+// Parser reads location points from a source and passes them to a Handler
+type Parser interface {
+	Parse(h Handler) error
+}
+
+// Result summary of an import run
+type Result struct {
+	Imported   int64
+	Duplicates int64
+	Failed     int64
+}
+
+// Import reads all points from the parser and stores them for the given username & device
+// in batches of BatchSize, every batch must be stored within timeout
+func Import(
+	ctx context.Context,
+	repo model.LocationRepository,
+	p Parser,
+	username, device string,
+	timeout time.Duration,
+) (Result, error) {
+	var res Result
+
+	batch := make([]*model.Location, 0, BatchSize)
+
+	store := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		storeCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		inserted, err := repo.CreateLocations(storeCtx, batch)
+		if err != nil {
+			res.Failed += int64(len(batch))
+		} else {
+			res.Imported += inserted
+			res.Duplicates += int64(len(batch)) - inserted
+		}
+
+		// a failed batch isn't stored again after the parse stops
+		batch = batch[:0]
+
+		return err
+	}
+
+	err := p.Parse(func(l *model.Location) error {
+		l.Username = username
+		l.Device = device
+		batch = append(batch, l)
+
+		if len(batch) < BatchSize {
+			return nil
+		}
+
+		return store()
+	})
+
+	// points parsed before a parse error are kept
+	if storeErr := store(); err == nil {
+		err = storeErr
+	}
+
+	return res, err
+}
+
+func validatePoint(l *model.Location) error {
+	if l.CreatedAt <= 0 {
+		return fmt.Errorf("%w: missing timestamp", ErrInvalidPoint)
+	}
+
+	if math.Abs(l.Lat) > maxLat || math.Abs(l.Lon) > maxLon {
+		return fmt.Errorf("%w: coordinates out of range (%f, %f)", ErrInvalidPoint, l.Lat, l.Lon)
+	}
+
+	return nil
+}
+
+func toInt16(v float64) int16 {
+	switch {
+	case v > math.MaxInt16:
+		return math.MaxInt16
+	case v < math.MinInt16:
+		return math.MinInt16
+	default:
+		return int16(math.Round(v))
+	}
+}
@@ -0,0 +1,108 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ot-recorder/app/location/importer"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImport(t *testing.T) {
+	doc := "tst,lat,lon\n1669888800,23.8,90.4\n1669888830,23.9,90.5\n"
+
+	t.Run("success", func(t *testing.T) {
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocations", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+			return len(ls) == 2 && ls[0].Username == "dev" && ls[1].Device == "phone"
+		})).Return(int64(2), nil).Once()
+
+		res, err := importer.Import(context.TODO(), mockLocationRepo,
+			importer.NewCSVParser(strings.NewReader(doc)), "dev", "phone", time.Second)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), res.Imported)
+		mockLocationRepo.AssertExpectations(t)
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		var b strings.Builder
+		b.WriteString("tst,lat,lon\n")
+
+		for i := 0; i < importer.BatchSize+1; i++ {
+			fmt.Fprintf(&b, "%d,23.8,90.4\n", 1669888800+i)
+		}
+
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocations", mock.MatchedBy(func(ctx context.Context) bool {
+			_, ok := ctx.Deadline()
+			return ok
+		}), mock.MatchedBy(func(ls []*model.Location) bool {
+			return len(ls) == importer.BatchSize
+		})).Return(int64(importer.BatchSize), nil).Once()
+		mockLocationRepo.On("CreateLocations", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+			return len(ls) == 1
+		})).Return(int64(1), nil).Once()
+
+		res, err := importer.Import(context.TODO(), mockLocationRepo,
+			importer.NewCSVParser(strings.NewReader(b.String())), "dev", "phone", time.Second)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(importer.BatchSize+1), res.Imported)
+		mockLocationRepo.AssertExpectations(t)
+	})
+
+	t.Run("duplicates", func(t *testing.T) {
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocations", mock.Anything, mock.Anything).Return(int64(1), nil).Once()
+
+		res, err := importer.Import(context.TODO(), mockLocationRepo,
+			importer.NewCSVParser(strings.NewReader(doc)), "dev", "phone", time.Second)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), res.Imported)
+		assert.Equal(t, int64(1), res.Duplicates)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocations", mock.Anything, mock.Anything).
+			Return(int64(0), errors.New("db down")).Once()
+
+		res, err := importer.Import(context.TODO(), mockLocationRepo,
+			importer.NewCSVParser(strings.NewReader(doc)), "dev", "phone", time.Second)
+
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), res.Imported)
+		assert.Equal(t, int64(2), res.Failed)
+	})
+	t.Run("db error mid stream", func(t *testing.T) {
+		var b strings.Builder
+		b.WriteString("tst,lat,lon\n")
+
+		for i := 0; i < importer.BatchSize*2+1; i++ {
+			fmt.Fprintf(&b, "%d,23.8,90.4\n", 1669888800+i)
+		}
+
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocations", mock.Anything, mock.Anything).
+			Return(int64(importer.BatchSize), nil).Once()
+		mockLocationRepo.On("CreateLocations", mock.Anything, mock.Anything).
+			Return(int64(0), errors.New("db down")).Once()
+
+		res, err := importer.Import(context.TODO(), mockLocationRepo,
+			importer.NewCSVParser(strings.NewReader(b.String())), "dev", "phone", time.Second)
+
+		assert.Error(t, err)
+		assert.Equal(t, int64(importer.BatchSize), res.Imported)
+		assert.Equal(t, int64(importer.BatchSize), res.Failed)
+		mockLocationRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,281 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"ot-recorder/app/model"
+	"strconv"
+	"time"
+)
+
+const (
+	e7 = 1e7
+	// e7Overflow some exports contain E7 values that overflowed the int32 range
+	e7Overflow     = 1 << 32
+	e7MaxLatitude  = 900000000
+	e7MaxLongitude = 1800000000
+)
+
+type takeoutRecord struct {
+	LatitudeE7       *int64   `json:"latitudeE7"`
+	LongitudeE7      *int64   `json:"longitudeE7"`
+	Accuracy         *float64 `json:"accuracy"`
+	Altitude         *float64 `json:"altitude"`
+	VerticalAccuracy *float64 `json:"verticalAccuracy"`
+	Velocity         *float64 `json:"velocity"`
+	Timestamp        string   `json:"timestamp"`
+	TimestampMs      string   `json:"timestampMs"`
+}
+
+type takeoutPlace struct {
+	LatitudeE7  *int64 `json:"latitudeE7"`
+	LongitudeE7 *int64 `json:"longitudeE7"`
+}
+
+type takeoutDuration struct {
+	StartTimestamp   string `json:"startTimestamp"`
+	StartTimestampMs string `json:"startTimestampMs"`
+	EndTimestamp     string `json:"endTimestamp"`
+	EndTimestampMs   string `json:"endTimestampMs"`
+}
+
+type takeoutPathPoint struct {
+	LatE7          *int64 `json:"latE7"`
+	LngE7          *int64 `json:"lngE7"`
+	AccuracyMeters int64  `json:"accuracyMeters"`
+	Timestamp      string `json:"timestamp"`
+	TimestampMs    string `json:"timestampMs"`
+}
+
+type takeoutTimelineObject struct {
+	PlaceVisit *struct {
+		Location takeoutPlace    `json:"location"`
+		Duration takeoutDuration `json:"duration"`
+	} `json:"placeVisit"`
+	ActivitySegment *struct {
+		StartLocation     takeoutPlace    `json:"startLocation"`
+		EndLocation       takeoutPlace    `json:"endLocation"`
+		Duration          takeoutDuration `json:"duration"`
+		SimplifiedRawPath *struct {
+			Points []takeoutPathPoint `json:"points"`
+		} `json:"simplifiedRawPath"`
+	} `json:"activitySegment"`
+}
+
+// Takeout parses Google Takeout location history, both the raw `Records.json`
+// (`locations` array) and the monthly `Semantic Location History` files (`timelineObjects` array).
+// Place visits yield a point at the start & end of the visit, activity segments yield
+// their start, simplified raw path and end points.
+type Takeout struct {
+	r io.Reader
+}
+
+func NewTakeoutParser(r io.Reader) *Takeout {
+	return &Takeout{r: r}
+}
+
+func (p *Takeout) Parse(h Handler) error {
+	decoder := json.NewDecoder(p.r)
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("takeout: %w", err)
+		}
+
+		switch token {
+		case "locations":
+			err = decodeArray(decoder, func() error {
+				var r takeoutRecord
+				if err := decoder.Decode(&r); err != nil {
+					return err
+				}
+
+				return emit(h, r.toLocation())
+			})
+		case "timelineObjects":
+			err = decodeArray(decoder, func() error {
+				var o takeoutTimelineObject
+				if err := decoder.Decode(&o); err != nil {
+					return err
+				}
+
+				for _, l := range o.toLocations() {
+					if err := emit(h, l); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		default:
+			var skip json.RawMessage
+			err = decoder.Decode(&skip)
+		}
+
+		if err != nil {
+			return fmt.Errorf("takeout: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("takeout: %w", err)
+	}
+
+	if token != delim {
+		return fmt.Errorf("takeout: expected %q got %v", delim, token)
+	}
+
+	return nil
+}
+
+func decodeArray(decoder *json.Decoder, next func() error) error {
+	if err := expectDelim(decoder, '['); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		if err := next(); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(decoder, ']')
+}
+
+// emit skips points without position or time, google exports have plenty of them
+func emit(h Handler, l *model.Location) error {
+	if l == nil {
+		return nil
+	}
+
+	if err := validatePoint(l); err != nil {
+		if errors.Is(err, ErrInvalidPoint) {
+			return nil
+		}
+
+		return err
+	}
+
+	return h(l)
+}
+
+func (r *takeoutRecord) toLocation() *model.Location {
+	if r.LatitudeE7 == nil || r.LongitudeE7 == nil {
+		return nil
+	}
+
+	l := &model.Location{
+		CreatedAt: parseTakeoutTime(r.Timestamp, r.TimestampMs),
+		Lat:       fromE7(*r.LatitudeE7, e7MaxLatitude),
+		Lon:       fromE7(*r.LongitudeE7, e7MaxLongitude),
+	}
+
+	if r.Accuracy != nil {
+		l.Acc = toInt16(*r.Accuracy)
+	}
+
+	if r.Altitude != nil {
+		l.Alt = toInt16(*r.Altitude)
+	}
+
+	if r.VerticalAccuracy != nil {
+		l.Vac = toInt16(*r.VerticalAccuracy)
+	}
+
+	if r.Velocity != nil {
+		l.Vel = toInt16(*r.Velocity * mpsToKmph)
+	}
+
+	return l
+}
+
+func (o *takeoutTimelineObject) toLocations() []*model.Location {
+	var locations []*model.Location
+
+	if v := o.PlaceVisit; v != nil {
+		locations = append(locations,
+			v.Location.toLocation(parseTakeoutTime(v.Duration.StartTimestamp, v.Duration.StartTimestampMs)),
+			v.Location.toLocation(parseTakeoutTime(v.Duration.EndTimestamp, v.Duration.EndTimestampMs)),
+		)
+	}
+
+	if s := o.ActivitySegment; s != nil {
+		locations = append(locations,
+			s.StartLocation.toLocation(parseTakeoutTime(s.Duration.StartTimestamp, s.Duration.StartTimestampMs)),
+		)
+
+		if s.SimplifiedRawPath != nil {
+			for i := range s.SimplifiedRawPath.Points {
+				locations = append(locations, s.SimplifiedRawPath.Points[i].toLocation())
+			}
+		}
+
+		locations = append(locations,
+			s.EndLocation.toLocation(parseTakeoutTime(s.Duration.EndTimestamp, s.Duration.EndTimestampMs)),
+		)
+	}
+
+	return locations
+}
+
+func (p *takeoutPlace) toLocation(tst int64) *model.Location {
+	if p.LatitudeE7 == nil || p.LongitudeE7 == nil {
+		return nil
+	}
+
+	return &model.Location{
+		CreatedAt: tst,
+		Lat:       fromE7(*p.LatitudeE7, e7MaxLatitude),
+		Lon:       fromE7(*p.LongitudeE7, e7MaxLongitude),
+	}
+}
+
+func (p *takeoutPathPoint) toLocation() *model.Location {
+	if p.LatE7 == nil || p.LngE7 == nil {
+		return nil
+	}
+
+	return &model.Location{
+		CreatedAt: parseTakeoutTime(p.Timestamp, p.TimestampMs),
+		Acc:       toInt16(float64(p.AccuracyMeters)),
+		Lat:       fromE7(*p.LatE7, e7MaxLatitude),
+		Lon:       fromE7(*p.LngE7, e7MaxLongitude),
+	}
+}
+
+func fromE7(v, limit int64) float64 {
+	if v > limit {
+		v -= e7Overflow
+	}
+
+	return float64(v) / e7
+}
+
+// parseTakeoutTime newer exports use RFC3339 timestamps, older ones epoch milliseconds as string
+func parseTakeoutTime(ts, tsMs string) int64 {
+	if ts != "" {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			return t.Unix()
+		}
+	}
+
+	if tsMs != "" {
+		if ms, err := strconv.ParseInt(tsMs, 10, 64); err == nil {
+			return ms / msPerSecond
+		}
+	}
+
+	return 0
+}
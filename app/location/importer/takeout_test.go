@@ -0,0 +1,73 @@
+package importer_test
+
+import (
+	"ot-recorder/app/location/importer"
+	"ot-recorder/app/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeoutParseRecords(t *testing.T) {
+	doc := `{"locations": [
+  {"latitudeE7": 238103320, "longitudeE7": 904125180, "accuracy": 20, "altitude": 12, "velocity": 5,
+   "timestamp": "2022-12-01T10:00:00.123Z", "source": "WIFI", "activity": [{"type": "STILL"}]},
+  {"latitudeE7": 238105000, "longitudeE7": 904126000, "timestampMs": "1669888830000"},
+  {"timestampMs": "1669888860000"},
+  {"latitudeE7": 4294967295, "longitudeE7": 904126000, "timestampMs": "1669888890000"}
+]}`
+
+	var locations []*model.Location
+
+	err := importer.NewTakeoutParser(strings.NewReader(doc)).Parse(func(l *model.Location) error {
+		locations = append(locations, l)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, locations, 3)
+	assert.Equal(t, int64(1669888800), locations[0].CreatedAt)
+	assert.Equal(t, 23.810332, locations[0].Lat)
+	assert.Equal(t, int16(20), locations[0].Acc)
+	assert.Equal(t, int16(18), locations[0].Vel)
+	assert.Equal(t, int64(1669888830), locations[1].CreatedAt)
+	assert.InDelta(t, -0.0000001, locations[2].Lat, 1e-9)
+}
+
+func TestTakeoutParseSemantic(t *testing.T) {
+	doc := `{"timelineObjects": [
+  {"activitySegment": {
+    "startLocation": {"latitudeE7": 238100000, "longitudeE7": 904100000},
+    "endLocation": {"latitudeE7": 238200000, "longitudeE7": 904200000},
+    "duration": {"startTimestamp": "2022-12-01T10:00:00Z", "endTimestamp": "2022-12-01T10:30:00Z"},
+    "simplifiedRawPath": {"points": [
+      {"latE7": 238150000, "lngE7": 904150000, "accuracyMeters": 10, "timestamp": "2022-12-01T10:15:00Z"}
+    ]}
+  }},
+  {"placeVisit": {
+    "location": {"latitudeE7": 238200000, "longitudeE7": 904200000, "name": "Office"},
+    "duration": {"startTimestampMs": "1669890600000", "endTimestampMs": "1669919400000"}
+  }}
+]}`
+
+	var locations []*model.Location
+
+	err := importer.NewTakeoutParser(strings.NewReader(doc)).Parse(func(l *model.Location) error {
+		locations = append(locations, l)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, locations, 5)
+	assert.Equal(t, int64(1669888800), locations[0].CreatedAt)
+	assert.Equal(t, int16(10), locations[1].Acc)
+	assert.Equal(t, int64(1669890600), locations[2].CreatedAt)
+	assert.Equal(t, 23.82, locations[4].Lat)
+	assert.Equal(t, int64(1669919400), locations[4].CreatedAt)
+}
+
+func TestTakeoutParseInvalid(t *testing.T) {
+	err := importer.NewTakeoutParser(strings.NewReader(`[1, 2]`)).Parse(func(l *model.Location) error { return nil })
+	assert.Error(t, err)
+}
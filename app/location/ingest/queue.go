@@ -0,0 +1,473 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/metrics"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	spillFileMode = 0o600
+	retryInterval = time.Second
+	// failed rows at the start of a batch retried row by row that mean the database is down
+	outageRows = 3
+)
+
+var (
+	// ErrQueueFull returned when the queue stays full until the request context is done
+	ErrQueueFull = errors.New("ingest queue is full")
+	// ErrQueueClosed returned for locations received after shutdown started
+	ErrQueueClosed = errors.New("ingest queue is closed")
+)
+
+// Queue is a write-behind LocationRepository. CreateLocation appends the location to a spill
+// file and buffers it in memory, a background worker stores the buffer in batches by size or
+// time. Stored locations are dropped from the spill file as the worker goes, it is replayed on
+// start, so locations acknowledged before a crash are not lost. Rows of a failed batch are retried
+// one by one, those that still fail go to the dead-letter file. Other methods use the wrapped repository.
+type Queue struct {
+	model.LocationRepository
+
+	cfg     config.IngestConfig
+	slots   chan struct{}
+	notify  chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	mu      sync.Mutex
+	pending []*model.Location
+	flushed int // stored locations still in the spill file
+	spill   *os.File
+	closed  bool
+}
+
+// NewQueue stores locations left in the spill file by a previous run and starts the worker
+func NewQueue(repo model.LocationRepository, cfg config.IngestConfig) (*Queue, error) {
+	q := &Queue{
+		LocationRepository: repo,
+		cfg:                cfg,
+		notify:             make(chan struct{}, 1),
+		stop:               make(chan struct{}),
+		done:               make(chan struct{}),
+	}
+
+	spill, err := os.OpenFile(cfg.SpillFile, os.O_CREATE|os.O_RDWR|os.O_APPEND, spillFileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	q.spill = spill
+
+	if err := q.recover(); err != nil {
+		_ = spill.Close()
+		return nil, fmt.Errorf("ingest spill file %s: %w", cfg.SpillFile, err)
+	}
+
+	// recovered locations that can't be stored yet are pending, the queue holds at least them
+	size := cfg.QueueSize
+	if len(q.pending) > size {
+		size = len(q.pending)
+	}
+
+	q.slots = make(chan struct{}, size)
+	for range q.pending {
+		q.slots <- struct{}{}
+	}
+
+	metrics.SetIngestQueueDepth(q.Depth)
+
+	go q.run()
+
+	return q, nil
+}
+
+// CreateLocation queues the location, waits for free space until the context is done
+func (q *Queue) CreateLocation(ctx context.Context, location *model.Location) error {
+	select {
+	case q.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ErrQueueFull
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		<-q.slots
+		return ErrQueueClosed
+	}
+
+	if err := q.appendSpill(location); err != nil {
+		<-q.slots
+		return err
+	}
+
+	q.pending = append(q.pending, location)
+
+	if len(q.pending) >= q.cfg.BatchSize {
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Depth number of locations waiting to be stored
+func (q *Queue) Depth() int {
+	return len(q.slots)
+}
+
+// Close stops accepting locations and stores the buffer, what can't be stored
+// before the context is done stays in the spill file for the next start
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+
+	q.closed = true
+	q.mu.Unlock()
+
+	close(q.stop)
+
+	select {
+	case <-q.done:
+	case <-ctx.Done():
+		return fmt.Errorf("ingest queue flush: %w", ctx.Err())
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) > 0 {
+		return fmt.Errorf("ingest queue flush: %d locations left in %s", len(q.pending), q.cfg.SpillFile)
+	}
+
+	return q.spill.Close()
+}
+
+func (q *Queue) run() {
+	ticker := time.NewTicker(q.cfg.FlushInterval)
+	defer ticker.Stop()
+	defer close(q.done)
+
+	for {
+		select {
+		case <-q.notify:
+		case <-ticker.C:
+		case <-q.stop:
+			for q.flush() {
+			}
+
+			return
+		}
+
+		for q.flush() {
+		}
+	}
+}
+
+// flush stores one batch, returns true when there may be more to store
+func (q *Queue) flush() bool {
+	q.mu.Lock()
+	n := len(q.pending)
+	if n > q.cfg.BatchSize {
+		n = q.cfg.BatchSize
+	}
+
+	batch := make([]*model.Location, n)
+	copy(batch, q.pending)
+	q.pending = q.pending[n:]
+	q.mu.Unlock()
+
+	if n == 0 {
+		return false
+	}
+
+	if rest, err := q.storeBatch(batch); len(rest) > 0 {
+		logrus.Errorf("ingest queue: storing %d locations failed: %v", len(rest), err)
+
+		q.mu.Lock()
+		q.pending = append(rest, q.pending...)
+		q.mu.Unlock()
+
+		select {
+		case <-q.stop:
+		case <-time.After(retryInterval):
+		}
+
+		return false
+	}
+
+	for i := 0; i < n; i++ {
+		<-q.slots
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.flushed += n
+
+	if err := q.trimSpill(); err != nil {
+		logrus.Errorf("ingest queue: trim spill file: %v", err)
+	}
+
+	return len(q.pending) > 0
+}
+
+// storeBatch stores the batch, when that fails its rows one by one. Rows that still fail are
+// moved to the dead-letter file, unless the first ones or all of them fail: the database is
+// likely down then and the whole batch is returned to be retried later. A single row is
+// moved when the database still answers
+func (q *Queue) storeBatch(batch []*model.Location) ([]*model.Location, error) {
+	err := q.store(batch)
+	if err == nil {
+		return nil, nil
+	}
+
+	if len(batch) == 1 {
+		if !q.reachable(batch[0]) {
+			return batch, err
+		}
+
+		logrus.Errorf("ingest queue: storing a location failed: %v", err)
+		q.deadLetter(batch)
+
+		return nil, nil
+	}
+
+	var failed []*model.Location
+
+	for i, l := range batch {
+		if err = q.store([]*model.Location{l}); err == nil {
+			continue
+		}
+
+		failed = append(failed, l)
+
+		if len(failed) == i+1 && (len(failed) == outageRows || len(failed) == len(batch)) {
+			return batch, err
+		}
+	}
+
+	if len(failed) > 0 {
+		q.deadLetter(failed)
+	}
+
+	return nil, nil
+}
+
+// reachable reports whether the database answers a query for the user of the location
+func (q *Queue) reachable(l *model.Location) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Get().App.ContextTimeout)
+	defer cancel()
+
+	_, err := q.LocationRepository.GetUserLastLocation(ctx, l.Username)
+
+	return err == nil || errors.Is(err, sql.ErrNoRows)
+}
+
+// deadLetter appends locations that can't be stored to the dead-letter file
+func (q *Queue) deadLetter(locations []*model.Location) {
+	metrics.IngestDeadLetters.Add(float64(len(locations)))
+
+	err := func() error {
+		f, err := os.OpenFile(q.cfg.DeadLetterFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, spillFileMode)
+		if err != nil {
+			return err
+		}
+
+		if err := writeLocations(f, locations); err != nil {
+			_ = f.Close()
+			return err
+		}
+
+		return f.Close()
+	}()
+	if err != nil {
+		logrus.Errorf("ingest queue: dead-letter %d locations to %s: %v", len(locations), q.cfg.DeadLetterFile, err)
+		return
+	}
+
+	logrus.Warnf("ingest queue: moved %d locations that can't be stored to %s", len(locations), q.cfg.DeadLetterFile)
+}
+
+// trimSpill drops stored locations from the spill file: it is truncated when nothing is pending
+// and rewritten with the pending locations once stored ones outnumber them. Called by the worker
+// between batches, so every spilled location not stored yet is pending
+func (q *Queue) trimSpill() error {
+	if len(q.pending) == 0 {
+		q.flushed = 0
+		return q.spill.Truncate(0)
+	}
+
+	if q.flushed < len(q.pending) {
+		return nil
+	}
+
+	tmp := q.cfg.SpillFile + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, spillFileMode)
+	if err != nil {
+		return err
+	}
+
+	if err := writeLocations(f, q.pending); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, q.cfg.SpillFile); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	spill, err := os.OpenFile(q.cfg.SpillFile, os.O_RDWR|os.O_APPEND, spillFileMode)
+	if err != nil {
+		return err
+	}
+
+	_ = q.spill.Close()
+	q.spill = spill
+	q.flushed = 0
+
+	return nil
+}
+
+func (q *Queue) store(batch []*model.Location) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Get().App.ContextTimeout+q.cfg.FlushInterval)
+	defer cancel()
+
+	inserted, err := q.LocationRepository.CreateLocations(ctx, batch)
+	if err != nil {
+		metrics.IngestFailures.Inc()
+		return err
+	}
+
+	metrics.IngestStored.Add(float64(inserted))
+	metrics.DuplicatePings.Add(float64(int64(len(batch)) - inserted))
+
+	return nil
+}
+
+func (q *Queue) appendSpill(location *model.Location) error {
+	line, err := json.Marshal(location)
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.spill.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return q.spill.Sync()
+}
+
+// writeLocations writes the locations as lines of JSON and syncs the file
+func writeLocations(f *os.File, locations []*model.Location) error {
+	w := bufio.NewWriter(f)
+
+	for _, l := range locations {
+		line, err := json.Marshal(l)
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// recover stores locations spilled but not stored by a previous run, duplicates of already
+// stored ones are skipped by the repository. Those that can't be stored stay pending in the
+// spill file for the worker, from the first failed batch on as the database is likely down
+func (q *Queue) recover() error {
+	if _, err := q.spill.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var (
+		batch     []*model.Location
+		recovered int
+	)
+
+	store := func() {
+		if len(q.pending) > 0 {
+			q.pending = append(q.pending, batch...)
+			return
+		}
+
+		rest, err := q.storeBatch(batch)
+		if len(rest) > 0 {
+			logrus.Errorf("ingest queue: storing %d recovered locations failed: %v", len(rest), err)
+
+			q.pending = append(q.pending, rest...)
+
+			return
+		}
+
+		recovered += len(batch)
+	}
+
+	scanner := bufio.NewScanner(q.spill)
+	for scanner.Scan() {
+		var l model.Location
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			// last line of a crash may be partially written
+			logrus.Warnf("ingest queue: skip invalid spill line: %v", err)
+			continue
+		}
+
+		batch = append(batch, &l)
+
+		if len(batch) == q.cfg.BatchSize {
+			store()
+
+			batch = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		store()
+	}
+
+	if recovered > 0 {
+		logrus.Infof("ingest queue: recovered %d locations from %s", recovered, q.cfg.SpillFile)
+	}
+
+	q.flushed = recovered
+
+	return q.trimSpill()
+}
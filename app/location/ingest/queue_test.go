@@ -0,0 +1,252 @@
+package ingest_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"ot-recorder/app/location/ingest"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/infrastructure/config"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T) config.IngestConfig {
+	return config.IngestConfig{
+		Enabled:       true,
+		SpillFile:     filepath.Join(t.TempDir(), "spill.ndjson"),
+		FlushInterval: time.Hour,
+		BatchSize:     2,
+		QueueSize:     4,
+	}
+}
+
+func mockLocation(tst int64) *model.Location {
+	return &model.Location{Username: "dev", Device: "phone", CreatedAt: tst, Lat: 23, Lon: 90}
+}
+
+func TestQueueFlushBySize(t *testing.T) {
+	cfg := testConfig(t)
+	stored := make(chan []*model.Location, 1)
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("CreateLocations", mock.Anything, mock.AnythingOfType("[]*model.Location")).
+		Run(func(args mock.Arguments) {
+			stored <- args.Get(1).([]*model.Location)
+		}).Return(int64(2), nil).Once()
+
+	q, err := ingest.NewQueue(mockLocationRepo, cfg)
+	require.NoError(t, err)
+
+	assert.NoError(t, q.CreateLocation(context.TODO(), mockLocation(1)))
+	assert.NoError(t, q.CreateLocation(context.TODO(), mockLocation(2)))
+
+	select {
+	case batch := <-stored:
+		assert.Len(t, batch, 2)
+		assert.Equal(t, int64(1), batch[0].CreatedAt)
+	case <-time.After(time.Second):
+		t.Fatal("batch was not stored")
+	}
+
+	assert.NoError(t, q.Close(context.TODO()))
+
+	spill, err := os.ReadFile(cfg.SpillFile)
+	assert.NoError(t, err)
+	assert.Empty(t, spill)
+	mockLocationRepo.AssertExpectations(t)
+}
+
+func TestQueueFlushOnClose(t *testing.T) {
+	cfg := testConfig(t)
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("CreateLocations", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+		return len(ls) == 1
+	})).Return(int64(1), nil).Once()
+
+	q, err := ingest.NewQueue(mockLocationRepo, cfg)
+	require.NoError(t, err)
+
+	assert.NoError(t, q.CreateLocation(context.TODO(), mockLocation(1)))
+	assert.Equal(t, 1, q.Depth())
+	assert.NoError(t, q.Close(context.TODO()))
+	assert.Equal(t, 0, q.Depth())
+	assert.ErrorIs(t, q.CreateLocation(context.TODO(), mockLocation(2)), ingest.ErrQueueClosed)
+	mockLocationRepo.AssertExpectations(t)
+}
+
+func TestQueueRecoverSpillFile(t *testing.T) {
+	cfg := testConfig(t)
+	spill := `{"username":"dev","device":"phone","created_at":1,"lat":23,"lon":90}
+{"username":"dev","device":"phone","created_at":2,"lat":23,"lon":90}
+{"username":"dev","device":"pho`
+	require.NoError(t, os.WriteFile(cfg.SpillFile, []byte(spill), 0o600))
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("CreateLocations", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+		return len(ls) == 2 && ls[1].CreatedAt == 2
+	})).Return(int64(1), nil).Once()
+
+	q, err := ingest.NewQueue(mockLocationRepo, cfg)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(cfg.SpillFile)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+	assert.NoError(t, q.Close(context.TODO()))
+	mockLocationRepo.AssertExpectations(t)
+}
+
+func TestQueueRecoverFailedRows(t *testing.T) {
+	spill := `{"username":"dev","device":"phone","created_at":1,"lat":23,"lon":90}
+`
+
+	t.Run("bad row", func(t *testing.T) {
+		cfg := testConfig(t)
+		cfg.DeadLetterFile = filepath.Join(t.TempDir(), "failed.ndjson")
+		require.NoError(t, os.WriteFile(cfg.SpillFile, []byte(spill), 0o600))
+
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocations", mock.Anything, mock.Anything).
+			Return(int64(0), errors.New("invalid row")).Once()
+		mockLocationRepo.On("GetUserLastLocation", mock.Anything, "dev").Return(model.Location{}, nil).Once()
+
+		q, err := ingest.NewQueue(mockLocationRepo, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, 0, q.Depth())
+
+		failed, err := os.ReadFile(cfg.DeadLetterFile)
+		assert.NoError(t, err)
+		assert.Contains(t, string(failed), `"created_at":1`)
+
+		data, err := os.ReadFile(cfg.SpillFile)
+		assert.NoError(t, err)
+		assert.Empty(t, data)
+		assert.NoError(t, q.Close(context.TODO()))
+		mockLocationRepo.AssertExpectations(t)
+	})
+
+	t.Run("database down", func(t *testing.T) {
+		cfg := testConfig(t)
+		require.NoError(t, os.WriteFile(cfg.SpillFile, []byte(spill), 0o600))
+
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocations", mock.Anything, mock.Anything).
+			Return(int64(0), errors.New("db down")).Once()
+		mockLocationRepo.On("GetUserLastLocation", mock.Anything, "dev").
+			Return(model.Location{}, errors.New("db down")).Once()
+		mockLocationRepo.On("CreateLocations", mock.Anything, mock.Anything).Return(int64(1), nil).Once()
+
+		q, err := ingest.NewQueue(mockLocationRepo, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, 1, q.Depth())
+
+		data, err := os.ReadFile(cfg.SpillFile)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"created_at":1`)
+
+		// the worker stores it once the database is back
+		assert.NoError(t, q.Close(context.TODO()))
+		mockLocationRepo.AssertExpectations(t)
+	})
+}
+
+func TestQueueBackpressure(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.BatchSize = 1
+	cfg.QueueSize = 1
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("CreateLocations", mock.Anything, mock.AnythingOfType("[]*model.Location")).
+		Return(int64(0), errors.New("db down"))
+	mockLocationRepo.On("GetUserLastLocation", mock.Anything, "dev").Return(model.Location{}, errors.New("db down"))
+
+	q, err := ingest.NewQueue(mockLocationRepo, cfg)
+	require.NoError(t, err)
+
+	assert.NoError(t, q.CreateLocation(context.TODO(), mockLocation(1)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, q.CreateLocation(ctx, mockLocation(2)), ingest.ErrQueueFull)
+
+	// unstored location stays in the spill file
+	assert.Error(t, q.Close(context.TODO()))
+
+	data, err := os.ReadFile(cfg.SpillFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"created_at":1`)
+}
+
+func TestQueueDeadLetter(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.DeadLetterFile = filepath.Join(t.TempDir(), "failed.ndjson")
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("CreateLocations", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+		return len(ls) == 2
+	})).Return(int64(0), errors.New("invalid row")).Once()
+	mockLocationRepo.On("CreateLocations", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+		return len(ls) == 1 && ls[0].CreatedAt == 1
+	})).Return(int64(1), nil).Once()
+	mockLocationRepo.On("CreateLocations", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+		return len(ls) == 1 && ls[0].CreatedAt == 2
+	})).Return(int64(0), errors.New("invalid row")).Once()
+
+	q, err := ingest.NewQueue(mockLocationRepo, cfg)
+	require.NoError(t, err)
+
+	assert.NoError(t, q.CreateLocation(context.TODO(), mockLocation(1)))
+	assert.NoError(t, q.CreateLocation(context.TODO(), mockLocation(2)))
+	assert.NoError(t, q.Close(context.TODO()))
+
+	failed, err := os.ReadFile(cfg.DeadLetterFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(failed), `"created_at":2`)
+	assert.NotContains(t, string(failed), `"created_at":1`)
+
+	spill, err := os.ReadFile(cfg.SpillFile)
+	assert.NoError(t, err)
+	assert.Empty(t, spill)
+	mockLocationRepo.AssertExpectations(t)
+}
+
+func TestQueueTrimSpill(t *testing.T) {
+	cfg := testConfig(t)
+	release := make(chan struct{})
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("CreateLocations", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+		return len(ls) == 2
+	})).Return(int64(2), nil).Once()
+	mockLocationRepo.On("CreateLocations", mock.Anything, mock.MatchedBy(func(ls []*model.Location) bool {
+		return len(ls) == 1 && ls[0].CreatedAt == 3
+	})).Run(func(mock.Arguments) { <-release }).Return(int64(1), nil).Once()
+
+	q, err := ingest.NewQueue(mockLocationRepo, cfg)
+	require.NoError(t, err)
+
+	for tst := int64(1); tst <= 3; tst++ {
+		assert.NoError(t, q.CreateLocation(context.TODO(), mockLocation(tst)))
+	}
+
+	// the stored batch is dropped from the spill file while the last location is pending
+	assert.Eventually(t, func() bool {
+		spill, err := os.ReadFile(cfg.SpillFile)
+		return err == nil && strings.Count(string(spill), "\n") == 1 &&
+			strings.Contains(string(spill), `"created_at":3`)
+	}, time.Second, 10*time.Millisecond)
+
+	close(release)
+	assert.NoError(t, q.Close(context.TODO()))
+	mockLocationRepo.AssertExpectations(t)
+}
@@ -2,6 +2,7 @@ package mysql_test
 
 import (
 	"context"
+	"encoding/json"
 	locationRepo "ot-recorder/app/location/repository/mysql"
 	"ot-recorder/app/model"
 	"testing"
@@ -29,6 +30,11 @@ func TestCreateLocation(t *testing.T) {
 		Bssid:     "c0:00:00:00:00:00",
 		Ssid:      "dev-test",
 		IP:        "127.0.0.1",
+		Cog:       90,
+		Conn:      "w",
+		Topic:     "owntracks/dev/phoneAndroid",
+		InRegions: model.StringList{"home"},
+		Raw:       json.RawMessage(`{"_type":"location"}`),
 	}
 
 	db, mock, err := sqlmock.New()
@@ -56,6 +62,20 @@ func TestCreateLocation(t *testing.T) {
 		l.Bssid,
 		l.Ssid,
 		l.IP,
+		"wh04b5008",
+		l.Cog,
+		l.Rad,
+		l.P,
+		l.Poi,
+		l.Tag,
+		l.Conn,
+		l.Topic,
+		l.MsgID,
+		l.MsgCreatedAt,
+		`["home"]`,
+		"",
+		"",
+		`{"_type":"location"}`,
 	).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -64,6 +84,77 @@ func TestCreateLocation(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestCreateDuplicateLocation(t *testing.T) {
+	l := &model.Location{
+		Username:  "dev",
+		Device:    "phoneAndroid",
+		CreatedAt: time.Now().Unix(),
+		Lat:       23.0000000,
+		Lon:       90.0000000,
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectPrepare("INSERT INTO locations").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+	err = ur.CreateLocation(context.TODO(), l)
+	assert.ErrorIs(t, err, model.ErrDuplicateLocation)
+}
+
+func TestCreateLocationBatch(t *testing.T) {
+	locations := []*model.Location{
+		{Username: "dev", Device: "phoneAndroid", CreatedAt: time.Now().Unix(), Lat: 23.0000000, Lon: 90.0000000},
+		{Username: "dev", Device: "phoneAndroid", CreatedAt: time.Now().Unix(), Lat: 23.0000000, Lon: 90.0000000},
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare("INSERT INTO locations")
+	prep.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	prep.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+	results, err := ur.CreateLocationBatch(context.TODO(), locations)
+	assert.NoError(t, err)
+	assert.NoError(t, results[0])
+	assert.ErrorIs(t, results[1], model.ErrDuplicateLocation)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateLocations(t *testing.T) {
+	locations := []*model.Location{
+		{Username: "dev", Device: "phoneAndroid", CreatedAt: time.Now().Unix(), Lat: 23.0000000, Lon: 90.0000000},
+		{Username: "dev", Device: "phoneAndroid", CreatedAt: time.Now().Unix() + 1, Lat: 23.1000000, Lon: 90.1000000},
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO locations").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+	inserted, err := ur.CreateLocations(context.TODO(), locations)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), inserted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetUserLastLocation(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -73,11 +164,13 @@ func TestGetUserLastLocation(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{
 		"id", "username", "device", "created_at", "acc", "alt", "batt", "bs", "lat", "lon", "m", "t", "tid", "vac",
-		"vel", "bssid", "ssid", "ip"}).
+		"vel", "bssid", "ssid", "ip", "cog", "rad", "p", "poi", "tag", "conn", "topic", "msg_id",
+		"msg_created_at", "inregions", "inrids", "motion_activities"}).
 		AddRow(1, "dev", "phoneAndroid", time.Now().Unix(), 13, -42, 40, 1, 23.0000000, 90.0000000, 1, "p",
-			"p1", 1, 0, "", "", "")
+			"p1", 1, 0, "", "", "",
+			90, 0, 0.0, "", "", "w", "", "", 0, `["home"]`, "", `["walking"]`)
 
-	query := "SELECT \\* FROM locations WHERE username = \\? ORDER BY created_at DESC LIMIT 1"
+	query := "SELECT id, (.+), motion_activities FROM locations WHERE username = \\? ORDER BY created_at DESC LIMIT 1"
 	mock.ExpectQuery(query).WillReturnRows(rows)
 
 	ur := locationRepo.NewMysqlLocationRepository(db)
@@ -86,4 +179,309 @@ func TestGetUserLastLocation(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, location)
 	assert.Equal(t, "phoneAndroid", location.Device)
+	assert.Equal(t, int16(90), location.Cog)
+	assert.Equal(t, model.StringList{"home"}, location.InRegions)
+	assert.Nil(t, location.InRids)
+	assert.Equal(t, model.StringList{"walking"}, location.MotionActivities)
+}
+
+func TestGetLocations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now().Unix()
+	rows := sqlmock.NewRows([]string{
+		"id", "username", "device", "created_at", "acc", "alt", "batt", "bs", "lat", "lon", "m", "t", "tid", "vac",
+		"vel", "bssid", "ssid", "ip", "cog", "rad", "p", "poi", "tag", "conn", "topic", "msg_id",
+		"msg_created_at", "inregions", "inrids", "motion_activities"}).
+		AddRow(1, "dev", "phoneAndroid", now-60, 13, -42, 40, 1, 23.0000000, 90.0000000, 1, "p", "p1", 1, 0, "", "", "",
+			0, 0, 0.0, "", "", "", "", "", 0, "", "", "").
+		AddRow(2, "dev", "phoneAndroid", now, 13, -42, 40, 1, 23.0010000, 90.0010000, 1, "p", "p1", 1, 0, "", "", "",
+			0, 0, 0.0, "", "", "", "", "", 0, "", "", "")
+
+	query := "SELECT id, (.+), motion_activities FROM locations\\s+" +
+		"WHERE username = \\? AND created_at BETWEEN \\? AND \\? " +
+		"AND device = \\? ORDER BY created_at LIMIT \\?"
+	mock.ExpectQuery(query).WithArgs("dev", now-3600, now, "phoneAndroid", 100).WillReturnRows(rows)
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+
+	locations, err := ur.GetLocations(context.TODO(), model.LocationQuery{
+		Username: "dev",
+		Device:   "phoneAndroid",
+		From:     now - 3600,
+		To:       now,
+		Limit:    100,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, locations, 2)
+	assert.Equal(t, now, locations[1].CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"username"}).AddRow("dev").AddRow("mom")
+	mock.ExpectQuery("SELECT DISTINCT username FROM locations ORDER BY username").WillReturnRows(rows)
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+
+	users, err := ur.GetUsers(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev", "mom"}, users)
+}
+
+func TestGetUserDevices(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"device", "max"}).AddRow("phone", 1700000000).AddRow("tablet", 1690000000)
+	mock.ExpectQuery("SELECT device, MAX\\(created_at\\) FROM locations WHERE username = \\?").
+		WithArgs("dev").WillReturnRows(rows)
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+
+	devices, err := ur.GetUserDevices(context.TODO(), "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Device{{Name: "phone", LastSeen: 1700000000}, {Name: "tablet", LastSeen: 1690000000}}, devices)
+}
+
+func TestGetLastLocations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now().Unix()
+	columns := []string{
+		"id", "username", "device", "created_at", "acc", "alt", "batt", "bs", "lat", "lon", "m", "t", "tid", "vac",
+		"vel", "bssid", "ssid", "ip", "cog", "rad", "p", "poi", "tag", "conn", "topic", "msg_id",
+		"msg_created_at", "inregions", "inrids", "motion_activities"}
+
+	t.Run("every user", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(3, "dev", "phone", now, 13, -42, 40, 1, 23.0000000, 90.0000000, 1, "p", "p1", 1, 0, "", "", "",
+				0, 0, 0.0, "", "", "", "", "", 0, "", "", "").
+			AddRow(2, "dev", "phone", now, 13, -42, 40, 1, 23.0010000, 90.0010000, 1, "p", "p1", 1, 0, "", "", "",
+				0, 0, 0.0, "", "", "", "", "", 0, "", "", "").
+			AddRow(1, "dev", "tablet", now-60, 13, -42, 40, 1, 23.0020000, 90.0020000, 1, "p", "t1", 1, 0, "", "", "",
+				0, 0, 0.0, "", "", "", "", "", 0, "", "", "").
+			AddRow(4, "mom", "phone", now-30, 13, -42, 40, 1, 23.0030000, 90.0030000, 1, "p", "m1", 1, 0, "", "", "",
+				0, 0, 0.0, "", "", "", "", "", 0, "", "", "")
+		mock.ExpectQuery("FROM locations GROUP BY username, device").WithArgs().WillReturnRows(rows)
+
+		ur := locationRepo.NewMysqlLocationRepository(db)
+
+		locations, err := ur.GetLastLocations(context.TODO(), "")
+		assert.NoError(t, err)
+		assert.Len(t, locations, 3)
+		assert.Equal(t, int64(3), locations[0].ID)
+		assert.Equal(t, "tablet", locations[1].Device)
+		assert.Equal(t, "mom", locations[2].Username)
+	})
+
+	t.Run("one user", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(1, "dev", "tablet", now-60, 13, -42, 40, 1, 23.0020000, 90.0020000, 1, "p", "t1", 1, 0, "", "", "",
+				0, 0, 0.0, "", "", "", "", "", 0, "", "", "")
+		mock.ExpectQuery("FROM locations WHERE username = \\? GROUP BY username, device").
+			WithArgs("dev").WillReturnRows(rows)
+
+		ur := locationRepo.NewMysqlLocationRepository(db)
+
+		locations, err := ur.GetLastLocations(context.TODO(), "dev")
+		assert.NoError(t, err)
+		assert.Len(t, locations, 1)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLocationsWithin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now().Unix()
+	columns := []string{
+		"id", "username", "device", "created_at", "acc", "alt", "batt", "bs", "lat", "lon", "m", "t", "tid", "vac",
+		"vel", "bssid", "ssid", "ip", "cog", "rad", "p", "poi", "tag", "conn", "topic", "msg_id",
+		"msg_created_at", "inregions", "inrids", "motion_activities"}
+
+	rows := sqlmock.NewRows(columns).
+		AddRow(1, "dev", "phone", now-60, 13, -42, 40, 1, 23.8103, 90.4125, 1, "p", "p1", 1, 0, "", "", "",
+			0, 0, 0.0, "", "", "", "", "", 0, "", "", "")
+
+	query := "FROM locations\\s+WHERE created_at BETWEEN \\? AND \\? " +
+		"AND lat BETWEEN \\? AND \\? AND lon BETWEEN \\? AND \\? " +
+		"AND \\(geohash >= \\? AND geohash < \\? OR geohash >= \\? AND geohash < \\? " +
+		"OR geohash IS NULL OR geohash = ''\\) " +
+		"AND ST_Distance_Sphere\\(POINT\\(lon, lat\\), POINT\\(\\?, \\?\\)\\) <= \\? AND username = \\? " +
+		"ORDER BY created_at LIMIT \\?"
+	mock.ExpectQuery(query).
+		WithArgs(now-3600, now, 23.80, 23.82, 90.40, 90.43, "wh0r3", "wh0r3~", "wh0r9", "wh0r9~",
+			90.4125, 23.8103, 500.0, "dev", 100).
+		WillReturnRows(rows)
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+
+	locations, err := ur.GetLocationsWithin(context.TODO(), model.SpatialQuery{
+		Username: "dev",
+		From:     now - 3600,
+		To:       now,
+		MinLat:   23.80,
+		MinLon:   90.40,
+		MaxLat:   23.82,
+		MaxLon:   90.43,
+		Lat:      23.8103,
+		Lon:      90.4125,
+		Radius:   500,
+		Limit:    100,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, locations, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBackfillGeohash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "lat", "lon"}).
+		AddRow(3, 23.0, 90.0).
+		AddRow(7, 23.8103, 90.4125)
+	mock.ExpectQuery("SELECT id, lat, lon FROM locations WHERE id > \\? AND geohash IS NULL").
+		WithArgs(0, 500).WillReturnRows(rows)
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare("UPDATE locations SET geohash")
+	prep.ExpectExec().WithArgs("wh04b5008", 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithArgs("wh0r3qs35", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT id, lat, lon FROM locations").
+		WithArgs(7, 500).WillReturnRows(sqlmock.NewRows([]string{"id", "lat", "lon"}))
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+
+	lastID, updated, err := ur.BackfillGeohash(context.TODO(), 0, 500)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), lastID)
+	assert.Equal(t, int64(2), updated)
+
+	lastID, updated, err = ur.BackfillGeohash(context.TODO(), lastID, 500)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), lastID)
+	assert.Zero(t, updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetHeatmap(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"cell", "count", "lat", "lon"}).
+		AddRow("wh0r3qs", 42, 23.8103, 90.4125).
+		AddRow("wh0r3qt", 1, 23.8110, 90.4130)
+	query := "SELECT substr\\(geohash, 1, \\?\\) AS cell, COUNT\\(\\*\\), AVG\\(lat\\), AVG\\(lon\\) FROM locations\\s+" +
+		"WHERE username = \\? AND created_at BETWEEN \\? AND \\? AND geohash IS NOT NULL AND device = \\? " +
+		"GROUP BY cell ORDER BY cell"
+	mock.ExpectQuery(query).WithArgs(7, "dev", 100, 200, "phone").WillReturnRows(rows)
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+
+	cells, err := ur.GetHeatmap(context.TODO(), model.LocationQuery{
+		Username: "dev",
+		Device:   "phone",
+		From:     100,
+		To:       200,
+	}, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.HeatmapCell{
+		{Geohash: "wh0r3qs", Lat: 23.8103, Lon: 90.4125, Count: 42},
+		{Geohash: "wh0r3qt", Lat: 23.8110, Lon: 90.4130, Count: 1},
+	}, cells)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBatteryHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"device", "bucket", "count", "min", "max", "avg", "unplugged", "charging"}).
+		AddRow("phone", 900, 3, 38, 40, 39.0, 3, 0).
+		AddRow("phone", 2700, 2, 41, 45, 43.0, 0, 2)
+	query := "SELECT device, created_at - created_at % \\? AS bucket, COUNT\\(\\*\\), MIN\\(batt\\), MAX\\(batt\\),\\s+" +
+		"AVG\\(batt\\), SUM\\(CASE WHEN bs = 1 THEN 1 ELSE 0 END\\), " +
+		"SUM\\(CASE WHEN bs IN \\(2, 3\\) THEN 1 ELSE 0 END\\)\\s+" +
+		"FROM locations WHERE username = \\? AND created_at BETWEEN \\? AND \\? AND batt > 0 AND device = \\? " +
+		"GROUP BY device, bucket ORDER BY device, bucket"
+	mock.ExpectQuery(query).WithArgs(900, "dev", 100, 3600, "phone").WillReturnRows(rows)
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+
+	buckets, err := ur.GetBatteryHistory(context.TODO(), model.LocationQuery{
+		Username: "dev",
+		Device:   "phone",
+		From:     100,
+		To:       3600,
+	}, 900)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.BatteryBucket{
+		{Device: "phone", From: 900, Points: 3, Min: 38, Max: 40, Avg: 39, Unplugged: 3},
+		{Device: "phone", From: 2700, Points: 2, Min: 41, Max: 45, Avg: 43, Charging: 2},
+	}, buckets)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetNetworkHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"device", "bucket", "ssid", "bssid", "ip", "count", "first_seen", "last_seen"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("phone", 0, "home", "c0:00:00:00:00:00", "10.0.0.2", 4, 60, 600).
+		AddRow("phone", 0, "", "", "100.64.0.9", 2, 700, 800)
+	query := "SELECT device, created_at - created_at % \\? AS bucket, COALESCE\\(ssid, ''\\),\\s+" +
+		"COALESCE\\(bssid, ''\\), COALESCE\\(ip, ''\\), COUNT\\(\\*\\), MIN\\(created_at\\) AS first_seen, " +
+		"MAX\\(created_at\\)\\s+FROM locations WHERE username = \\? AND created_at BETWEEN \\? AND \\? " +
+		"GROUP BY device, bucket, ssid, bssid, ip ORDER BY device, bucket, first_seen"
+	mock.ExpectQuery(query).WithArgs(3600, "dev", 0, 3600).WillReturnRows(rows)
+
+	ur := locationRepo.NewMysqlLocationRepository(db)
+
+	buckets, err := ur.GetNetworkHistory(context.TODO(), model.LocationQuery{Username: "dev", To: 3600}, 3600)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.NetworkBucket{
+		{
+			Device: "phone", WifiName: "home", WifiMAC: "c0:00:00:00:00:00", IPAddress: "10.0.0.2",
+			Points: 4, FirstSeen: 60, LastSeen: 600,
+		},
+		{Device: "phone", IPAddress: "100.64.0.9", Points: 2, FirstSeen: 700, LastSeen: 800},
+	}, buckets)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -3,11 +3,35 @@ package pgsql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ot-recorder/app/geo"
 	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/metrics"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//nolint:gochecknoglobals
+var (
+	tracer         = otel.Tracer("ot-recorder/app/location/repository/pgsql")
+	spanAttributes = trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBSQLTableKey.String("locations"))
 )
 
 type locationRepository struct {
 	db *sql.DB
+
+	postgisOnce sync.Once
+	postgis     bool
 }
 
 func NewPgsqlLocationRepository(db *sql.DB) model.LocationRepository {
@@ -17,45 +41,763 @@ func NewPgsqlLocationRepository(db *sql.DB) model.LocationRepository {
 }
 
 const createLocation = `INSERT INTO locations (
-  username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel, bssid, ssid, ip
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+  username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel, bssid, ssid, ip, geohash,
+  cog, rad, p, poi, tag, conn, topic, msg_id, msg_created_at, inregions, inrids, motion_activities, raw
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+  $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31
+)
+ON CONFLICT DO NOTHING
 `
 
 func (r *locationRepository) CreateLocation(ctx context.Context, location *model.Location) error {
+	defer metrics.ObserveDBQuery("location", "CreateLocation", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.CreateLocation", spanAttributes)
+	defer span.End()
+
 	stmt, err := r.db.PrepareContext(ctx, createLocation)
 	if err != nil {
 		return err
 	}
 
 	defer stmt.Close()
-	_, err = stmt.ExecContext(ctx,
-		location.Username,
-		location.Device,
-		location.CreatedAt,
-		location.Acc,
-		location.Alt,
-		location.Batt,
-		location.Bs,
-		location.Lat,
-		location.Lon,
-		location.M,
-		location.T,
-		location.Tid,
-		location.Vac,
-		location.Vel,
-		location.Bssid,
-		location.Ssid,
-		location.IP,
+	res, err := stmt.ExecContext(ctx, locationArgs(location)...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return model.ErrDuplicateLocation
+	}
+
+	return nil
+}
+
+// CreateLocationBatch stores locations one by one in a single transaction, the returned
+// slice holds ErrDuplicateLocation for every skipped location and nil for stored ones
+func (r *locationRepository) CreateLocationBatch(ctx context.Context, locations []*model.Location) ([]error, error) {
+	defer metrics.ObserveDBQuery("location", "CreateLocationBatch", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.CreateLocationBatch", spanAttributes)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, createLocation)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	results := make([]error, len(locations))
+
+	for i, l := range locations {
+		res, err := stmt.ExecContext(ctx, locationArgs(l)...)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		if affected == 0 {
+			results[i] = model.ErrDuplicateLocation
+		}
+	}
+
+	return results, tx.Commit()
+}
+
+const (
+	createLocations = `INSERT INTO locations (
+  username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel, bssid, ssid, ip, geohash,
+  cog, rad, p, poi, tag, conn, topic, msg_id, msg_created_at, inregions, inrids, motion_activities, raw
+) VALUES %s
+ON CONFLICT DO NOTHING
+`
+	locationColumns  = 31
+	maxRowsPerInsert = 1000
+)
+
+// CreateLocations stores locations in one transaction, duplicates are skipped and the number
+// of stored rows is returned. With the pgx driver rows are COPY'd to a temporary table and
+// moved with INSERT ... ON CONFLICT, COPY alone can't skip duplicates.
+func (r *locationRepository) CreateLocations(ctx context.Context, locations []*model.Location) (int64, error) {
+	defer metrics.ObserveDBQuery("location", "CreateLocations", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.CreateLocations", spanAttributes)
+	defer span.End()
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer conn.Close()
+
+	var inserted int64
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errCopyUnsupported
+		}
+
+		inserted, err = copyLocations(ctx, pgxConn.Conn(), locations)
+
+		return err
+	})
+	if errors.Is(err, errCopyUnsupported) {
+		return r.insertLocations(ctx, locations)
+	}
+
+	return inserted, err
+}
+
+const (
+	createLocationsBatch = `CREATE TEMP TABLE locations_batch ON COMMIT DROP AS
+SELECT username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel, bssid, ssid, ip, geohash,
+  cog, rad, p, poi, tag, conn, topic, msg_id, msg_created_at, inregions, inrids, motion_activities, raw
+FROM locations WITH NO DATA`
+	moveLocationsBatch = `INSERT INTO locations (
+  username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel, bssid, ssid, ip, geohash,
+  cog, rad, p, poi, tag, conn, topic, msg_id, msg_created_at, inregions, inrids, motion_activities, raw
+) SELECT username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel, bssid, ssid, ip, geohash,
+  cog, rad, p, poi, tag, conn, topic, msg_id, msg_created_at, inregions, inrids, motion_activities, raw
+FROM locations_batch
+ON CONFLICT DO NOTHING`
+)
+
+//nolint:gochecknoglobals
+var (
+	errCopyUnsupported = errors.New("driver does not support copy")
+	copyColumns        = []string{
+		"username", "device", "created_at", "acc", "alt", "batt", "bs", "lat", "lon",
+		"m", "t", "tid", "vac", "vel", "bssid", "ssid", "ip", "geohash",
+		"cog", "rad", "p", "poi", "tag", "conn", "topic", "msg_id", "msg_created_at",
+		"inregions", "inrids", "motion_activities", "raw",
+	}
+)
+
+func copyLocations(ctx context.Context, conn *pgx.Conn, locations []*model.Location) (int64, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, createLocationsBatch); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"locations_batch"}, copyColumns,
+		pgx.CopyFromSlice(len(locations), func(i int) ([]interface{}, error) {
+			return locationArgs(locations[i]), nil
+		}),
 	)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := tx.Exec(ctx, moveLocationsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
+
+func (r *locationRepository) insertLocations(ctx context.Context, locations []*model.Location) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var inserted int64
+
+	for start := 0; start < len(locations); start += maxRowsPerInsert {
+		end := start + maxRowsPerInsert
+		if end > len(locations) {
+			end = len(locations)
+		}
+
+		args := make([]interface{}, 0, (end-start)*locationColumns)
+		for _, l := range locations[start:end] {
+			args = append(args, locationArgs(l)...)
+		}
+
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(createLocations, locationValues(end-start)), args...)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+
+		inserted += affected
+	}
+
+	return inserted, tx.Commit()
+}
+
+// locationValues builds numbered placeholders of n rows, e.g. ($1, $2), ($3, $4)
+func locationValues(n int) string {
+	rows := make([]string, n)
+	for i := range rows {
+		holders := make([]string, locationColumns)
+		for j := range holders {
+			holders[j] = fmt.Sprintf("$%d", i*locationColumns+j+1)
+		}
 
-	return err
+		rows[i] = "(" + strings.Join(holders, ", ") + ")"
+	}
+
+	return strings.Join(rows, ", ")
+}
+
+func locationArgs(l *model.Location) []interface{} {
+	return []interface{}{
+		l.Username,
+		l.Device,
+		l.CreatedAt,
+		l.Acc,
+		l.Alt,
+		l.Batt,
+		l.Bs,
+		l.Lat,
+		l.Lon,
+		l.M,
+		l.T,
+		l.Tid,
+		l.Vac,
+		l.Vel,
+		l.Bssid,
+		l.Ssid,
+		l.IP,
+		geo.Geohash(l.Lat, l.Lon, geo.GeohashPrecision),
+		l.Cog,
+		l.Rad,
+		l.P,
+		l.Poi,
+		l.Tag,
+		l.Conn,
+		l.Topic,
+		l.MsgID,
+		l.MsgCreatedAt,
+		l.InRegions.String(),
+		l.InRids.String(),
+		l.MotionActivities.String(),
+		rawArg(l.Raw),
+	}
+}
+
+// rawArg stores the raw message as text, NULL when it was not kept
+func rawArg(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	return string(raw)
 }
 
-const getPing = `SELECT * FROM locations WHERE username = $1 ORDER BY created_at DESC LIMIT 1`
+const locationSelectColumns = `id, username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel,
+  bssid, ssid, ip, cog, rad, p, poi, tag, conn, topic, msg_id, msg_created_at, inregions, inrids,
+  motion_activities`
+
+const getPing = `SELECT ` + locationSelectColumns + ` FROM locations
+WHERE username = $1 ORDER BY created_at DESC LIMIT 1`
 
 func (r *locationRepository) GetUserLastLocation(ctx context.Context, username string) (model.Location, error) {
-	row := r.db.QueryRowContext(ctx, getPing, username)
+	defer metrics.ObserveDBQuery("location", "GetUserLastLocation", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetUserLastLocation", spanAttributes)
+	defer span.End()
+
+	return scanLocation(r.db.QueryRowContext(ctx, getPing, username))
+}
+
+const getLocations = `SELECT ` + locationSelectColumns + ` FROM locations
+WHERE username = $1 AND created_at BETWEEN $2 AND $3`
+
+// GetLocations returns locations of the query ordered by time
+func (r *locationRepository) GetLocations(ctx context.Context, query model.LocationQuery) ([]model.Location, error) {
+	defer metrics.ObserveDBQuery("location", "GetLocations", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetLocations", spanAttributes)
+	defer span.End()
+
+	stmt := getLocations
+	args := []interface{}{query.Username, query.From, query.To}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += fmt.Sprintf(` AND device = $%d`, len(args))
+	}
+
+	args = append(args, query.Limit)
+	stmt += fmt.Sprintf(` ORDER BY created_at LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var locations []model.Location
+
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, l)
+	}
+
+	return locations, rows.Err()
+}
+
+const getUsers = `SELECT DISTINCT username FROM locations ORDER BY username`
+
+// GetUsers returns every user with a location, ordered by name
+func (r *locationRepository) GetUsers(ctx context.Context) ([]string, error) {
+	defer metrics.ObserveDBQuery("location", "GetUsers", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetUsers", spanAttributes)
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, getUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := []string{}
+
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+
+		users = append(users, username)
+	}
+
+	return users, rows.Err()
+}
+
+const getUserDevices = `SELECT device, MAX(created_at) FROM locations WHERE username = $1
+GROUP BY device ORDER BY device`
+
+// GetUserDevices returns the devices of a user with the time of their latest location
+func (r *locationRepository) GetUserDevices(ctx context.Context, username string) ([]model.Device, error) {
+	defer metrics.ObserveDBQuery("location", "GetUserDevices", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetUserDevices", spanAttributes)
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, getUserDevices, username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	devices := []model.Device{}
+
+	for rows.Next() {
+		var d model.Device
+		if err := rows.Scan(&d.Name, &d.LastSeen); err != nil {
+			return nil, err
+		}
+
+		devices = append(devices, d)
+	}
+
+	return devices, rows.Err()
+}
+
+// getLastLocations joins the newest created_at of every user & device, found on the
+// locations_index_udc index, back to its row
+const getLastLocations = `SELECT ` + locationSelectColumns + ` FROM locations
+JOIN (
+  SELECT username AS latest_username, device AS latest_device, MAX(created_at) AS latest_created_at
+  FROM locations%s GROUP BY username, device
+) latest ON username = latest_username AND device = latest_device AND created_at = latest_created_at
+ORDER BY username, device, id DESC`
+
+// GetLastLocations returns the newest location of every device, of one user when username isn't empty
+func (r *locationRepository) GetLastLocations(ctx context.Context, username string) ([]model.Location, error) {
+	defer metrics.ObserveDBQuery("location", "GetLastLocations", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetLastLocations", spanAttributes)
+	defer span.End()
+
+	where := ""
+	args := []interface{}{}
+
+	if username != "" {
+		args = append(args, username)
+		where = fmt.Sprintf(` WHERE username = $%d`, len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(getLastLocations, where), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	locations := []model.Location{}
+
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		// locations of a device with the same time, keep the last stored
+		if n := len(locations); n > 0 && locations[n-1].Username == l.Username && locations[n-1].Device == l.Device {
+			continue
+		}
+
+		locations = append(locations, l)
+	}
+
+	return locations, rows.Err()
+}
+
+const getLocationsWithin = `SELECT ` + locationSelectColumns + ` FROM locations
+WHERE created_at BETWEEN $1 AND $2 AND lat BETWEEN $3 AND $4 AND lon BETWEEN $5 AND $6`
+
+// radius conditions of lat $%[1]d, lon $%[2]d & radius $%[3]d, the PostGIS one uses locations_index_geography
+const (
+	withinRadiusPostGIS = ` AND ST_DWithin(geography(ST_MakePoint(lon, lat)),
+  geography(ST_MakePoint($%[2]d, $%[1]d)), $%[3]d)`
+	withinRadius = ` AND 2 * 6371008.8 * asin(least(1, sqrt(power(sin(radians(lat - $%[1]d) / 2), 2) +
+  cos(radians($%[1]d)) * cos(radians(lat)) * power(sin(radians(lon - $%[2]d) / 2), 2)))) <= $%[3]d`
+)
+
+// GetLocationsWithin returns locations inside the box of the query ordered by time, radius
+// queries use PostGIS geography when the extension is installed, the others find candidates
+// by the geohash prefixes covering the box
+func (r *locationRepository) GetLocationsWithin(
+	ctx context.Context,
+	query model.SpatialQuery,
+) ([]model.Location, error) {
+	defer metrics.ObserveDBQuery("location", "GetLocationsWithin", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetLocationsWithin", spanAttributes)
+	defer span.End()
+
+	stmt := getLocationsWithin
+	args := []interface{}{query.From, query.To, query.MinLat, query.MaxLat, query.MinLon, query.MaxLon}
+
+	switch {
+	case query.Radius > 0 && r.hasPostGIS(ctx):
+		args = append(args, query.Lat, query.Lon, query.Radius)
+		stmt += fmt.Sprintf(withinRadiusPostGIS, len(args)-2, len(args)-1, len(args))
+	case query.Radius > 0:
+		stmt, args = withinGeohash(stmt, args, query)
+
+		args = append(args, query.Lat, query.Lon, query.Radius)
+		stmt += fmt.Sprintf(withinRadius, len(args)-2, len(args)-1, len(args))
+	default:
+		stmt, args = withinGeohash(stmt, args, query)
+	}
+
+	if query.Username != "" {
+		args = append(args, query.Username)
+		stmt += fmt.Sprintf(` AND username = $%d`, len(args))
+	}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += fmt.Sprintf(` AND device = $%d`, len(args))
+	}
+
+	args = append(args, query.Limit)
+	stmt += fmt.Sprintf(` ORDER BY created_at LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	locations := []model.Location{}
+
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, l)
+	}
+
+	return locations, rows.Err()
+}
+
+// maxGeohashCells prefixes of a spatial query, each is a range scan of locations_index_geohash
+const maxGeohashCells = 16
+
+// withoutGeohash matches locations the geohash isn't backfilled for yet,
+// the box & radius are still checked by their lat & lon
+const withoutGeohash = "geohash IS NULL OR geohash = ''"
+
+// withinGeohash adds the geohash prefixes covering the box of the query to stmt
+func withinGeohash(stmt string, args []interface{}, query model.SpatialQuery) (string, []interface{}) {
+	box := geo.Box{MinLat: query.MinLat, MinLon: query.MinLon, MaxLat: query.MaxLat, MaxLon: query.MaxLon}
+	cover := geo.GeohashCover(box, maxGeohashCells)
+	prefixes := make([]string, len(cover))
+
+	for i, prefix := range cover {
+		// every geohash starting with prefix sorts before prefix + "~"
+		args = append(args, prefix, prefix+"~")
+		prefixes[i] = fmt.Sprintf("geohash >= $%d AND geohash < $%d", len(args)-1, len(args))
+	}
+
+	return stmt + ` AND (` + strings.Join(append(prefixes, withoutGeohash), " OR ") + `)`, args
+}
+
+const hasPostGIS = `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'postgis')`
+
+// hasPostGIS checks once whether the PostGIS extension is installed
+func (r *locationRepository) hasPostGIS(ctx context.Context) bool {
+	r.postgisOnce.Do(func() {
+		if err := r.db.QueryRowContext(ctx, hasPostGIS).Scan(&r.postgis); err != nil {
+			logger.FromContext(ctx).Warnf("postgis check failed, using plain sql distances: %v", err)
+		}
+	})
+
+	return r.postgis
+}
+
+const getHeatmap = `SELECT substr(geohash, 1, $1) AS cell, COUNT(*), AVG(lat), AVG(lon) FROM locations
+WHERE username = $2 AND created_at BETWEEN $3 AND $4 AND geohash IS NOT NULL`
+
+// GetHeatmap counts the locations of the query per geohash cell of precision characters
+func (r *locationRepository) GetHeatmap(
+	ctx context.Context,
+	query model.LocationQuery,
+	precision int,
+) ([]model.HeatmapCell, error) {
+	defer metrics.ObserveDBQuery("location", "GetHeatmap", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetHeatmap", spanAttributes)
+	defer span.End()
+
+	stmt := getHeatmap
+	args := []interface{}{precision, query.Username, query.From, query.To}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += fmt.Sprintf(` AND device = $%d`, len(args))
+	}
+
+	stmt += ` GROUP BY cell ORDER BY cell`
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	cells := []model.HeatmapCell{}
+
+	for rows.Next() {
+		var c model.HeatmapCell
+		if err := rows.Scan(&c.Geohash, &c.Count, &c.Lat, &c.Lon); err != nil {
+			return nil, err
+		}
+
+		cells = append(cells, c)
+	}
+
+	return cells, rows.Err()
+}
+
+const (
+	getBatteryHistory = `SELECT device, created_at - created_at % $1 AS bucket, COUNT(*), MIN(batt), MAX(batt),
+AVG(batt)::float8, SUM(CASE WHEN bs = 1 THEN 1 ELSE 0 END), SUM(CASE WHEN bs IN (2, 3) THEN 1 ELSE 0 END)
+FROM locations WHERE username = $2 AND created_at BETWEEN $3 AND $4 AND batt > 0`
+	getNetworkHistory = `SELECT device, created_at - created_at % $1 AS bucket, COALESCE(ssid, ''),
+COALESCE(bssid, ''), COALESCE(ip, ''), COUNT(*), MIN(created_at) AS first_seen, MAX(created_at)
+FROM locations WHERE username = $2 AND created_at BETWEEN $3 AND $4`
+)
+
+// GetBatteryHistory aggregates the battery of the locations of the query per device & bucket seconds
+func (r *locationRepository) GetBatteryHistory(
+	ctx context.Context,
+	query model.LocationQuery,
+	bucket int64,
+) ([]model.BatteryBucket, error) {
+	defer metrics.ObserveDBQuery("location", "GetBatteryHistory", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetBatteryHistory", spanAttributes)
+	defer span.End()
+
+	stmt := getBatteryHistory
+	args := []interface{}{bucket, query.Username, query.From, query.To}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += fmt.Sprintf(` AND device = $%d`, len(args))
+	}
+	stmt += ` GROUP BY device, bucket ORDER BY device, bucket`
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	buckets := []model.BatteryBucket{}
+
+	for rows.Next() {
+		var b model.BatteryBucket
+		if err := rows.Scan(&b.Device, &b.From, &b.Points, &b.Min, &b.Max, &b.Avg, &b.Unplugged, &b.Charging); err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	return buckets, rows.Err()
+}
+
+// GetNetworkHistory groups the locations of the query per device, bucket seconds & network
+func (r *locationRepository) GetNetworkHistory(
+	ctx context.Context,
+	query model.LocationQuery,
+	bucket int64,
+) ([]model.NetworkBucket, error) {
+	defer metrics.ObserveDBQuery("location", "GetNetworkHistory", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetNetworkHistory", spanAttributes)
+	defer span.End()
+
+	stmt := getNetworkHistory
+	args := []interface{}{bucket, query.Username, query.From, query.To}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += fmt.Sprintf(` AND device = $%d`, len(args))
+	}
+	stmt += ` GROUP BY device, bucket, ssid, bssid, ip ORDER BY device, bucket, first_seen`
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	buckets := []model.NetworkBucket{}
+
+	for rows.Next() {
+		var b model.NetworkBucket
+		if err := rows.Scan(
+			&b.Device, &b.From, &b.WifiName, &b.WifiMAC, &b.IPAddress, &b.Points, &b.FirstSeen, &b.LastSeen,
+		); err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	return buckets, rows.Err()
+}
+
+const (
+	getLocationsWithoutGeohash = `SELECT id, lat, lon FROM locations WHERE id > $1 AND geohash IS NULL
+ORDER BY id LIMIT $2`
+	setGeohash = `UPDATE locations SET geohash = $1 WHERE id = $2`
+)
+
+// BackfillGeohash sets the geohash of up to limit locations without one after the id afterID,
+// it returns the last id handled and the number of updated locations, 0 when none is left
+func (r *locationRepository) BackfillGeohash(ctx context.Context, afterID int64, limit int) (int64, int64, error) {
+	defer metrics.ObserveDBQuery("location", "BackfillGeohash", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.BackfillGeohash", spanAttributes)
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, getLocationsWithoutGeohash, afterID, limit)
+	if err != nil {
+		return afterID, 0, err
+	}
+
+	var locations []model.Location
+
+	for rows.Next() {
+		var l model.Location
+		if err := rows.Scan(&l.ID, &l.Lat, &l.Lon); err != nil {
+			_ = rows.Close()
+			return afterID, 0, err
+		}
+
+		locations = append(locations, l)
+	}
+
+	if err := rows.Close(); err != nil {
+		return afterID, 0, err
+	}
+
+	if len(locations) == 0 {
+		return afterID, 0, rows.Err()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return afterID, 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, setGeohash)
+	if err != nil {
+		_ = tx.Rollback()
+		return afterID, 0, err
+	}
+
+	defer stmt.Close()
+
+	for _, l := range locations {
+		if _, err := stmt.ExecContext(ctx, geo.Geohash(l.Lat, l.Lon, geo.GeohashPrecision), l.ID); err != nil {
+			_ = tx.Rollback()
+			return afterID, 0, err
+		}
+	}
+
+	return locations[len(locations)-1].ID, int64(len(locations)), tx.Commit()
+}
 
+func scanLocation(row interface{ Scan(dest ...interface{}) error }) (model.Location, error) {
 	var l model.Location
 	err := row.Scan(
 		&l.ID,
@@ -76,6 +818,18 @@ func (r *locationRepository) GetUserLastLocation(ctx context.Context, username s
 		&l.Bssid,
 		&l.Ssid,
 		&l.IP,
+		&l.Cog,
+		&l.Rad,
+		&l.P,
+		&l.Poi,
+		&l.Tag,
+		&l.Conn,
+		&l.Topic,
+		&l.MsgID,
+		&l.MsgCreatedAt,
+		&l.InRegions,
+		&l.InRids,
+		&l.MotionActivities,
 	)
 
 	return l, err
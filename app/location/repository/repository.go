@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"database/sql"
+	"ot-recorder/app/location/repository/mysql"
+	"ot-recorder/app/location/repository/pgsql"
+	"ot-recorder/app/location/repository/sqlite"
+	"ot-recorder/app/model"
+)
+
+// NewLocationRepository returns the location repository for the given database type
+func NewLocationRepository(dbType string, dbClient *sql.DB) model.LocationRepository {
+	switch dbType {
+	case "postgres":
+		return pgsql.NewPgsqlLocationRepository(dbClient)
+	case "mysql":
+		return mysql.NewMysqlLocationRepository(dbClient)
+	default:
+		return sqlite.NewSqliteLocationRepository(dbClient)
+	}
+}
@@ -3,7 +3,23 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"ot-recorder/app/geo"
 	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/metrics"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//nolint:gochecknoglobals
+var (
+	tracer         = otel.Tracer("ot-recorder/app/location/repository/sqlite")
+	spanAttributes = trace.WithAttributes(semconv.DBSystemSqlite, semconv.DBSQLTableKey.String("locations"))
 )
 
 type locationRepository struct {
@@ -17,45 +33,641 @@ func NewSqliteLocationRepository(db *sql.DB) model.LocationRepository {
 }
 
 const createLocation = `INSERT INTO locations (
-  username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel, bssid, ssid, ip
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel, bssid, ssid, ip, geohash,
+  cog, rad, p, poi, tag, conn, topic, msg_id, msg_created_at, inregions, inrids, motion_activities, raw
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT DO NOTHING
 `
 
 func (r *locationRepository) CreateLocation(ctx context.Context, location *model.Location) error {
+	defer metrics.ObserveDBQuery("location", "CreateLocation", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.CreateLocation", spanAttributes)
+	defer span.End()
+
 	stmt, err := r.db.PrepareContext(ctx, createLocation)
 	if err != nil {
 		return err
 	}
 
 	defer stmt.Close()
-	_, err = stmt.ExecContext(ctx,
-		location.Username,
-		location.Device,
-		location.CreatedAt,
-		location.Acc,
-		location.Alt,
-		location.Batt,
-		location.Bs,
-		location.Lat,
-		location.Lon,
-		location.M,
-		location.T,
-		location.Tid,
-		location.Vac,
-		location.Vel,
-		location.Bssid,
-		location.Ssid,
-		location.IP,
-	)
+	res, err := stmt.ExecContext(ctx, locationArgs(location)...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return model.ErrDuplicateLocation
+	}
+
+	return nil
+}
+
+// CreateLocationBatch stores locations one by one in a single transaction, the returned
+// slice holds ErrDuplicateLocation for every skipped location and nil for stored ones
+func (r *locationRepository) CreateLocationBatch(ctx context.Context, locations []*model.Location) ([]error, error) {
+	defer metrics.ObserveDBQuery("location", "CreateLocationBatch", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.CreateLocationBatch", spanAttributes)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, createLocation)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	results := make([]error, len(locations))
+
+	for i, l := range locations {
+		res, err := stmt.ExecContext(ctx, locationArgs(l)...)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		if affected == 0 {
+			results[i] = model.ErrDuplicateLocation
+		}
+	}
+
+	return results, tx.Commit()
+}
+
+const (
+	createLocations = `INSERT INTO locations (
+  username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel, bssid, ssid, ip, geohash,
+  cog, rad, p, poi, tag, conn, topic, msg_id, msg_created_at, inregions, inrids, motion_activities, raw
+) VALUES %s
+ON CONFLICT DO NOTHING
+`
+	locationColumns  = 31
+	maxRowsPerInsert = 1000
+)
+
+// CreateLocations stores locations with multi row inserts in one transaction,
+// duplicates are skipped and the number of stored rows is returned
+func (r *locationRepository) CreateLocations(ctx context.Context, locations []*model.Location) (int64, error) {
+	defer metrics.ObserveDBQuery("location", "CreateLocations", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.CreateLocations", spanAttributes)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var inserted int64
+
+	for start := 0; start < len(locations); start += maxRowsPerInsert {
+		end := start + maxRowsPerInsert
+		if end > len(locations) {
+			end = len(locations)
+		}
+
+		args := make([]interface{}, 0, (end-start)*locationColumns)
+		for _, l := range locations[start:end] {
+			args = append(args, locationArgs(l)...)
+		}
+
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(createLocations, locationValues(end-start)), args...)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+
+		inserted += affected
+	}
+
+	return inserted, tx.Commit()
+}
+
+// locationValues builds placeholders of n rows, e.g. (?, ?), (?, ?)
+func locationValues(n int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", locationColumns), ", ") + ")"
+
+	return strings.TrimSuffix(strings.Repeat(row+", ", n), ", ")
+}
+
+func locationArgs(l *model.Location) []interface{} {
+	return []interface{}{
+		l.Username,
+		l.Device,
+		l.CreatedAt,
+		l.Acc,
+		l.Alt,
+		l.Batt,
+		l.Bs,
+		l.Lat,
+		l.Lon,
+		l.M,
+		l.T,
+		l.Tid,
+		l.Vac,
+		l.Vel,
+		l.Bssid,
+		l.Ssid,
+		l.IP,
+		geo.Geohash(l.Lat, l.Lon, geo.GeohashPrecision),
+		l.Cog,
+		l.Rad,
+		l.P,
+		l.Poi,
+		l.Tag,
+		l.Conn,
+		l.Topic,
+		l.MsgID,
+		l.MsgCreatedAt,
+		l.InRegions.String(),
+		l.InRids.String(),
+		l.MotionActivities.String(),
+		rawArg(l.Raw),
+	}
+}
+
+// rawArg stores the raw message as text, NULL when it was not kept
+func rawArg(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
 
-	return err
+	return string(raw)
 }
 
-const getPing = `SELECT * FROM locations WHERE username = ? ORDER BY created_at DESC LIMIT 1`
+const locationSelectColumns = `id, username, device, created_at, acc, alt, batt, bs, lat, lon, m, t, tid, vac, vel,
+  bssid, ssid, ip, cog, rad, p, poi, tag, conn, topic, msg_id, msg_created_at, inregions, inrids,
+  motion_activities`
+
+const getPing = `SELECT ` + locationSelectColumns + ` FROM locations
+WHERE username = ? ORDER BY created_at DESC LIMIT 1`
 
 func (r *locationRepository) GetUserLastLocation(ctx context.Context, username string) (model.Location, error) {
-	row := r.db.QueryRowContext(ctx, getPing, username)
+	defer metrics.ObserveDBQuery("location", "GetUserLastLocation", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetUserLastLocation", spanAttributes)
+	defer span.End()
+
+	return scanLocation(r.db.QueryRowContext(ctx, getPing, username))
+}
+
+const getLocations = `SELECT ` + locationSelectColumns + ` FROM locations
+WHERE username = ? AND created_at BETWEEN ? AND ?`
+
+// GetLocations returns locations of the query ordered by time
+func (r *locationRepository) GetLocations(ctx context.Context, query model.LocationQuery) ([]model.Location, error) {
+	defer metrics.ObserveDBQuery("location", "GetLocations", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetLocations", spanAttributes)
+	defer span.End()
+
+	stmt := getLocations
+	args := []interface{}{query.Username, query.From, query.To}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += ` AND device = ?`
+	}
+
+	args = append(args, query.Limit)
+	stmt += ` ORDER BY created_at LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var locations []model.Location
+
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, l)
+	}
+
+	return locations, rows.Err()
+}
+
+const getUsers = `SELECT DISTINCT username FROM locations ORDER BY username`
+
+// GetUsers returns every user with a location, ordered by name
+func (r *locationRepository) GetUsers(ctx context.Context) ([]string, error) {
+	defer metrics.ObserveDBQuery("location", "GetUsers", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetUsers", spanAttributes)
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, getUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := []string{}
+
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+
+		users = append(users, username)
+	}
+
+	return users, rows.Err()
+}
+
+const getUserDevices = `SELECT device, MAX(created_at) FROM locations WHERE username = ?
+GROUP BY device ORDER BY device`
+
+// GetUserDevices returns the devices of a user with the time of their latest location
+func (r *locationRepository) GetUserDevices(ctx context.Context, username string) ([]model.Device, error) {
+	defer metrics.ObserveDBQuery("location", "GetUserDevices", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetUserDevices", spanAttributes)
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, getUserDevices, username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	devices := []model.Device{}
+
+	for rows.Next() {
+		var d model.Device
+		if err := rows.Scan(&d.Name, &d.LastSeen); err != nil {
+			return nil, err
+		}
+
+		devices = append(devices, d)
+	}
+
+	return devices, rows.Err()
+}
+
+// getLastLocations joins the newest created_at of every user & device, found on the
+// locations_index_udc index, back to its row
+const getLastLocations = `SELECT ` + locationSelectColumns + ` FROM locations
+JOIN (
+  SELECT username AS latest_username, device AS latest_device, MAX(created_at) AS latest_created_at
+  FROM locations%s GROUP BY username, device
+) latest ON username = latest_username AND device = latest_device AND created_at = latest_created_at
+ORDER BY username, device, id DESC`
+
+// GetLastLocations returns the newest location of every device, of one user when username isn't empty
+func (r *locationRepository) GetLastLocations(ctx context.Context, username string) ([]model.Location, error) {
+	defer metrics.ObserveDBQuery("location", "GetLastLocations", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetLastLocations", spanAttributes)
+	defer span.End()
+
+	where := ""
+	args := []interface{}{}
+
+	if username != "" {
+		args = append(args, username)
+		where = ` WHERE username = ?`
+	}
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(getLastLocations, where), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	locations := []model.Location{}
+
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		// locations of a device with the same time, keep the last stored
+		if n := len(locations); n > 0 && locations[n-1].Username == l.Username && locations[n-1].Device == l.Device {
+			continue
+		}
+
+		locations = append(locations, l)
+	}
+
+	return locations, rows.Err()
+}
+
+// maxGeohashCells prefixes of a spatial query, each is a range scan of locations_index_geohash
+const maxGeohashCells = 16
+
+// withoutGeohash matches locations the geohash isn't backfilled for yet,
+// the box & radius are still checked by their lat & lon
+const withoutGeohash = "geohash IS NULL OR geohash = ''"
+
+const getLocationsWithin = `SELECT ` + locationSelectColumns + ` FROM locations
+WHERE created_at BETWEEN ? AND ? AND (%s)`
+
+// GetLocationsWithin finds candidates by the geohash prefixes covering the box of the query,
+// lat & lon are stored as TEXT, and keeps the ones inside the box & radius
+func (r *locationRepository) GetLocationsWithin(
+	ctx context.Context,
+	query model.SpatialQuery,
+) ([]model.Location, error) {
+	defer metrics.ObserveDBQuery("location", "GetLocationsWithin", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetLocationsWithin", spanAttributes)
+	defer span.End()
+
+	box := geo.Box{MinLat: query.MinLat, MinLon: query.MinLon, MaxLat: query.MaxLat, MaxLon: query.MaxLon}
+	cover := geo.GeohashCover(box, maxGeohashCells)
+
+	args := []interface{}{query.From, query.To}
+	prefixes := make([]string, len(cover))
+
+	for i, prefix := range cover {
+		// every geohash starting with prefix sorts before prefix + "~"
+		args = append(args, prefix, prefix+"~")
+		prefixes[i] = "geohash >= ? AND geohash < ?"
+	}
+
+	stmt := fmt.Sprintf(getLocationsWithin, strings.Join(append(prefixes, withoutGeohash), " OR "))
+
+	if query.Username != "" {
+		args = append(args, query.Username)
+		stmt += ` AND username = ?`
+	}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += ` AND device = ?`
+	}
+
+	stmt += ` ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	locations := []model.Location{}
+
+	for len(locations) < query.Limit && rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		if !box.Contains(l.Lat, l.Lon) {
+			continue
+		}
+
+		if query.Radius > 0 && geo.Distance(query.Lat, query.Lon, l.Lat, l.Lon) > query.Radius {
+			continue
+		}
+
+		locations = append(locations, l)
+	}
+
+	return locations, rows.Err()
+}
+
+const getHeatmap = `SELECT substr(geohash, 1, ?) AS cell, COUNT(*), AVG(lat), AVG(lon) FROM locations
+WHERE username = ? AND created_at BETWEEN ? AND ? AND geohash IS NOT NULL`
+
+// GetHeatmap counts the locations of the query per geohash cell of precision characters
+func (r *locationRepository) GetHeatmap(
+	ctx context.Context,
+	query model.LocationQuery,
+	precision int,
+) ([]model.HeatmapCell, error) {
+	defer metrics.ObserveDBQuery("location", "GetHeatmap", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetHeatmap", spanAttributes)
+	defer span.End()
+
+	stmt := getHeatmap
+	args := []interface{}{precision, query.Username, query.From, query.To}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += ` AND device = ?`
+	}
+
+	stmt += ` GROUP BY cell ORDER BY cell`
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	cells := []model.HeatmapCell{}
+
+	for rows.Next() {
+		var c model.HeatmapCell
+		if err := rows.Scan(&c.Geohash, &c.Count, &c.Lat, &c.Lon); err != nil {
+			return nil, err
+		}
+
+		cells = append(cells, c)
+	}
+
+	return cells, rows.Err()
+}
+
+const (
+	getBatteryHistory = `SELECT device, created_at - created_at % ? AS bucket, COUNT(*), MIN(batt), MAX(batt),
+AVG(batt), SUM(CASE WHEN bs = 1 THEN 1 ELSE 0 END), SUM(CASE WHEN bs IN (2, 3) THEN 1 ELSE 0 END)
+FROM locations WHERE username = ? AND created_at BETWEEN ? AND ? AND batt > 0`
+	getNetworkHistory = `SELECT device, created_at - created_at % ? AS bucket, COALESCE(ssid, ''),
+COALESCE(bssid, ''), COALESCE(ip, ''), COUNT(*), MIN(created_at) AS first_seen, MAX(created_at)
+FROM locations WHERE username = ? AND created_at BETWEEN ? AND ?`
+)
+
+// GetBatteryHistory aggregates the battery of the locations of the query per device & bucket seconds
+func (r *locationRepository) GetBatteryHistory(
+	ctx context.Context,
+	query model.LocationQuery,
+	bucket int64,
+) ([]model.BatteryBucket, error) {
+	defer metrics.ObserveDBQuery("location", "GetBatteryHistory", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetBatteryHistory", spanAttributes)
+	defer span.End()
+
+	stmt := getBatteryHistory
+	args := []interface{}{bucket, query.Username, query.From, query.To}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += ` AND device = ?`
+	}
+	stmt += ` GROUP BY device, bucket ORDER BY device, bucket`
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	buckets := []model.BatteryBucket{}
+
+	for rows.Next() {
+		var b model.BatteryBucket
+		if err := rows.Scan(&b.Device, &b.From, &b.Points, &b.Min, &b.Max, &b.Avg, &b.Unplugged, &b.Charging); err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	return buckets, rows.Err()
+}
+
+// GetNetworkHistory groups the locations of the query per device, bucket seconds & network
+func (r *locationRepository) GetNetworkHistory(
+	ctx context.Context,
+	query model.LocationQuery,
+	bucket int64,
+) ([]model.NetworkBucket, error) {
+	defer metrics.ObserveDBQuery("location", "GetNetworkHistory", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.GetNetworkHistory", spanAttributes)
+	defer span.End()
+
+	stmt := getNetworkHistory
+	args := []interface{}{bucket, query.Username, query.From, query.To}
+
+	if query.Device != "" {
+		args = append(args, query.Device)
+		stmt += ` AND device = ?`
+	}
+	stmt += ` GROUP BY device, bucket, ssid, bssid, ip ORDER BY device, bucket, first_seen`
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	buckets := []model.NetworkBucket{}
+
+	for rows.Next() {
+		var b model.NetworkBucket
+		if err := rows.Scan(
+			&b.Device, &b.From, &b.WifiName, &b.WifiMAC, &b.IPAddress, &b.Points, &b.FirstSeen, &b.LastSeen,
+		); err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	return buckets, rows.Err()
+}
+
+const (
+	getLocationsWithoutGeohash = `SELECT id, lat, lon FROM locations WHERE id > ? AND geohash IS NULL
+ORDER BY id LIMIT ?`
+	setGeohash = `UPDATE locations SET geohash = ? WHERE id = ?`
+)
+
+// BackfillGeohash sets the geohash of up to limit locations without one after the id afterID,
+// it returns the last id handled and the number of updated locations, 0 when none is left
+func (r *locationRepository) BackfillGeohash(ctx context.Context, afterID int64, limit int) (int64, int64, error) {
+	defer metrics.ObserveDBQuery("location", "BackfillGeohash", time.Now())
+
+	ctx, span := tracer.Start(ctx, "locationRepository.BackfillGeohash", spanAttributes)
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, getLocationsWithoutGeohash, afterID, limit)
+	if err != nil {
+		return afterID, 0, err
+	}
+
+	var locations []model.Location
+
+	for rows.Next() {
+		var l model.Location
+		if err := rows.Scan(&l.ID, &l.Lat, &l.Lon); err != nil {
+			_ = rows.Close()
+			return afterID, 0, err
+		}
+
+		locations = append(locations, l)
+	}
+
+	if err := rows.Close(); err != nil {
+		return afterID, 0, err
+	}
+
+	if len(locations) == 0 {
+		return afterID, 0, rows.Err()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return afterID, 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, setGeohash)
+	if err != nil {
+		_ = tx.Rollback()
+		return afterID, 0, err
+	}
+
+	defer stmt.Close()
+
+	for _, l := range locations {
+		if _, err := stmt.ExecContext(ctx, geo.Geohash(l.Lat, l.Lon, geo.GeohashPrecision), l.ID); err != nil {
+			_ = tx.Rollback()
+			return afterID, 0, err
+		}
+	}
+
+	return locations[len(locations)-1].ID, int64(len(locations)), tx.Commit()
+}
 
+func scanLocation(row interface{ Scan(dest ...interface{}) error }) (model.Location, error) {
 	var l model.Location
 	err := row.Scan(
 		&l.ID,
@@ -76,6 +688,18 @@ func (r *locationRepository) GetUserLastLocation(ctx context.Context, username s
 		&l.Bssid,
 		&l.Ssid,
 		&l.IP,
+		&l.Cog,
+		&l.Rad,
+		&l.P,
+		&l.Poi,
+		&l.Tag,
+		&l.Conn,
+		&l.Topic,
+		&l.MsgID,
+		&l.MsgCreatedAt,
+		&l.InRegions,
+		&l.InRids,
+		&l.MotionActivities,
 	)
 
 	return l, err
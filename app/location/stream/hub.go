@@ -0,0 +1,87 @@
+package stream
+
+import (
+	"context"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/metrics"
+	"sync"
+)
+
+// Hub fans out accepted locations to live subscribers in process. A subscriber
+// which doesn't keep up misses locations instead of slowing down pings.
+type Hub struct {
+	mu     sync.RWMutex
+	subs   map[*subscriber]struct{}
+	buffer int
+}
+
+type subscriber struct {
+	ch    chan *model.LocationDetails
+	allow func(username string) bool
+}
+
+// NewHub buffer is the number of locations queued per subscriber
+func NewHub(buffer int) *Hub {
+	return &Hub{
+		subs:   map[*subscriber]struct{}{},
+		buffer: buffer,
+	}
+}
+
+// Publish sends the location to every subscriber allowed to see its user
+func (h *Hub) Publish(l *model.LocationDetails) {
+	h.PublishFunc(l.Username, func() *model.LocationDetails { return l })
+}
+
+// PublishFunc sends the location of the user to every subscriber allowed to see it,
+// details is called once when there is one
+func (h *Hub) PublishFunc(username string, details func() *model.LocationDetails) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var l *model.LocationDetails
+
+	for sub := range h.subs {
+		if !sub.allow(username) {
+			continue
+		}
+
+		if l == nil {
+			l = details()
+		}
+
+		select {
+		case sub.ch <- l:
+		default:
+			metrics.StreamDropped.Inc()
+		}
+	}
+}
+
+// Subscribe returns locations of users allowed by allow, the channel is
+// closed once ctx is done
+func (h *Hub) Subscribe(ctx context.Context, allow func(username string) bool) <-chan *model.LocationDetails {
+	sub := &subscriber{
+		ch:    make(chan *model.LocationDetails, h.buffer),
+		allow: allow,
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	metrics.StreamSubscribers.Inc()
+
+	go func() {
+		<-ctx.Done()
+
+		h.mu.Lock()
+		delete(h.subs, sub)
+		close(sub.ch)
+		h.mu.Unlock()
+
+		metrics.StreamSubscribers.Dec()
+	}()
+
+	return sub.ch
+}
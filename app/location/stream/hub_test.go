@@ -0,0 +1,64 @@
+package stream_test
+
+import (
+	"context"
+	"ot-recorder/app/location/stream"
+	"ot-recorder/app/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub(t *testing.T) {
+	hub := stream.NewHub(1)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	all := hub.Subscribe(ctx, func(string) bool { return true })
+	dev := hub.Subscribe(ctx, func(username string) bool { return username == "dev" })
+
+	hub.Publish(&model.LocationDetails{Username: "ops"})
+	assert.Equal(t, "ops", (<-all).Username)
+	assert.Len(t, dev, 0)
+
+	// the second location is dropped for the subscriber which didn't read the first one
+	hub.Publish(&model.LocationDetails{Username: "dev", Device: "first"})
+	hub.Publish(&model.LocationDetails{Username: "dev", Device: "second"})
+	assert.Equal(t, "first", (<-dev).Device)
+	assert.Len(t, dev, 0)
+
+	cancel()
+
+	for range all {
+	}
+
+	_, open := <-dev
+	assert.False(t, open)
+}
+
+func TestHubPublishFunc(t *testing.T) {
+	hub := stream.NewHub(1)
+	built := 0
+	details := func() *model.LocationDetails {
+		built++
+		return &model.LocationDetails{Username: "dev"}
+	}
+
+	// nothing is built without a subscriber allowed to see the user
+	hub.PublishFunc("dev", details)
+	assert.Equal(t, 0, built)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	ops := hub.Subscribe(ctx, func(username string) bool { return username == "ops" })
+	hub.PublishFunc("dev", details)
+	assert.Equal(t, 0, built)
+	assert.Len(t, ops, 0)
+
+	first := hub.Subscribe(ctx, func(string) bool { return true })
+	second := hub.Subscribe(ctx, func(string) bool { return true })
+	hub.PublishFunc("dev", details)
+	assert.Equal(t, 1, built)
+	assert.Same(t, <-first, <-second)
+}
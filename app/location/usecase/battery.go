@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+)
+
+// BatteryHistory returns the battery levels & status of the query per device in buckets of bucket seconds,
+// buckets without a location are left out so gaps in reporting show up
+func (u *locationUsecase) BatteryHistory(
+	c context.Context,
+	query model.LocationQuery,
+	bucket int64,
+) (buckets []model.BatteryBucket, err error) {
+	c, span := tracer.Start(c, "locationUsecase.BatteryHistory")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	buckets, err = u.repo.GetBatteryHistory(ctx, query, bucket)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	for i := range buckets {
+		buckets[i].Avg = math.Round(buckets[i].Avg*10) / 10
+	}
+
+	return buckets, nil
+}
+
+// NetworkHistory returns the wifi & ip of the query per device in buckets of bucket seconds, a bucket
+// with network changes has an entry per network ordered by the time it was first seen
+func (u *locationUsecase) NetworkHistory(
+	c context.Context,
+	query model.LocationQuery,
+	bucket int64,
+) (buckets []model.NetworkBucket, err error) {
+	c, span := tracer.Start(c, "locationUsecase.NetworkHistory")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	buckets, err = u.repo.GetNetworkHistory(ctx, query, bucket)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,58 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"ot-recorder/app/location/stream"
+	"ot-recorder/app/location/usecase"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatteryHistory(t *testing.T) {
+	query := model.LocationQuery{Username: "dev", From: 0, To: 3600, Limit: 10}
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetBatteryHistory", mock.Anything, query, int64(900)).Return([]model.BatteryBucket{
+		{Device: "phone", From: 0, Points: 3, Min: 38, Max: 40, Avg: 38.666666, Unplugged: 3},
+	}, nil).Once()
+	mockLocationRepo.On("GetBatteryHistory", mock.Anything, query, int64(60)).Return(nil, errors.New("timeout")).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	buckets, err := u.BatteryHistory(context.TODO(), query, 900)
+	assert.NoError(t, err)
+	assert.Len(t, buckets, 1)
+	assert.Equal(t, 38.7, buckets[0].Avg)
+
+	_, err = u.BatteryHistory(context.TODO(), query, 60)
+	assert.Error(t, err)
+	mockLocationRepo.AssertExpectations(t)
+}
+
+func TestNetworkHistory(t *testing.T) {
+	query := model.LocationQuery{Username: "dev", From: 0, To: 3600, Limit: 10}
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetNetworkHistory", mock.Anything, query, int64(900)).Return([]model.NetworkBucket{
+		{Device: "phone", WifiName: "home", IPAddress: "10.0.0.2", Points: 4, FirstSeen: 60, LastSeen: 600},
+		{Device: "phone", IPAddress: "100.64.0.9", Points: 2, FirstSeen: 700, LastSeen: 800},
+	}, nil).Once()
+	mockLocationRepo.On("GetNetworkHistory", mock.Anything, query, int64(60)).Return(nil, errors.New("timeout")).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	buckets, err := u.NetworkHistory(context.TODO(), query, 900)
+	assert.NoError(t, err)
+	assert.Len(t, buckets, 2)
+	assert.Equal(t, "home", buckets[0].WifiName)
+
+	_, err = u.NetworkHistory(context.TODO(), query, 60)
+	assert.Error(t, err)
+	mockLocationRepo.AssertExpectations(t)
+}
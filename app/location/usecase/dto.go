@@ -2,61 +2,100 @@ package usecase
 
 import (
 	"fmt"
+	"ot-recorder/app/locale"
 	"ot-recorder/app/model"
-	"ot-recorder/infrastructure/config"
-	"time"
+	"sort"
 )
 
-const dateTimeFormat = "2006-01-02 15:04:05"
-
-func parseEpochTimeToLocal(epoch int64, tz string) time.Time {
-	et := time.Unix(epoch, 0)
-	loc, _ := time.LoadLocation(tz)
-	return et.In(loc)
-}
+// toLastLocationDetails place is the label of the known place of the location, if any,
+// p formats it per the preferences of its user
+func toLastLocationDetails(l *model.Location, place string, p locale.Printer) *model.LocationDetails {
+	speed := ""
+	if l.Vel > 0 {
+		speed = p.Speed(l.Vel)
+	}
 
-func toLastLocationDetails(l *model.Location) *model.LocationDetails {
 	return &model.LocationDetails{
 		Username:         l.Username,
 		Device:           l.Device,
-		DateTime:         parseEpochTimeToLocal(l.CreatedAt, config.Get().App.TimeZone).Format(dateTimeFormat),
+		DateTime:         p.DateTime(l.CreatedAt),
+		DateTimeISO:      p.ISO(l.CreatedAt),
+		Timestamp:        l.CreatedAt,
 		Accuracy:         l.Acc,
 		Altitude:         l.Alt,
-		BatteryLevel:     fmt.Sprintf("%d%s", l.Batt, "%"),
+		BatteryLevel:     p.Percent(l.Batt),
 		BatteryStatus:    model.BatteryStatusEnum(l.Bs).String(),
 		Latitude:         l.Lat,
 		Longitude:        l.Lon,
+		Place:            place,
 		Mode:             model.ModeEnum(l.M).String(),
 		VerticalAccuracy: l.Vac,
 		Velocity:         l.Vel,
+		Speed:            speed,
 		WifiName:         l.Ssid,
 		WifiMAC:          l.Bssid,
 		IPAddress:        l.IP,
 		MapLink:          fmt.Sprintf(mapLink, l.Lat, l.Lon, l.Lat, l.Lon),
+		Course:           l.Cog,
+		Radius:           l.Rad,
+		Pressure:         l.P,
+		POI:              l.Poi,
+		Tag:              l.Tag,
+		Connection:       model.ConnectionEnum(l.Conn).String(),
+		InRegions:        l.InRegions,
+		InRegionIDs:      l.InRids,
+		MotionActivities: l.MotionActivities,
+		Topic:            l.Topic,
+		MessageID:        l.MsgID,
+		MessageCreatedAt: l.MsgCreatedAt,
+	}
+}
+
+func toTrackPoint(l *model.Location) model.TrackPoint {
+	return model.TrackPoint{
+		Device:    l.Device,
+		CreatedAt: l.CreatedAt,
+		Lat:       l.Lat,
+		Lon:       l.Lon,
+		Acc:       l.Acc,
+		Vel:       l.Vel,
+		Batt:      l.Batt,
 	}
 }
 
-func toTelegramMessage(l *model.Location) string {
-	message := fmt.Sprintf(`Username: *%s*
-Device: *%s*
-DateTime: *%s*
-Latitude: *%f*
-Longitude: *%f*
-Accuracy: *%d*
-Altitude: *%d*
-BatteryLevel: *%s*
-Mode: *%s*
-[View in map](%s)`,
-		l.Username,
-		l.Device,
-		parseEpochTimeToLocal(l.CreatedAt, config.Get().App.TimeZone).Format(dateTimeFormat),
-		l.Lat,
-		l.Lon,
-		l.Acc,
-		l.Alt,
-		fmt.Sprintf("%d%s", l.Batt, "%"),
-		model.ModeEnum(l.M).String(),
-		fmt.Sprintf(mapLink, l.Lat, l.Lon, l.Lat, l.Lon),
+// sortByFrom orders items of different devices by their start time
+func sortByFrom[T any](items []T, from func(T) int64) {
+	sort.SliceStable(items, func(i, j int) bool { return from(items[i]) < from(items[j]) })
+}
+
+// toTelegramMessage tells the known place instead of the coordinates when place isn't empty,
+// labels & values are formatted by p
+func toTelegramMessage(l *model.Location, place string, p locale.Printer) string {
+	position := fmt.Sprintf("%s: *%f*\n%s: *%f*", p.Label("latitude"), l.Lat, p.Label("longitude"), l.Lon)
+	if place != "" {
+		position = fmt.Sprintf("%s: *%s %s*", p.Label("place"), p.Label("at"), place)
+	}
+
+	message := fmt.Sprintf(`%s: *%s*
+%s: *%s*
+%s: *%s*
+%s
+%s: *%s*
+%s: *%s*
+%s: *%s*
+%s: *%s*
+%s: *%s*
+[%s](%s)`,
+		p.Label("username"), l.Username,
+		p.Label("device"), l.Device,
+		p.Label("datetime"), p.DateTime(l.CreatedAt),
+		position,
+		p.Label("accuracy"), p.Length(l.Acc),
+		p.Label("altitude"), p.Length(l.Alt),
+		p.Label("speed"), p.Speed(l.Vel),
+		p.Label("battery"), p.Percent(l.Batt),
+		p.Label("mode"), model.ModeEnum(l.M).String(),
+		p.Label("map"), fmt.Sprintf(mapLink, l.Lat, l.Lon, l.Lat, l.Lon),
 	)
 
 	return message
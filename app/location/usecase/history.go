@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"ot-recorder/app/geo"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+)
+
+const (
+	stayRadius      = 100 // meters
+	minStayDuration = 300 // seconds
+	minTripDistance = 200 // meters
+)
+
+// History returns the track of the query, ordered by time
+func (u *locationUsecase) History(c context.Context, query model.LocationQuery) (points []model.TrackPoint, err error) {
+	c, span := tracer.Start(c, "locationUsecase.History")
+	defer func() { tracing.End(span, err) }()
+
+	locations, err := u.getLocations(c, query)
+	if err != nil {
+		return nil, err
+	}
+
+	points = make([]model.TrackPoint, len(locations))
+	for i := range locations {
+		points[i] = toTrackPoint(&locations[i])
+	}
+
+	return points, nil
+}
+
+// Stays returns where every device of the query stayed for a while
+func (u *locationUsecase) Stays(c context.Context, query model.LocationQuery) (stays []model.Stay, err error) {
+	c, span := tracer.Start(c, "locationUsecase.Stays")
+	defer func() { tracing.End(span, err) }()
+
+	locations, err := u.getLocations(c, query)
+	if err != nil {
+		return nil, err
+	}
+
+	stays = []model.Stay{}
+
+	for device, points := range groupByDevice(locations) {
+		for _, s := range geo.Stays(points, stayRadius, minStayDuration) {
+			lat, lon := geo.Centroid(points[s.Start : s.End+1])
+			stays = append(stays, model.Stay{
+				Device:   device,
+				Lat:      lat,
+				Lon:      lon,
+				From:     points[s.Start].Tst,
+				To:       points[s.End].Tst,
+				Duration: points[s.End].Tst - points[s.Start].Tst,
+				Points:   s.End - s.Start + 1,
+			})
+		}
+	}
+
+	sortByFrom(stays, func(s model.Stay) int64 { return s.From })
+
+	return stays, nil
+}
+
+// Trips returns the movements of every device of the query between its stays
+func (u *locationUsecase) Trips(c context.Context, query model.LocationQuery) (trips []model.Trip, err error) {
+	c, span := tracer.Start(c, "locationUsecase.Trips")
+	defer func() { tracing.End(span, err) }()
+
+	locations, err := u.getLocations(c, query)
+	if err != nil {
+		return nil, err
+	}
+
+	trips = []model.Trip{}
+
+	for device, points := range groupByDevice(locations) {
+		stays := geo.Stays(points, stayRadius, minStayDuration)
+
+		for _, t := range geo.Trips(points, stays, minTripDistance) {
+			start, end := points[t.Start], points[t.End]
+			trips = append(trips, model.Trip{
+				Device:   device,
+				From:     start.Tst,
+				To:       end.Tst,
+				StartLat: start.Lat,
+				StartLon: start.Lon,
+				EndLat:   end.Lat,
+				EndLon:   end.Lon,
+				Distance: geo.PathLength(points[t.Start : t.End+1]),
+				Duration: end.Tst - start.Tst,
+				Points:   t.End - t.Start + 1,
+			})
+		}
+	}
+
+	sortByFrom(trips, func(t model.Trip) int64 { return t.From })
+
+	return trips, nil
+}
+
+func (u *locationUsecase) getLocations(c context.Context, query model.LocationQuery) ([]model.Location, error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	locations, err := u.repo.GetLocations(ctx, query)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	return locations, nil
+}
+
+// groupByDevice splits time ordered locations into the track of every device
+func groupByDevice(locations []model.Location) map[string][]geo.Point {
+	tracks := map[string][]geo.Point{}
+
+	for i := range locations {
+		l := &locations[i]
+		tracks[l.Device] = append(tracks[l.Device], geo.Point{Lat: l.Lat, Lon: l.Lon, Tst: l.CreatedAt})
+	}
+
+	return tracks
+}
@@ -0,0 +1,94 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"ot-recorder/app/location/stream"
+	"ot-recorder/app/location/usecase"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// home for 20 minutes, 4.5km drive, office for 30 minutes, tablet stays home
+var dayLocations = []model.Location{
+	{Device: "phone", CreatedAt: 0, Lat: 23.0000, Lon: 90.0000},
+	{Device: "tablet", CreatedAt: 0, Lat: 23.0000, Lon: 90.0000},
+	{Device: "phone", CreatedAt: 600, Lat: 23.0001, Lon: 90.0001},
+	{Device: "phone", CreatedAt: 1200, Lat: 23.0000, Lon: 90.0001},
+	{Device: "phone", CreatedAt: 1500, Lat: 23.0100, Lon: 90.0100},
+	{Device: "phone", CreatedAt: 1800, Lat: 23.0200, Lon: 90.0200},
+	{Device: "phone", CreatedAt: 2100, Lat: 23.0300, Lon: 90.0300},
+	{Device: "tablet", CreatedAt: 2400, Lat: 23.0001, Lon: 90.0000},
+	{Device: "phone", CreatedAt: 3000, Lat: 23.0301, Lon: 90.0300},
+	{Device: "phone", CreatedAt: 3900, Lat: 23.0300, Lon: 90.0301},
+}
+
+func TestHistory(t *testing.T) {
+	query := model.LocationQuery{Username: "dev", From: 0, To: 3600, Limit: 10}
+
+	t.Run("success", func(t *testing.T) {
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("GetLocations", mock.Anything, query).Return(dayLocations, nil).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+		points, err := u.History(context.TODO(), query)
+		assert.NoError(t, err)
+		assert.Len(t, points, len(dayLocations))
+		assert.Equal(t, "tablet", points[1].Device)
+		mockLocationRepo.AssertExpectations(t)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("GetLocations", mock.Anything, query).Return(nil, errors.New("db down")).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+		_, err := u.History(context.TODO(), query)
+		code, _ := response.RespondError(err)
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+}
+
+func TestStays(t *testing.T) {
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetLocations", mock.Anything, mock.Anything).Return(dayLocations, nil).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	stays, err := u.Stays(context.TODO(), model.LocationQuery{Username: "dev"})
+	assert.NoError(t, err)
+	assert.Len(t, stays, 3)
+
+	// phone & tablet at home first, ordered by start
+	assert.Equal(t, int64(0), stays[0].From)
+	assert.Equal(t, int64(0), stays[1].From)
+	assert.Equal(t, "phone", stays[2].Device)
+	assert.Equal(t, int64(2100), stays[2].From)
+	assert.Equal(t, int64(1800), stays[2].Duration)
+	assert.Equal(t, 3, stays[2].Points)
+}
+
+func TestTrips(t *testing.T) {
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetLocations", mock.Anything, mock.Anything).Return(dayLocations, nil).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	trips, err := u.Trips(context.TODO(), model.LocationQuery{Username: "dev"})
+	assert.NoError(t, err)
+	assert.Len(t, trips, 1)
+	assert.Equal(t, "phone", trips[0].Device)
+	assert.Equal(t, int64(1200), trips[0].From)
+	assert.Equal(t, int64(2100), trips[0].To)
+	assert.InDelta(t, 4500, trips[0].Distance, 200)
+	assert.Equal(t, 4, trips[0].Points)
+}
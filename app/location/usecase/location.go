@@ -4,13 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"math"
 	"net/http"
+	"ot-recorder/app/locale"
+	"ot-recorder/app/location/ingest"
+	"ot-recorder/app/location/stream"
 	"ot-recorder/app/model"
 	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/metrics"
+	"ot-recorder/infrastructure/tracing"
 	"strings"
 	"time"
 
-	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
 )
 
 const mapLink = "https://www.openstreetmap.org/?mlat=%f&mlon=%f#map=18/%f/%f"
@@ -21,6 +29,8 @@ const helpDescription = `*/loc<space><username>* - get user last location
 */location<space><username>* - get user last location
 */help* - for a list of commands`
 
+var tracer = otel.Tracer("ot-recorder/app/location/usecase") //nolint:gochecknoglobals
+
 var commands = map[string]string{
 	"/location": locationCMD,
 	"/loc":      locationCMD,
@@ -29,24 +39,59 @@ var commands = map[string]string{
 
 type locationUsecase struct {
 	repo           model.LocationRepository
+	places         model.PlaceLocator
+	prefs          model.PreferenceProvider
+	hub            *stream.Hub
 	contextTimeout time.Duration
 }
 
-func NewLocationUsecase(repo model.LocationRepository, timeout time.Duration) model.LocationUsecase {
+// NewLocationUsecase places tells the known places of locations, nil leaves them out. prefs
+// tells how the locations of a user are formatted, nil formats all with the defaults
+func NewLocationUsecase(
+	repo model.LocationRepository,
+	places model.PlaceLocator,
+	prefs model.PreferenceProvider,
+	hub *stream.Hub,
+	timeout time.Duration,
+) model.LocationUsecase {
 	return &locationUsecase{
 		repo:           repo,
+		places:         places,
+		prefs:          prefs,
+		hub:            hub,
 		contextTimeout: timeout,
 	}
 }
 
 func (u *locationUsecase) Ping(c context.Context, l *model.Location) (err error) {
+	c, span := tracer.Start(c, "locationUsecase.Ping")
+	defer func() { tracing.End(span, err) }()
+
 	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
 	defer cancel()
 
 	// store location
 	err = u.repo.CreateLocation(ctx, l)
+	if err == nil {
+		metrics.SetLastFix(l.Username, l.Device, l.CreatedAt)
+		u.publish(ctx, l)
+	}
+
+	if errors.Is(err, model.ErrDuplicateLocation) {
+		metrics.DuplicatePings.Inc()
+		logger.FromContext(ctx).Debugf("duplicate location of %s/%s at %d skipped", l.Username, l.Device, l.CreatedAt)
+
+		return nil
+	}
+
+	if errors.Is(err, ingest.ErrQueueFull) || errors.Is(err, ingest.ErrQueueClosed) {
+		logger.FromContext(ctx).Warnln(err)
+
+		return response.WrapError(errors.New("server is busy, try again later"), http.StatusServiceUnavailable)
+	}
+
 	if err != nil {
-		logrus.Errorln(err)
+		logger.FromContext(ctx).Errorln(err)
 
 		return response.WrapError(errors.New("internal server error, please report to admin"), http.StatusInternalServerError)
 	}
@@ -54,10 +99,43 @@ func (u *locationUsecase) Ping(c context.Context, l *model.Location) (err error)
 	return nil
 }
 
+func (u *locationUsecase) PingBatch(c context.Context, locations []*model.Location) (results []error, err error) {
+	c, span := tracer.Start(c, "locationUsecase.PingBatch")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	results, err = u.repo.CreateLocationBatch(ctx, locations)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	for i, r := range results {
+		if errors.Is(r, model.ErrDuplicateLocation) {
+			metrics.DuplicatePings.Inc()
+			continue
+		}
+
+		metrics.SetLastFix(locations[i].Username, locations[i].Device, locations[i].CreatedAt)
+		u.publish(ctx, locations[i])
+	}
+
+	return results, nil
+}
+
 func (u *locationUsecase) LastLocation(
 	c context.Context,
 	username string,
 ) (location *model.LocationDetails, err error) {
+	c, span := tracer.Start(c, "locationUsecase.LastLocation")
+	defer func() { tracing.End(span, err) }()
+
 	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
 	defer cancel()
 
@@ -67,7 +145,7 @@ func (u *locationUsecase) LastLocation(
 			return nil, response.ErrNotFound
 		}
 
-		logrus.Errorln(err)
+		logger.FromContext(ctx).Errorln(err)
 
 		return nil, response.WrapError(
 			errors.New("internal server error, please report to admin"),
@@ -75,15 +153,23 @@ func (u *locationUsecase) LastLocation(
 		)
 	}
 
-	return toLastLocationDetails(&l), nil
+	return u.details(ctx, &l), nil
 }
 
 func (u *locationUsecase) TelegramHook(c context.Context, req *model.TelegramRequest) (res *model.TelegramResponse) {
+	c, span := tracer.Start(c, "locationUsecase.TelegramHook")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
 	defer cancel()
 
 	text := ""
 	command, username := extractTelegramCommand(req.Message.Text)
+	if command == "" {
+		metrics.TelegramCommands.WithLabelValues(helpCMD).Inc()
+	} else {
+		metrics.TelegramCommands.WithLabelValues(command).Inc()
+	}
 
 	switch command {
 	case locationCMD:
@@ -131,6 +217,45 @@ func extractTelegramCommand(text string) (command, username string) {
 	return command, username
 }
 
+// Subscribe streams accepted locations of the users the viewer may see until c is done
+func (u *locationUsecase) Subscribe(c context.Context, viewer string) (<-chan *model.LocationDetails, error) {
+	allow, err := streamAccess(config.Get().Stream.Access, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.hub.Subscribe(c, allow), nil
+}
+
+// streamAccess returns which users the viewer may see, everyone when no rules are configured
+func streamAccess(rules []config.StreamAccess, viewer string) (func(username string) bool, error) {
+	if len(rules) == 0 {
+		return func(string) bool { return true }, nil
+	}
+
+	if viewer == "" {
+		return nil, response.WrapError(errors.New("viewer is missing, set x-limit-u header"), http.StatusUnauthorized)
+	}
+
+	users := map[string]bool{viewer: true}
+
+	for _, rule := range rules {
+		if rule.Viewer != viewer {
+			continue
+		}
+
+		for _, user := range rule.Users {
+			if user == "*" {
+				return func(string) bool { return true }, nil
+			}
+
+			users[user] = true
+		}
+	}
+
+	return func(username string) bool { return users[username] }, nil
+}
+
 func getUserLocationForTelegram(u *locationUsecase, ctx context.Context, name string) string {
 	loc, err := u.repo.GetUserLastLocation(ctx, name)
 	if err != nil {
@@ -138,10 +263,72 @@ func getUserLocationForTelegram(u *locationUsecase, ctx context.Context, name st
 			return "*username not found!*"
 		}
 
-		logrus.Errorln(err)
+		logger.FromContext(ctx).Errorln(err)
 
 		return "*internal server error, please report to admin.*"
 	}
 
-	return toTelegramMessage(&loc)
+	place, _ := u.placeAt(ctx, &loc)
+
+	return toTelegramMessage(&loc, place, u.printer(ctx, loc.Username))
+}
+
+// publish sends the stored location to live subscribers, its details are only looked up
+// when one of them may see it
+func (u *locationUsecase) publish(ctx context.Context, l *model.Location) {
+	u.hub.PublishFunc(l.Username, func() *model.LocationDetails {
+		return u.details(ctx, l)
+	})
+}
+
+// details returns the details of the location formatted per the preferences of its user,
+// with its known place & the position of its access point for a coarse fix
+func (u *locationUsecase) details(ctx context.Context, l *model.Location) *model.LocationDetails {
+	place, wifi := u.placeAt(ctx, l)
+
+	details := toLastLocationDetails(l, place, u.printer(ctx, l.Username))
+	details.WifiFix = wifiFix(l, wifi)
+
+	return details
+}
+
+// placeAt returns the label of the known place of the location, empty without places.
+// Connected to a known access point it is the place of the access point, like "Office WiFi",
+// which is returned too
+func (u *locationUsecase) placeAt(ctx context.Context, l *model.Location) (string, *model.Place) {
+	if u.places == nil {
+		return "", nil
+	}
+
+	if l.Bssid != "" {
+		if place := u.places.WifiPlace(ctx, l.Username, l.Bssid); place != nil {
+			return place.Label() + " WiFi", place
+		}
+	}
+
+	return u.places.PlaceAt(ctx, l.Username, l.Lat, l.Lon), nil
+}
+
+// printer formats the locations of the user per its preferences
+func (u *locationUsecase) printer(ctx context.Context, username string) locale.Printer {
+	if u.prefs == nil {
+		return locale.New(locale.WithDefaults(model.Preference{}, config.Get().App.TimeZone))
+	}
+
+	return locale.New(u.prefs.For(ctx, username))
+}
+
+// wifiFix returns the position of the place of the access point a fix less accurate than
+// places.wifi_fix_accuracy was connected to, the place radius is its accuracy. The fix itself is kept
+func wifiFix(l *model.Location, place *model.Place) *model.WifiFix {
+	threshold := config.Get().Places.WifiFixAccuracy
+	if place == nil || threshold <= 0 || l.Acc <= threshold || place.Radius >= float64(l.Acc) {
+		return nil
+	}
+
+	return &model.WifiFix{
+		Latitude:  place.Lat,
+		Longitude: place.Lon,
+		Accuracy:  int16(math.Min(math.Max(math.Ceil(place.Radius), 1), math.MaxInt16)),
+	}
 }
@@ -4,9 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"net/http"
+	"os"
+	"ot-recorder/app/location/ingest"
+	"ot-recorder/app/location/stream"
 	"ot-recorder/app/location/usecase"
 	"ot-recorder/app/model"
 	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -43,12 +50,117 @@ func TestCreateLocation(t *testing.T) {
 		mockLocationRepo.On("CreateLocation", mock.Anything, mock.AnythingOfType("*model.Location")).
 			Return(nil).Once()
 
-		u := usecase.NewLocationUsecase(mockLocationRepo, time.Second*2)
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
 
 		err := u.Ping(context.TODO(), &tMockLoc)
 		assert.NoError(t, err)
 		mockLocationRepo.AssertExpectations(t)
 	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		tMockLoc := mockLocation
+
+		mockLocationRepo.On("CreateLocation", mock.Anything, mock.AnythingOfType("*model.Location")).
+			Return(model.ErrDuplicateLocation).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+		err := u.Ping(context.TODO(), &tMockLoc)
+		assert.NoError(t, err)
+		mockLocationRepo.AssertExpectations(t)
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		tMockLoc := mockLocation
+
+		mockLocationRepo.On("CreateLocation", mock.Anything, mock.AnythingOfType("*model.Location")).
+			Return(ingest.ErrQueueFull).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+		err := u.Ping(context.TODO(), &tMockLoc)
+		code, _ := response.RespondError(err)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		mockLocationRepo.AssertExpectations(t)
+	})
+
+	t.Run("wifi fix", func(t *testing.T) {
+		cfgFile := filepath.Join(t.TempDir(), "config.yml")
+		assert.NoError(t, os.WriteFile(cfgFile, []byte("places:\n  wifi_fix_accuracy: 100\n"), 0o600))
+		assert.NoError(t, config.Load(cfgFile))
+
+		coarse := mockLocation
+		coarse.Acc = 1500
+
+		mockLocationRepo.On("GetUserLastLocation", mock.Anything, "dev").Return(coarse, nil).Once()
+		mockLocationRepo.On("GetUserLastLocation", mock.Anything, "dev").Return(mockLocation, nil).Once()
+
+		mockPlaces := new(mocks.PlaceLocator)
+		mockPlaces.On("WifiPlace", mock.Anything, "dev", "c0:00:00:00:00:00").
+			Return(&model.Place{Name: "Office", Lat: 23.5, Lon: 90.5, Radius: 49.2})
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, mockPlaces, nil, stream.NewHub(1), time.Second*2)
+
+		// a coarse fix is kept and gets the position of the place of the access point
+		details, err := u.LastLocation(context.TODO(), "dev")
+		assert.NoError(t, err)
+		assert.Equal(t, 23.0, details.Latitude)
+		assert.Equal(t, int16(1500), details.Accuracy)
+		assert.Equal(t, &model.WifiFix{Latitude: 23.5, Longitude: 90.5, Accuracy: 50}, details.WifiFix)
+
+		// an accurate one doesn't
+		details, err = u.LastLocation(context.TODO(), "dev")
+		assert.NoError(t, err)
+		assert.Nil(t, details.WifiFix)
+		mockLocationRepo.AssertExpectations(t)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		tMockLoc := mockLocation
+
+		mockLocationRepo.On("CreateLocation", mock.Anything, mock.AnythingOfType("*model.Location")).
+			Return(errors.New("db down")).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+		err := u.Ping(context.TODO(), &tMockLoc)
+		assert.Error(t, err)
+		mockLocationRepo.AssertExpectations(t)
+	})
+}
+
+func TestPingBatch(t *testing.T) {
+	locations := []*model.Location{
+		{Username: "dev", Device: "phoneAndroid", CreatedAt: time.Now().Unix(), Lat: 23.0000000, Lon: 90.0000000},
+		{Username: "dev", Device: "phoneAndroid", CreatedAt: time.Now().Unix(), Lat: 23.0000000, Lon: 90.0000000},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocationBatch", mock.Anything, locations).
+			Return([]error{nil, model.ErrDuplicateLocation}, nil).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+		results, err := u.PingBatch(context.TODO(), locations)
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.ErrorIs(t, results[1], model.ErrDuplicateLocation)
+		mockLocationRepo.AssertExpectations(t)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocationBatch", mock.Anything, locations).
+			Return(nil, errors.New("db down")).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+		_, err := u.PingBatch(context.TODO(), locations)
+		code, _ := response.RespondError(err)
+		assert.Equal(t, http.StatusInternalServerError, code)
+		mockLocationRepo.AssertExpectations(t)
+	})
 }
 
 func TestGetUserLastLocation(t *testing.T) {
@@ -79,7 +191,7 @@ func TestGetUserLastLocation(t *testing.T) {
 		mockLocationRepo.On("GetUserLastLocation", mock.Anything, mock.AnythingOfType("string")).
 			Return(existingLocation, nil).Once()
 
-		u := usecase.NewLocationUsecase(mockLocationRepo, time.Second*2)
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
 		details, err := u.LastLocation(context.TODO(), "dev")
 
 		assert.NoError(t, err)
@@ -88,11 +200,36 @@ func TestGetUserLastLocation(t *testing.T) {
 		mockLocationRepo.AssertExpectations(t)
 	})
 
+	t.Run("preferences", func(t *testing.T) {
+		existingLocation := mockLocation
+		existingLocation.CreatedAt = 1669131295 // 2022-11-22 15:34:55 UTC
+		existingLocation.Vel = 12
+
+		mockLocationRepo.On("GetUserLastLocation", mock.Anything, "dev").Return(existingLocation, nil).Once()
+
+		mockPrefs := new(mocks.PreferenceProvider)
+		mockPrefs.On("For", mock.Anything, "dev").Return(model.Preference{
+			TimeZone: "Asia/Dhaka", Units: model.UnitsImperial, Clock: model.Clock12h, Language: "fr",
+		}).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, mockPrefs, stream.NewHub(1), time.Second*2)
+		details, err := u.LastLocation(context.TODO(), "dev")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2022-11-22 09:34:55 PM", details.DateTime)
+		assert.Equal(t, "2022-11-22T21:34:55+06:00", details.DateTimeISO)
+		assert.Equal(t, int64(1669131295), details.Timestamp)
+		assert.Equal(t, "7 mph", details.Speed)
+		assert.Equal(t, "40 %", details.BatteryLevel)
+		mockLocationRepo.AssertExpectations(t)
+		mockPrefs.AssertExpectations(t)
+	})
+
 	t.Run("not-found", func(t *testing.T) {
 		mockLocationRepo.On("GetUserLastLocation", mock.Anything, mock.AnythingOfType("string")).
 			Return(model.Location{}, errors.New("no row found")).Once()
 
-		u := usecase.NewLocationUsecase(mockLocationRepo, time.Second*2)
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
 		_, err := u.LastLocation(context.TODO(), "none")
 
 		assert.Error(t, err)
@@ -150,20 +287,72 @@ func TestTelegramHook(t *testing.T) {
 		mockLocationRepo.On("GetUserLastLocation", mock.Anything, mock.AnythingOfType("string")).
 			Return(mockLocation, nil).Once()
 
-		u := usecase.NewLocationUsecase(mockLocationRepo, time.Second*2)
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
 		details := u.TelegramHook(context.TODO(), mockTGReq)
 
 		assert.Equal(t, mockTGReq.Message.MessageID, details.ReplyToMessageID)
 		assert.Equal(t, mockTGReq.Message.Chat.ID, details.ChatID)
 		assert.Contains(t, details.Text, "Username: *dev*")
+		assert.Contains(t, details.Text, "Latitude: *23.000000*")
 		mockLocationRepo.AssertExpectations(t)
 	})
 
+	t.Run("at a place", func(t *testing.T) {
+		mockLocationRepo.On("GetUserLastLocation", mock.Anything, mock.AnythingOfType("string")).
+			Return(mockLocation, nil).Once()
+
+		mockPlaces := new(mocks.PlaceLocator)
+		mockPlaces.On("WifiPlace", mock.Anything, "dev", "c0:00:00:00:00:00").Return(nil).Once()
+		mockPlaces.On("PlaceAt", mock.Anything, "dev", 23.0, 90.0).Return("Home").Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, mockPlaces, nil, stream.NewHub(1), time.Second*2)
+		details := u.TelegramHook(context.TODO(), mockTGReq)
+
+		assert.Contains(t, details.Text, "Place: *at Home*")
+		assert.NotContains(t, details.Text, "Latitude")
+		mockLocationRepo.AssertExpectations(t)
+		mockPlaces.AssertExpectations(t)
+	})
+
+	t.Run("at a known access point", func(t *testing.T) {
+		mockLocationRepo.On("GetUserLastLocation", mock.Anything, mock.AnythingOfType("string")).
+			Return(mockLocation, nil).Once()
+
+		mockPlaces := new(mocks.PlaceLocator)
+		mockPlaces.On("WifiPlace", mock.Anything, "dev", "c0:00:00:00:00:00").
+			Return(&model.Place{Name: "Office"}).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, mockPlaces, nil, stream.NewHub(1), time.Second*2)
+		details := u.TelegramHook(context.TODO(), mockTGReq)
+
+		assert.Contains(t, details.Text, "Place: *at Office WiFi*")
+		mockLocationRepo.AssertExpectations(t)
+		mockPlaces.AssertExpectations(t)
+	})
+
+	t.Run("preferences", func(t *testing.T) {
+		mockLocationRepo.On("GetUserLastLocation", mock.Anything, mock.AnythingOfType("string")).
+			Return(mockLocation, nil).Once()
+
+		mockPrefs := new(mocks.PreferenceProvider)
+		mockPrefs.On("For", mock.Anything, "dev").
+			Return(model.Preference{TimeZone: "UTC", Units: model.UnitsImperial, Language: "de"}).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, mockPrefs, stream.NewHub(1), time.Second*2)
+		details := u.TelegramHook(context.TODO(), mockTGReq)
+
+		assert.Contains(t, details.Text, "Benutzer: *dev*")
+		assert.Contains(t, details.Text, "Genauigkeit: *43 ft*")
+		assert.Contains(t, details.Text, "Akku: *40 %*")
+		mockLocationRepo.AssertExpectations(t)
+		mockPrefs.AssertExpectations(t)
+	})
+
 	t.Run("not-found", func(t *testing.T) {
 		mockLocationRepo.On("GetUserLastLocation", mock.Anything, mock.AnythingOfType("string")).
 			Return(model.Location{}, sql.ErrNoRows).Once()
 
-		u := usecase.NewLocationUsecase(mockLocationRepo, time.Second*2)
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
 		tgReq := mockTGReq
 		tgReq.Message.Text = "/loc test"
 		details := u.TelegramHook(context.TODO(), mockTGReq)
@@ -201,7 +390,7 @@ func TestTelegramHookInvalid(t *testing.T) {
 	}
 
 	t.Run("show help", func(t *testing.T) {
-		u := usecase.NewLocationUsecase(mockLocationRepo, time.Second*2)
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
 		details := u.TelegramHook(context.TODO(), mockTGReq)
 
 		assert.Contains(t, details.Text, "/help")
@@ -210,9 +399,67 @@ func TestTelegramHookInvalid(t *testing.T) {
 	t.Run("invalid command", func(t *testing.T) {
 		mtg := mockTGReq
 		mtg.Message.Text = "/invalid"
-		u := usecase.NewLocationUsecase(mockLocationRepo, time.Second*2)
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
 		details := u.TelegramHook(context.TODO(), mtg)
 
 		assert.Contains(t, details.Text, "/help")
 	})
 }
+
+func TestSubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	t.Run("publish accepted ping", func(t *testing.T) {
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocation", mock.Anything, mock.AnythingOfType("*model.Location")).
+			Return(nil).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+		locations, err := u.Subscribe(ctx, "")
+		assert.NoError(t, err)
+
+		assert.NoError(t, u.Ping(ctx, &model.Location{Username: "dev", Device: "phone", CreatedAt: time.Now().Unix()}))
+
+		l := <-locations
+		assert.Equal(t, "dev", l.Username)
+		assert.Equal(t, "phone", l.Device)
+	})
+
+	t.Run("access rules", func(t *testing.T) {
+		cfgFile := filepath.Join(t.TempDir(), "config.yml")
+		assert.NoError(t, os.WriteFile(cfgFile, []byte(`
+stream:
+  access:
+    - viewer: dev
+      users: [ops]
+    - viewer: admin
+      users: ["*"]
+`), 0o600))
+		assert.NoError(t, config.Load(cfgFile))
+
+		mockLocationRepo := new(mocks.LocationRepository)
+		mockLocationRepo.On("CreateLocationBatch", mock.Anything, mock.Anything).
+			Return([]error{nil, nil, nil}, nil).Once()
+
+		u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(3), time.Second*2)
+
+		_, err := u.Subscribe(ctx, "")
+		code, _ := response.RespondError(err)
+		assert.Equal(t, http.StatusUnauthorized, code)
+
+		devLocations, err := u.Subscribe(ctx, "dev")
+		assert.NoError(t, err)
+		adminLocations, err := u.Subscribe(ctx, "admin")
+		assert.NoError(t, err)
+
+		_, err = u.PingBatch(ctx, []*model.Location{{Username: "dev"}, {Username: "ops"}, {Username: "guest"}})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "dev", (<-devLocations).Username)
+		assert.Equal(t, "ops", (<-devLocations).Username)
+		assert.Len(t, devLocations, 0)
+		assert.Len(t, adminLocations, 3)
+	})
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"ot-recorder/app/geo"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+)
+
+// Within returns the locations of the query ordered by time, with their distance
+// in meters from the center of radius queries
+func (u *locationUsecase) Within(c context.Context, query model.SpatialQuery) (points []model.SpatialPoint, err error) {
+	c, span := tracer.Start(c, "locationUsecase.Within")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	locations, err := u.repo.GetLocationsWithin(ctx, query)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	points = make([]model.SpatialPoint, len(locations))
+	for i := range locations {
+		l := &locations[i]
+		points[i] = model.SpatialPoint{Username: l.Username, TrackPoint: toTrackPoint(l)}
+
+		if query.Radius > 0 {
+			points[i].Distance = math.Round(geo.Distance(query.Lat, query.Lon, l.Lat, l.Lon))
+		}
+	}
+
+	return points, nil
+}
@@ -0,0 +1,51 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"ot-recorder/app/location/stream"
+	"ot-recorder/app/location/usecase"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWithin(t *testing.T) {
+	query := model.SpatialQuery{
+		From:   100,
+		To:     200,
+		MinLat: 23.80,
+		MinLon: 90.40,
+		MaxLat: 23.82,
+		MaxLon: 90.43,
+		Lat:    23.8103,
+		Lon:    90.4125,
+		Radius: 500,
+		Limit:  10,
+	}
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetLocationsWithin", mock.Anything, query).Return([]model.Location{
+		{Username: "dev", Device: "phone", CreatedAt: 150, Lat: 23.8103, Lon: 90.4125},
+		{Username: "mom", Device: "phone", CreatedAt: 160, Lat: 23.8130, Lon: 90.4125},
+	}, nil).Once()
+	mockLocationRepo.On("GetLocationsWithin", mock.Anything, mock.Anything).Return(nil, errors.New("timeout")).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	points, err := u.Within(context.TODO(), query)
+	assert.NoError(t, err)
+	assert.Len(t, points, 2)
+	assert.Equal(t, "mom", points[1].Username)
+	assert.Equal(t, int64(160), points[1].CreatedAt)
+	assert.Equal(t, 0.0, points[0].Distance)
+	assert.Equal(t, 300.0, points[1].Distance)
+
+	_, err = u.Within(context.TODO(), model.SpatialQuery{})
+	assert.Error(t, err)
+	mockLocationRepo.AssertExpectations(t)
+}
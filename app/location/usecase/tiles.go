@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"ot-recorder/app/geo"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+)
+
+const (
+	tileBuffer  = 64   // tile coordinates around a tile whose locations are included, so tracks cross its edges
+	maxTrackGap = 1800 // seconds between two locations of a device that break its track
+	pointsLayer = "points"
+	tracksLayer = "tracks"
+)
+
+// Heatmap counts the locations of the query per geohash cell of precision characters
+func (u *locationUsecase) Heatmap(
+	c context.Context,
+	query model.LocationQuery,
+	precision int,
+) (cells []model.HeatmapCell, err error) {
+	c, span := tracer.Start(c, "locationUsecase.Heatmap")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	cells, err = u.repo.GetHeatmap(ctx, query, precision)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	return cells, nil
+}
+
+// Tile returns the locations of the query inside the tile z/x/y as a Mapbox Vector Tile
+// with a points and a tracks layer, tracks are the locations of a device joined in time order
+func (u *locationUsecase) Tile(c context.Context, z, x, y int, query model.LocationQuery) (tile []byte, err error) {
+	c, span := tracer.Start(c, "locationUsecase.Tile")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	t := geo.Tile{Z: z, X: x, Y: y}
+	box := t.Bounds(tileBuffer)
+
+	locations, err := u.repo.GetLocationsWithin(ctx, model.SpatialQuery{
+		Username: query.Username,
+		Device:   query.Device,
+		From:     query.From,
+		To:       query.To,
+		MinLat:   box.MinLat,
+		MinLon:   box.MinLon,
+		MaxLat:   box.MaxLat,
+		MaxLon:   box.MaxLon,
+		Limit:    query.Limit,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	return geo.EncodeMVT([]geo.Layer{
+		{Name: pointsLayer, Features: pointFeatures(t, locations)},
+		{Name: tracksLayer, Features: trackFeatures(t, locations)},
+	}), nil
+}
+
+func pointFeatures(t geo.Tile, locations []model.Location) []geo.Feature {
+	features := make([]geo.Feature, len(locations))
+
+	for i := range locations {
+		l := &locations[i]
+		x, y := t.Project(l.Lat, l.Lon)
+
+		features[i] = geo.Feature{
+			ID:       uint64(l.ID),
+			Type:     geo.GeomPoint,
+			Geometry: [][2]int{{x, y}},
+			Properties: map[string]interface{}{
+				"username":   l.Username,
+				"device":     l.Device,
+				"created_at": l.CreatedAt,
+				"acc":        int64(l.Acc),
+				"vel":        int64(l.Vel),
+				"batt":       int64(l.Batt),
+			},
+		}
+	}
+
+	return features
+}
+
+// trackFeatures joins the time ordered locations of every device into line strings,
+// a gap longer than maxTrackGap starts a new line
+func trackFeatures(t geo.Tile, locations []model.Location) []geo.Feature {
+	type device struct{ username, name string }
+
+	var (
+		features []geo.Feature
+		open     = map[device]int{}
+		lastSeen = map[device]int64{}
+	)
+
+	for i := range locations {
+		l := &locations[i]
+		d := device{l.Username, l.Device}
+		x, y := t.Project(l.Lat, l.Lon)
+
+		idx, ok := open[d]
+		if !ok || l.CreatedAt-lastSeen[d] > maxTrackGap {
+			idx = len(features)
+			open[d] = idx
+			features = append(features, geo.Feature{
+				// a line is identified by its first location
+				ID:   uint64(l.ID),
+				Type: geo.GeomLineString,
+				Properties: map[string]interface{}{
+					"username": l.Username,
+					"device":   l.Device,
+					"from":     l.CreatedAt,
+				},
+			})
+		}
+
+		features[idx].Geometry = append(features[idx].Geometry, [2]int{x, y})
+		features[idx].Properties["to"] = l.CreatedAt
+		lastSeen[d] = l.CreatedAt
+	}
+
+	return features
+}
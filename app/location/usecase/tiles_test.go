@@ -0,0 +1,67 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"ot-recorder/app/location/stream"
+	"ot-recorder/app/location/usecase"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHeatmap(t *testing.T) {
+	query := model.LocationQuery{Username: "dev", From: 100, To: 200, Limit: 10}
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetHeatmap", mock.Anything, query, 7).Return([]model.HeatmapCell{
+		{Geohash: "wh0r3qs", Lat: 23.8103, Lon: 90.4125, Count: 42},
+	}, nil).Once()
+	mockLocationRepo.On("GetHeatmap", mock.Anything, query, 5).Return(nil, errors.New("timeout")).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	cells, err := u.Heatmap(context.TODO(), query, 7)
+	assert.NoError(t, err)
+	assert.Len(t, cells, 1)
+	assert.Equal(t, int64(42), cells[0].Count)
+
+	_, err = u.Heatmap(context.TODO(), query, 5)
+	assert.Error(t, err)
+	mockLocationRepo.AssertExpectations(t)
+}
+
+func TestTile(t *testing.T) {
+	query := model.LocationQuery{Username: "dev", Device: "phone", From: 100, To: 10000, Limit: 10}
+
+	inTile := mock.MatchedBy(func(q model.SpatialQuery) bool {
+		return q.Username == "dev" && q.Device == "phone" && q.Limit == 10 &&
+			q.MinLat < 23.8103 && q.MaxLat > 23.8103 && q.MinLon < 90.4125 && q.MaxLon > 90.4125
+	})
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetLocationsWithin", mock.Anything, inTile).Return([]model.Location{
+		{ID: 1, Username: "dev", Device: "phone", CreatedAt: 150, Lat: 23.8103, Lon: 90.4125},
+		{ID: 2, Username: "dev", Device: "phone", CreatedAt: 160, Lat: 23.8130, Lon: 90.4150},
+		// after a long gap, starts another track of a single point
+		{ID: 3, Username: "dev", Device: "phone", CreatedAt: 9000, Lat: 23.8140, Lon: 90.4160},
+	}, nil).Once()
+	mockLocationRepo.On("GetLocationsWithin", mock.Anything, mock.Anything).Return(nil, errors.New("timeout")).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	tile, err := u.Tile(context.TODO(), 12, 3076, 1768, query)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Contains(tile, []byte("points")))
+	assert.True(t, bytes.Contains(tile, []byte("tracks")))
+	assert.True(t, bytes.Contains(tile, []byte("phone")))
+
+	_, err = u.Tile(context.TODO(), 0, 0, 0, query)
+	assert.Error(t, err)
+	mockLocationRepo.AssertExpectations(t)
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+)
+
+// Users returns every user with a location
+func (u *locationUsecase) Users(c context.Context) (users []string, err error) {
+	c, span := tracer.Start(c, "locationUsecase.Users")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	users, err = u.repo.GetUsers(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	return users, nil
+}
+
+// Devices returns the devices of a user, not found when the user has no location
+func (u *locationUsecase) Devices(c context.Context, username string) (devices []model.Device, err error) {
+	c, span := tracer.Start(c, "locationUsecase.Devices")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	devices, err = u.repo.GetUserDevices(ctx, username)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	if len(devices) == 0 {
+		return nil, response.ErrNotFound
+	}
+
+	return devices, nil
+}
+
+// LastLocations returns the last location of every device, of one user when username isn't empty
+func (u *locationUsecase) LastLocations(
+	c context.Context,
+	username string,
+) (locations []*model.LocationDetails, err error) {
+	c, span := tracer.Start(c, "locationUsecase.LastLocations")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	last, err := u.repo.GetLastLocations(ctx, username)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, response.WrapError(
+			errors.New("internal server error, please report to admin"),
+			http.StatusInternalServerError,
+		)
+	}
+
+	if username != "" && len(last) == 0 {
+		return nil, response.ErrNotFound
+	}
+
+	locations = make([]*model.LocationDetails, len(last))
+	for i := range last {
+		locations[i] = u.details(ctx, &last[i])
+	}
+
+	return locations, nil
+}
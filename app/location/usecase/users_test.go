@@ -0,0 +1,77 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"ot-recorder/app/location/stream"
+	"ot-recorder/app/location/usecase"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUsers(t *testing.T) {
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetUsers", mock.Anything).Return([]string{"dev", "mom"}, nil).Once()
+	mockLocationRepo.On("GetUsers", mock.Anything).Return(nil, errors.New("connection lost")).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	users, err := u.Users(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev", "mom"}, users)
+
+	_, err = u.Users(context.TODO())
+	assert.Error(t, err)
+	mockLocationRepo.AssertExpectations(t)
+}
+
+func TestDevices(t *testing.T) {
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetUserDevices", mock.Anything, "dev").
+		Return([]model.Device{{Name: "phone", LastSeen: 100}, {Name: "tablet", LastSeen: 90}}, nil).Once()
+	mockLocationRepo.On("GetUserDevices", mock.Anything, "nobody").Return([]model.Device{}, nil).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	devices, err := u.Devices(context.TODO(), "dev")
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	_, err = u.Devices(context.TODO(), "nobody")
+	assert.ErrorIs(t, err, response.ErrNotFound)
+	mockLocationRepo.AssertExpectations(t)
+}
+
+func TestLastLocations(t *testing.T) {
+	now := time.Now().Unix()
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetLastLocations", mock.Anything, "").Return([]model.Location{
+		{Username: "dev", Device: "phone", CreatedAt: now, Lat: 23, Lon: 90},
+		{Username: "dev", Device: "tablet", CreatedAt: now - 60, Lat: 23.1, Lon: 90.1},
+	}, nil).Once()
+	mockLocationRepo.On("GetLastLocations", mock.Anything, "nobody").Return([]model.Location{}, nil).Once()
+	mockLocationRepo.On("GetLastLocations", mock.Anything, "").Return([]model.Location{}, nil).Once()
+
+	u := usecase.NewLocationUsecase(mockLocationRepo, nil, nil, stream.NewHub(1), time.Second*2)
+
+	locations, err := u.LastLocations(context.TODO(), "")
+	assert.NoError(t, err)
+	assert.Len(t, locations, 2)
+	assert.Equal(t, "tablet", locations[1].Device)
+	assert.Contains(t, locations[1].MapLink, "mlat=23.1")
+
+	_, err = u.LastLocations(context.TODO(), "nobody")
+	assert.ErrorIs(t, err, response.ErrNotFound)
+
+	locations, err = u.LastLocations(context.TODO(), "")
+	assert.NoError(t, err)
+	assert.Empty(t, locations)
+	mockLocationRepo.AssertExpectations(t)
+}
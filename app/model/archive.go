@@ -0,0 +1,28 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// ingestion channels of archived payloads
+const (
+	ChannelPing      = "ping"
+	ChannelPingBatch = "ping_batch"
+)
+
+// Payload a message as received by an ingestion channel, with the headers it was mapped with,
+// ReceivedAt is unix seconds
+type Payload struct {
+	ReceivedAt int64           `json:"received_at"`
+	Channel    string          `json:"channel"`
+	Username   string          `json:"username"`
+	Device     string          `json:"device"`
+	IP         string          `json:"ip"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// PayloadArchive represent the append-only raw payload archive contract
+type PayloadArchive interface {
+	Append(ctx context.Context, payload *Payload) error
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// actions of the OwnTracks cmd messages devices may be sent
+const (
+	CommandReportLocation   = "reportLocation"
+	CommandSetConfiguration = "setConfiguration"
+	CommandClearWaypoints   = "clearWaypoints"
+	CommandAction           = "action"
+)
+
+// DeviceCommand an OwnTracks cmd message queued for a device, Payload is the message itself.
+// It is pending until it is returned in the response of a ping of the device at DeliveredAt
+type DeviceCommand struct {
+	ID          int64           `json:"id"`
+	Username    string          `json:"username"`
+	Device      string          `json:"device"`
+	Action      string          `json:"action"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedBy   string          `json:"created_by"`
+	CreatedAt   int64           `json:"created_at"`
+	DeliveredAt int64           `json:"delivered_at"`
+}
+
+// CommandRepository represent the device commands repository contract
+type CommandRepository interface {
+	CreateCommand(ctx context.Context, command *DeviceCommand) error
+	GetCommands(ctx context.Context, username, device string, limit int) ([]DeviceCommand, error)
+	GetPendingCommands(ctx context.Context, username, device string, since int64) ([]DeviceCommand, error)
+	DeliverCommands(ctx context.Context, ids []int64, deliveredAt int64) error
+}
+
+// CommandUsecase represent the device commands usecase contract, caller is the admin
+// queueing the commands
+type CommandUsecase interface {
+	Enqueue(c context.Context, caller string, command *DeviceCommand) (err error)
+	List(c context.Context, caller, username, device string) (commands []DeviceCommand, err error)
+	Commands(c context.Context, username, device string, tst int64) []interface{}
+}
@@ -0,0 +1,50 @@
+package model
+
+import "context"
+
+// kinds of device alerts
+const (
+	AlertInactive   = "inactive"
+	AlertBatteryLow = "battery_low"
+	AlertUnplugged  = "unplugged"
+)
+
+// DeviceStatus health of a device from its locations in the monitor window. Cadence is the
+// median seconds between its reports, BatteryTrend the battery change in percent per hour,
+// UnpluggedSince the unix time it is unplugged since, 0 while charging or unknown
+type DeviceStatus struct {
+	Username       string   `json:"username"`
+	Device         string   `json:"device"`
+	LastSeen       int64    `json:"last_seen"`
+	Cadence        int64    `json:"cadence"`
+	Battery        int8     `json:"battery"`
+	BatteryStatus  string   `json:"battery_status"`
+	BatteryTrend   float64  `json:"battery_trend"`
+	UnpluggedSince int64    `json:"unplugged_since,omitempty"`
+	Trigger        string   `json:"trigger,omitempty"`
+	WifiName       string   `json:"wifi_name,omitempty"`
+	Alerts         []string `json:"alerts"`
+}
+
+// DeviceAlert a device alert starting or, when Resolved, ending at At(unix seconds)
+type DeviceAlert struct {
+	Username string `json:"username"`
+	Device   string `json:"device"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+	Resolved bool   `json:"resolved"`
+	At       int64  `json:"at"`
+}
+
+// Notifier a channel device alerts are sent through
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert *DeviceAlert) error
+}
+
+// DeviceUsecase represent the device monitoring usecase contract
+type DeviceUsecase interface {
+	Status(c context.Context, username string) (statuses []DeviceStatus, err error)
+	Check(c context.Context) (alerts []DeviceAlert, err error)
+	Notifiers() map[string]string
+}
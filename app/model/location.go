@@ -2,8 +2,15 @@ package model
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 )
 
+// ErrDuplicateLocation returned by LocationRepository.CreateLocation when the same
+// username, device, tst, lat & lon is already stored, e.g. app retried an upload
+var ErrDuplicateLocation = errors.New("duplicate location")
+
 type Location struct {
 	ID        int64   `json:"id"`
 	Username  string  `json:"username"`
@@ -23,25 +30,212 @@ type Location struct {
 	Bssid     string  `json:"bssid"`
 	Ssid      string  `json:"ssid"`
 	IP        string  `json:"ip"`
+	Cog       int16   `json:"cog"`
+	Rad       int32   `json:"rad"`
+	P         float64 `json:"p"`
+	Poi       string  `json:"poi"`
+	Tag       string  `json:"tag"`
+	Conn      string  `json:"conn"`
+	Topic     string  `json:"topic"`
+	// MsgID & MsgCreatedAt are the _id & created_at of the message, CreatedAt is its tst
+	MsgID            string     `json:"msg_id"`
+	MsgCreatedAt     int64      `json:"msg_created_at"`
+	InRegions        StringList `json:"inregions"`
+	InRids           StringList `json:"inrids"`
+	MotionActivities StringList `json:"motionactivities"`
+	// Raw the message as received, kept with app.store_raw so fields unknown today aren't lost
+	Raw json.RawMessage `json:"raw,omitempty"`
+}
+
+// StringList a list stored as its JSON array(String) in a text column, empty lists as an empty string
+type StringList []string
+
+// String the JSON array of the list, empty for an empty list
+func (s StringList) String() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	b, _ := json.Marshal([]string(s))
+
+	return string(b)
 }
 
+// Scan reads a JSON array, NULL & empty strings are an empty list
+func (s *StringList) Scan(src interface{}) error {
+	var b []byte
+
+	switch v := src.(type) {
+	case nil:
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("can't scan %T into a string list", src)
+	}
+
+	if len(b) == 0 {
+		*s = nil
+		return nil
+	}
+
+	return json.Unmarshal(b, (*[]string)(s))
+}
+
+// LocationQuery locations of a user between two unix times, of every device when Device is empty
+type LocationQuery struct {
+	Username string
+	Device   string
+	From     int64
+	To       int64
+	Limit    int
+}
+
+// SpatialQuery locations of every user, or of Username when set, inside a bounding box between two
+// unix times. With a Radius(meters) only locations within the radius of Lat & Lon match, the box must
+// enclose that circle.
+type SpatialQuery struct {
+	Username string
+	Device   string
+	From     int64
+	To       int64
+	MinLat   float64
+	MinLon   float64
+	MaxLat   float64
+	MaxLon   float64
+	Lat      float64
+	Lon      float64
+	Radius   float64
+	Limit    int
+}
+
+// SpatialPoint a location matching a spatial query, Distance in meters from the center of a radius query
+type SpatialPoint struct {
+	Username string `json:"username"`
+	TrackPoint
+	Distance float64 `json:"distance,omitempty"`
+}
+
+// HeatmapCell number of locations in a geohash cell, Lat & Lon are their average
+type HeatmapCell struct {
+	Geohash string  `json:"geohash"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Count   int64   `json:"count"`
+}
+
+// BatteryBucket battery levels of a device in the bucket starting at From, Unplugged & Charging
+// count its locations by battery status, charging includes full. Locations without a level are skipped
+type BatteryBucket struct {
+	Device    string  `json:"device"`
+	From      int64   `json:"from"`
+	Points    int64   `json:"points"`
+	Min       int8    `json:"min"`
+	Max       int8    `json:"max"`
+	Avg       float64 `json:"avg"`
+	Unplugged int64   `json:"unplugged"`
+	Charging  int64   `json:"charging"`
+}
+
+// NetworkBucket locations of a device on one network in the bucket starting at From, between
+// FirstSeen & LastSeen. WifiName & WifiMAC are empty on mobile data
+type NetworkBucket struct {
+	Device    string `json:"device"`
+	From      int64  `json:"from"`
+	WifiName  string `json:"wifi_name"`
+	WifiMAC   string `json:"wifi_mac"`
+	IPAddress string `json:"ip_address"`
+	Points    int64  `json:"points"`
+	FirstSeen int64  `json:"first_seen"`
+	LastSeen  int64  `json:"last_seen"`
+}
+
+// Device a device of a user with the unix time of its latest location
+type Device struct {
+	Name     string `json:"device"`
+	LastSeen int64  `json:"last_seen"`
+}
+
+// TrackPoint a location of a history track
+type TrackPoint struct {
+	Device    string  `json:"device"`
+	CreatedAt int64   `json:"created_at"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	Acc       int16   `json:"acc,omitempty"`
+	Vel       int16   `json:"vel,omitempty"`
+	Batt      int8    `json:"batt,omitempty"`
+}
+
+// Stay a device staying within a small radius for a while
+type Stay struct {
+	Device   string  `json:"device"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	From     int64   `json:"from"`
+	To       int64   `json:"to"`
+	Duration int64   `json:"duration"`
+	Points   int     `json:"points"`
+}
+
+// Trip a device moving between two stays, distance in meters, duration in seconds
+type Trip struct {
+	Device   string  `json:"device"`
+	From     int64   `json:"from"`
+	To       int64   `json:"to"`
+	StartLat float64 `json:"start_lat"`
+	StartLon float64 `json:"start_lon"`
+	EndLat   float64 `json:"end_lat"`
+	EndLon   float64 `json:"end_lon"`
+	Distance float64 `json:"distance"`
+	Duration int64   `json:"duration"`
+	Points   int     `json:"points"`
+}
+
+// LocationDetails a location formatted per the preferences of its user, DateTime is local
+// to its time zone, DateTimeISO the same as ISO-8601 and Timestamp unix seconds
 type LocationDetails struct {
-	Username         string  `json:"username"`
-	Device           string  `json:"device"`
-	DateTime         string  `json:"date_time"`
-	Accuracy         int16   `json:"accuracy,omitempty"`
-	Altitude         int16   `json:"altitude,omitempty"`
-	BatteryLevel     string  `json:"battery_level,omitempty"`
-	BatteryStatus    string  `json:"battery_status,omitempty"`
-	Latitude         float64 `json:"latitude"`
-	Longitude        float64 `json:"longitude"`
-	Mode             string  `json:"mode,omitempty"`
-	VerticalAccuracy int16   `json:"vertical_accuracy,omitempty"`
-	Velocity         int16   `json:"velocity,omitempty"`
-	WifiName         string  `json:"wifi_name,omitempty"`
-	WifiMAC          string  `json:"wifi_mac,omitempty"`
-	IPAddress        string  `json:"ip_address"`
-	MapLink          string  `json:"map_link"`
+	Username         string   `json:"username"`
+	Device           string   `json:"device"`
+	DateTime         string   `json:"date_time"`
+	DateTimeISO      string   `json:"date_time_iso"`
+	Timestamp        int64    `json:"timestamp"`
+	Accuracy         int16    `json:"accuracy,omitempty"`
+	Altitude         int16    `json:"altitude,omitempty"`
+	BatteryLevel     string   `json:"battery_level,omitempty"`
+	BatteryStatus    string   `json:"battery_status,omitempty"`
+	Latitude         float64  `json:"latitude"`
+	Longitude        float64  `json:"longitude"`
+	Place            string   `json:"place,omitempty"`
+	Mode             string   `json:"mode,omitempty"`
+	VerticalAccuracy int16    `json:"vertical_accuracy,omitempty"`
+	Velocity         int16    `json:"velocity,omitempty"`
+	Speed            string   `json:"speed,omitempty"`
+	WifiName         string   `json:"wifi_name,omitempty"`
+	WifiMAC          string   `json:"wifi_mac,omitempty"`
+	IPAddress        string   `json:"ip_address"`
+	MapLink          string   `json:"map_link"`
+	Course           int16    `json:"course,omitempty"`
+	Radius           int32    `json:"radius,omitempty"`
+	Pressure         float64  `json:"pressure,omitempty"`
+	POI              string   `json:"poi,omitempty"`
+	Tag              string   `json:"tag,omitempty"`
+	Connection       string   `json:"connection,omitempty"`
+	InRegions        []string `json:"in_regions,omitempty"`
+	InRegionIDs      []string `json:"in_region_ids,omitempty"`
+	MotionActivities []string `json:"motion_activities,omitempty"`
+	Topic            string   `json:"topic,omitempty"`
+	MessageID        string   `json:"message_id,omitempty"`
+	MessageCreatedAt int64    `json:"message_created_at,omitempty"`
+	WifiFix          *WifiFix `json:"wifi_fix,omitempty"`
+}
+
+// WifiFix position of the known access point a coarse fix was connected to, accuracy in meters
+type WifiFix struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Accuracy  int16   `json:"accuracy"`
 }
 
 type TGUSER struct {
@@ -86,6 +280,7 @@ type TelegramResponse struct {
 
 type BatteryStatusEnum int
 type ModeEnum int
+type ConnectionEnum string
 
 const (
 	Unknown   BatteryStatusEnum = 0
@@ -96,6 +291,10 @@ const (
 	Significant ModeEnum = 1
 	Move        ModeEnum = 2
 
+	Wifi    ConnectionEnum = "w"
+	Mobile  ConnectionEnum = "m"
+	Offline ConnectionEnum = "o"
+
 	unknownstr = "Unknown"
 )
 
@@ -125,15 +324,55 @@ func (e ModeEnum) String() string {
 	}
 }
 
+// String empty when the app didn't tell the connection
+func (e ConnectionEnum) String() string {
+	switch e {
+	case Wifi:
+		return "WiFi"
+	case Mobile:
+		return "Mobile"
+	case Offline:
+		return "Offline"
+	case "":
+		return ""
+	default:
+		return unknownstr
+	}
+}
+
 // LocationRepository represent the locations repository contract
 type LocationRepository interface {
 	CreateLocation(tx context.Context, location *Location) error
+	CreateLocations(tx context.Context, locations []*Location) (int64, error)
+	CreateLocationBatch(tx context.Context, locations []*Location) ([]error, error)
 	GetUserLastLocation(tx context.Context, username string) (Location, error)
+	GetLocations(tx context.Context, query LocationQuery) ([]Location, error)
+	GetUsers(tx context.Context) ([]string, error)
+	GetUserDevices(tx context.Context, username string) ([]Device, error)
+	GetLastLocations(tx context.Context, username string) ([]Location, error)
+	GetLocationsWithin(tx context.Context, query SpatialQuery) ([]Location, error)
+	BackfillGeohash(tx context.Context, afterID int64, limit int) (lastID int64, updated int64, err error)
+	GetHeatmap(tx context.Context, query LocationQuery, precision int) ([]HeatmapCell, error)
+	GetBatteryHistory(tx context.Context, query LocationQuery, bucket int64) ([]BatteryBucket, error)
+	GetNetworkHistory(tx context.Context, query LocationQuery, bucket int64) ([]NetworkBucket, error)
 }
 
 // LocationUsecase represent the locations usecase contract
 type LocationUsecase interface {
 	Ping(c context.Context, l *Location) (err error)
+	PingBatch(c context.Context, locations []*Location) (results []error, err error)
 	LastLocation(c context.Context, username string) (location *LocationDetails, err error)
+	LastLocations(c context.Context, username string) (locations []*LocationDetails, err error)
+	Users(c context.Context) (users []string, err error)
+	Devices(c context.Context, username string) (devices []Device, err error)
 	TelegramHook(c context.Context, req *TelegramRequest) (message *TelegramResponse)
+	Subscribe(c context.Context, viewer string) (locations <-chan *LocationDetails, err error)
+	History(c context.Context, query LocationQuery) (points []TrackPoint, err error)
+	Trips(c context.Context, query LocationQuery) (trips []Trip, err error)
+	Stays(c context.Context, query LocationQuery) (stays []Stay, err error)
+	Within(c context.Context, query SpatialQuery) (points []SpatialPoint, err error)
+	Heatmap(c context.Context, query LocationQuery, precision int) (cells []HeatmapCell, err error)
+	Tile(c context.Context, z, x, y int, query LocationQuery) (tile []byte, err error)
+	BatteryHistory(c context.Context, query LocationQuery, bucket int64) (buckets []BatteryBucket, err error)
+	NetworkHistory(c context.Context, query LocationQuery, bucket int64) (buckets []NetworkBucket, err error)
 }
@@ -14,6 +14,34 @@ type LocationRepository struct {
 	mock.Mock
 }
 
+// BackfillGeohash provides a mock function with given fields: tx, afterID, limit
+func (_m *LocationRepository) BackfillGeohash(tx context.Context, afterID int64, limit int) (int64, int64, error) {
+	ret := _m.Called(tx, afterID, limit)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) int64); ok {
+		r0 = rf(tx, afterID, limit)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int) int64); ok {
+		r1 = rf(tx, afterID, limit)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64, int) error); ok {
+		r2 = rf(tx, afterID, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // CreateLocation provides a mock function with given fields: tx, location
 func (_m *LocationRepository) CreateLocation(tx context.Context, location *model.Location) error {
 	ret := _m.Called(tx, location)
@@ -28,6 +56,211 @@ func (_m *LocationRepository) CreateLocation(tx context.Context, location *model
 	return r0
 }
 
+// CreateLocationBatch provides a mock function with given fields: tx, locations
+func (_m *LocationRepository) CreateLocationBatch(tx context.Context, locations []*model.Location) ([]error, error) {
+	ret := _m.Called(tx, locations)
+
+	var r0 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.Location) []error); ok {
+		r0 = rf(tx, locations)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*model.Location) error); ok {
+		r1 = rf(tx, locations)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateLocations provides a mock function with given fields: tx, locations
+func (_m *LocationRepository) CreateLocations(tx context.Context, locations []*model.Location) (int64, error) {
+	ret := _m.Called(tx, locations)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.Location) int64); ok {
+		r0 = rf(tx, locations)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*model.Location) error); ok {
+		r1 = rf(tx, locations)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBatteryHistory provides a mock function with given fields: tx, query, bucket
+func (_m *LocationRepository) GetBatteryHistory(tx context.Context, query model.LocationQuery, bucket int64) ([]model.BatteryBucket, error) {
+	ret := _m.Called(tx, query, bucket)
+
+	var r0 []model.BatteryBucket
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery, int64) []model.BatteryBucket); ok {
+		r0 = rf(tx, query, bucket)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.BatteryBucket)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery, int64) error); ok {
+		r1 = rf(tx, query, bucket)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHeatmap provides a mock function with given fields: tx, query, precision
+func (_m *LocationRepository) GetHeatmap(tx context.Context, query model.LocationQuery, precision int) ([]model.HeatmapCell, error) {
+	ret := _m.Called(tx, query, precision)
+
+	var r0 []model.HeatmapCell
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery, int) []model.HeatmapCell); ok {
+		r0 = rf(tx, query, precision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.HeatmapCell)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery, int) error); ok {
+		r1 = rf(tx, query, precision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLastLocations provides a mock function with given fields: tx, username
+func (_m *LocationRepository) GetLastLocations(tx context.Context, username string) ([]model.Location, error) {
+	ret := _m.Called(tx, username)
+
+	var r0 []model.Location
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Location); ok {
+		r0 = rf(tx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Location)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(tx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLocations provides a mock function with given fields: tx, query
+func (_m *LocationRepository) GetLocations(tx context.Context, query model.LocationQuery) ([]model.Location, error) {
+	ret := _m.Called(tx, query)
+
+	var r0 []model.Location
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery) []model.Location); ok {
+		r0 = rf(tx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Location)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery) error); ok {
+		r1 = rf(tx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLocationsWithin provides a mock function with given fields: tx, query
+func (_m *LocationRepository) GetLocationsWithin(tx context.Context, query model.SpatialQuery) ([]model.Location, error) {
+	ret := _m.Called(tx, query)
+
+	var r0 []model.Location
+	if rf, ok := ret.Get(0).(func(context.Context, model.SpatialQuery) []model.Location); ok {
+		r0 = rf(tx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Location)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.SpatialQuery) error); ok {
+		r1 = rf(tx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNetworkHistory provides a mock function with given fields: tx, query, bucket
+func (_m *LocationRepository) GetNetworkHistory(tx context.Context, query model.LocationQuery, bucket int64) ([]model.NetworkBucket, error) {
+	ret := _m.Called(tx, query, bucket)
+
+	var r0 []model.NetworkBucket
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery, int64) []model.NetworkBucket); ok {
+		r0 = rf(tx, query, bucket)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.NetworkBucket)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery, int64) error); ok {
+		r1 = rf(tx, query, bucket)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserDevices provides a mock function with given fields: tx, username
+func (_m *LocationRepository) GetUserDevices(tx context.Context, username string) ([]model.Device, error) {
+	ret := _m.Called(tx, username)
+
+	var r0 []model.Device
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Device); ok {
+		r0 = rf(tx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Device)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(tx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserLastLocation provides a mock function with given fields: tx, username
 func (_m *LocationRepository) GetUserLastLocation(tx context.Context, username string) (model.Location, error) {
 	ret := _m.Called(tx, username)
@@ -49,6 +282,29 @@ func (_m *LocationRepository) GetUserLastLocation(tx context.Context, username s
 	return r0, r1
 }
 
+// GetUsers provides a mock function with given fields: tx
+func (_m *LocationRepository) GetUsers(tx context.Context) ([]string, error) {
+	ret := _m.Called(tx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(tx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewLocationRepository interface {
 	mock.TestingT
 	Cleanup(func())
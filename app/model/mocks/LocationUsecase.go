@@ -14,6 +14,98 @@ type LocationUsecase struct {
 	mock.Mock
 }
 
+// BatteryHistory provides a mock function with given fields: c, query, bucket
+func (_m *LocationUsecase) BatteryHistory(c context.Context, query model.LocationQuery, bucket int64) ([]model.BatteryBucket, error) {
+	ret := _m.Called(c, query, bucket)
+
+	var r0 []model.BatteryBucket
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery, int64) []model.BatteryBucket); ok {
+		r0 = rf(c, query, bucket)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.BatteryBucket)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery, int64) error); ok {
+		r1 = rf(c, query, bucket)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Devices provides a mock function with given fields: c, username
+func (_m *LocationUsecase) Devices(c context.Context, username string) ([]model.Device, error) {
+	ret := _m.Called(c, username)
+
+	var r0 []model.Device
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Device); ok {
+		r0 = rf(c, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Device)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(c, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Heatmap provides a mock function with given fields: c, query, precision
+func (_m *LocationUsecase) Heatmap(c context.Context, query model.LocationQuery, precision int) ([]model.HeatmapCell, error) {
+	ret := _m.Called(c, query, precision)
+
+	var r0 []model.HeatmapCell
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery, int) []model.HeatmapCell); ok {
+		r0 = rf(c, query, precision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.HeatmapCell)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery, int) error); ok {
+		r1 = rf(c, query, precision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// History provides a mock function with given fields: c, query
+func (_m *LocationUsecase) History(c context.Context, query model.LocationQuery) ([]model.TrackPoint, error) {
+	ret := _m.Called(c, query)
+
+	var r0 []model.TrackPoint
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery) []model.TrackPoint); ok {
+		r0 = rf(c, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.TrackPoint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery) error); ok {
+		r1 = rf(c, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LastLocation provides a mock function with given fields: c, username
 func (_m *LocationUsecase) LastLocation(c context.Context, username string) (*model.LocationDetails, error) {
 	ret := _m.Called(c, username)
@@ -37,6 +129,52 @@ func (_m *LocationUsecase) LastLocation(c context.Context, username string) (*mo
 	return r0, r1
 }
 
+// LastLocations provides a mock function with given fields: c, username
+func (_m *LocationUsecase) LastLocations(c context.Context, username string) ([]*model.LocationDetails, error) {
+	ret := _m.Called(c, username)
+
+	var r0 []*model.LocationDetails
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*model.LocationDetails); ok {
+		r0 = rf(c, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.LocationDetails)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(c, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NetworkHistory provides a mock function with given fields: c, query, bucket
+func (_m *LocationUsecase) NetworkHistory(c context.Context, query model.LocationQuery, bucket int64) ([]model.NetworkBucket, error) {
+	ret := _m.Called(c, query, bucket)
+
+	var r0 []model.NetworkBucket
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery, int64) []model.NetworkBucket); ok {
+		r0 = rf(c, query, bucket)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.NetworkBucket)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery, int64) error); ok {
+		r1 = rf(c, query, bucket)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Ping provides a mock function with given fields: c, l
 func (_m *LocationUsecase) Ping(c context.Context, l *model.Location) error {
 	ret := _m.Called(c, l)
@@ -51,6 +189,75 @@ func (_m *LocationUsecase) Ping(c context.Context, l *model.Location) error {
 	return r0
 }
 
+// PingBatch provides a mock function with given fields: c, locations
+func (_m *LocationUsecase) PingBatch(c context.Context, locations []*model.Location) ([]error, error) {
+	ret := _m.Called(c, locations)
+
+	var r0 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.Location) []error); ok {
+		r0 = rf(c, locations)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*model.Location) error); ok {
+		r1 = rf(c, locations)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Stays provides a mock function with given fields: c, query
+func (_m *LocationUsecase) Stays(c context.Context, query model.LocationQuery) ([]model.Stay, error) {
+	ret := _m.Called(c, query)
+
+	var r0 []model.Stay
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery) []model.Stay); ok {
+		r0 = rf(c, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Stay)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery) error); ok {
+		r1 = rf(c, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Subscribe provides a mock function with given fields: c, viewer
+func (_m *LocationUsecase) Subscribe(c context.Context, viewer string) (<-chan *model.LocationDetails, error) {
+	ret := _m.Called(c, viewer)
+
+	var r0 <-chan *model.LocationDetails
+	if rf, ok := ret.Get(0).(func(context.Context, string) <-chan *model.LocationDetails); ok {
+		r0 = rf(c, viewer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan *model.LocationDetails)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(c, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // TelegramHook provides a mock function with given fields: c, req
 func (_m *LocationUsecase) TelegramHook(c context.Context, req *model.TelegramRequest) *model.TelegramResponse {
 	ret := _m.Called(c, req)
@@ -67,6 +274,98 @@ func (_m *LocationUsecase) TelegramHook(c context.Context, req *model.TelegramRe
 	return r0
 }
 
+// Tile provides a mock function with given fields: c, z, x, y, query
+func (_m *LocationUsecase) Tile(c context.Context, z int, x int, y int, query model.LocationQuery) ([]byte, error) {
+	ret := _m.Called(c, z, x, y, query)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, model.LocationQuery) []byte); ok {
+		r0 = rf(c, z, x, y, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, model.LocationQuery) error); ok {
+		r1 = rf(c, z, x, y, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Trips provides a mock function with given fields: c, query
+func (_m *LocationUsecase) Trips(c context.Context, query model.LocationQuery) ([]model.Trip, error) {
+	ret := _m.Called(c, query)
+
+	var r0 []model.Trip
+	if rf, ok := ret.Get(0).(func(context.Context, model.LocationQuery) []model.Trip); ok {
+		r0 = rf(c, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Trip)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.LocationQuery) error); ok {
+		r1 = rf(c, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Users provides a mock function with given fields: c
+func (_m *LocationUsecase) Users(c context.Context) ([]string, error) {
+	ret := _m.Called(c)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(c)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(c)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Within provides a mock function with given fields: c, query
+func (_m *LocationUsecase) Within(c context.Context, query model.SpatialQuery) ([]model.SpatialPoint, error) {
+	ret := _m.Called(c, query)
+
+	var r0 []model.SpatialPoint
+	if rf, ok := ret.Get(0).(func(context.Context, model.SpatialQuery) []model.SpatialPoint); ok {
+		r0 = rf(c, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.SpatialPoint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.SpatialQuery) error); ok {
+		r1 = rf(c, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewLocationUsecase interface {
 	mock.TestingT
 	Cleanup(func())
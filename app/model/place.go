@@ -0,0 +1,95 @@
+package model
+
+import "context"
+
+// kinds of places inferred from the time of day of the visits
+const (
+	PlaceHome = "home"
+	PlaceWork = "work"
+)
+
+// Place a recurring stay of a user within Radius meters of Lat & Lon, Duration is the
+// seconds stayed in Visits stays, times are unix seconds. Pinned places are named or
+// created by the user and kept when places are refreshed
+type Place struct {
+	ID         int64   `json:"id"`
+	Username   string  `json:"username"`
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`
+	Lat        float64 `json:"lat"`
+	Lon        float64 `json:"lon"`
+	Radius     float64 `json:"radius"`
+	Visits     int     `json:"visits"`
+	Duration   int64   `json:"duration"`
+	FirstVisit int64   `json:"first_visit"`
+	LastVisit  int64   `json:"last_visit"`
+	Pinned     bool    `json:"pinned"`
+	UpdatedAt  int64   `json:"updated_at"`
+}
+
+// Label the name of the place, Home or Work for unnamed inferred ones
+func (p *Place) Label() string {
+	if p.Name != "" {
+		return p.Name
+	}
+
+	switch p.Kind {
+	case PlaceHome:
+		return "Home"
+	case PlaceWork:
+		return "Work"
+	default:
+		return ""
+	}
+}
+
+// PlaceUpdate changes of a place, nil fields are kept
+type PlaceUpdate struct {
+	Name   *string  `json:"name"`
+	Radius *float64 `json:"radius"`
+	Pinned *bool    `json:"pinned"`
+}
+
+// AccessPoint a wifi access point of a user at a place, BSSID is lower case with two digits
+// per octet. Locations reported while connected to it are at the place
+type AccessPoint struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	PlaceID   int64  `json:"place_id"`
+	BSSID     string `json:"bssid"`
+	SSID      string `json:"ssid"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// PlaceRepository represent the places repository contract
+type PlaceRepository interface {
+	CreatePlace(ctx context.Context, place *Place) error
+	GetUserPlaces(ctx context.Context, username string) ([]Place, error)
+	UpdatePlace(ctx context.Context, place *Place) error
+	DeletePlace(ctx context.Context, id int64, username string) error
+	ReplacePlaces(ctx context.Context, username string, places []*Place) error
+	SaveAccessPoint(ctx context.Context, ap *AccessPoint) error
+	GetUserAccessPoints(ctx context.Context, username string) ([]AccessPoint, error)
+	DeleteAccessPoint(ctx context.Context, id int64, username string) error
+}
+
+// PlaceUsecase represent the places usecase contract
+type PlaceUsecase interface {
+	Create(c context.Context, place *Place) (err error)
+	List(c context.Context, username string) (places []Place, err error)
+	Update(c context.Context, id int64, username string, update PlaceUpdate) (place *Place, err error)
+	Delete(c context.Context, id int64, username string) (err error)
+	Refresh(c context.Context, username string, days int) (places []Place, err error)
+	AddAccessPoint(c context.Context, ap *AccessPoint) (err error)
+	AccessPoints(c context.Context, username string) (aps []AccessPoint, err error)
+	DeleteAccessPoint(c context.Context, id int64, username string) (err error)
+	PlaceAt(c context.Context, username string, lat, lon float64) string
+	WifiPlace(c context.Context, username, bssid string) *Place
+}
+
+// PlaceLocator finds the label of the known place of a user a coordinate is inside,
+// empty when there is none, and the place of a known access point, nil when unknown
+type PlaceLocator interface {
+	PlaceAt(c context.Context, username string, lat, lon float64) string
+	WifiPlace(c context.Context, username, bssid string) *Place
+}
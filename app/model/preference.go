@@ -0,0 +1,49 @@
+package model
+
+import "context"
+
+// units & clocks of preferences
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+	Clock24h      = "24h"
+	Clock12h      = "12h"
+)
+
+// Preference how the locations of a user are shown in responses & telegram replies,
+// TimeZone is an IANA name like Asia/Dhaka and Language a two letter code like en
+type Preference struct {
+	ID        int64  `json:"-"`
+	Username  string `json:"username"`
+	TimeZone  string `json:"time_zone"`
+	Units     string `json:"units"`
+	Clock     string `json:"clock"`
+	Language  string `json:"language"`
+	UpdatedAt int64  `json:"updated_at,omitempty"`
+}
+
+// PreferenceUpdate changes of preferences, nil fields are kept
+type PreferenceUpdate struct {
+	TimeZone *string `json:"time_zone"`
+	Units    *string `json:"units"`
+	Clock    *string `json:"clock"`
+	Language *string `json:"language"`
+}
+
+// PreferenceRepository represent the preferences repository contract
+type PreferenceRepository interface {
+	GetPreference(ctx context.Context, username string) (Preference, error)
+	SavePreference(ctx context.Context, pref *Preference) error
+}
+
+// PreferenceUsecase represent the preferences usecase contract
+type PreferenceUsecase interface {
+	Get(c context.Context, username string) (pref *Preference, err error)
+	Update(c context.Context, username string, update PreferenceUpdate) (pref *Preference, err error)
+	For(c context.Context, username string) Preference
+}
+
+// PreferenceProvider tells the preferences of a user, the defaults for unset ones
+type PreferenceProvider interface {
+	For(c context.Context, username string) Preference
+}
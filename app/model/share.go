@@ -0,0 +1,62 @@
+package model
+
+import "context"
+
+// Share a public link to the live location of a user, optionally with the
+// track of the last TrackHours, times are unix seconds, RevokedAt is 0 while active
+type Share struct {
+	ID         int64  `json:"id"`
+	Token      string `json:"token"`
+	Username   string `json:"username"`
+	TrackHours int    `json:"track_hours"`
+	ExpiresAt  int64  `json:"expires_at"`
+	RevokedAt  int64  `json:"revoked_at,omitempty"`
+	Views      int64  `json:"views"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// SharedLocation what a share link shows, only the position, accuracy, time & battery of the user
+type SharedLocation struct {
+	Username  string          `json:"username"`
+	ExpiresAt int64           `json:"expires_at"`
+	Location  *SharedPosition `json:"location"`
+	Track     []SharedPoint   `json:"track"`
+}
+
+// SharedPosition the last location of a share link
+type SharedPosition struct {
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	Accuracy     int16   `json:"accuracy,omitempty"`
+	Timestamp    int64   `json:"timestamp"`
+	DateTime     string  `json:"date_time"`
+	DateTimeISO  string  `json:"date_time_iso"`
+	BatteryLevel string  `json:"battery_level,omitempty"`
+}
+
+// SharedPoint a point of the track of a share link
+type SharedPoint struct {
+	CreatedAt int64   `json:"created_at"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	Acc       int16   `json:"acc,omitempty"`
+	Batt      int8    `json:"batt,omitempty"`
+}
+
+// ShareRepository represent the shares repository contract
+type ShareRepository interface {
+	CreateShare(ctx context.Context, share *Share) error
+	GetShareByToken(ctx context.Context, token string) (Share, error)
+	GetUserShares(ctx context.Context, username string) ([]Share, error)
+	RevokeShare(ctx context.Context, id int64, username string, revokedAt int64) error
+	IncrementShareViews(ctx context.Context, id int64) error
+}
+
+// ShareUsecase represent the shares usecase contract
+type ShareUsecase interface {
+	Create(c context.Context, share *Share, expiresIn int64) (err error)
+	List(c context.Context, username string) (shares []Share, err error)
+	Revoke(c context.Context, id int64, username string) (err error)
+	View(c context.Context, token string) (err error)
+	Shared(c context.Context, token string) (location *SharedLocation, err error)
+}
@@ -0,0 +1,83 @@
+package model
+
+import "context"
+
+// WaypointsEveryone username of the waypoints of every user
+const WaypointsEveryone = "*"
+
+// Waypoint a circular region of Radius meters pushed to devices, of every device of the user
+// when Device is empty & of every user when Username is WaypointsEveryone. Tst is the time it was
+// created, devices know a waypoint by it, Rid its region id in the transitions of devices
+type Waypoint struct {
+	ID          int64   `json:"id"`
+	Username    string  `json:"username"`
+	Device      string  `json:"device"`
+	Description string  `json:"description"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Radius      int32   `json:"radius"`
+	Rid         string  `json:"rid"`
+	Tst         int64   `json:"tst"`
+	UpdatedAt   int64   `json:"updated_at"`
+}
+
+// WaypointUpdate changes of a waypoint, nil fields are kept
+type WaypointUpdate struct {
+	Device      *string  `json:"device"`
+	Description *string  `json:"description"`
+	Lat         *float64 `json:"lat"`
+	Lon         *float64 `json:"lon"`
+	Radius      *int32   `json:"radius"`
+}
+
+// WaypointRepository represent the waypoints repository contract
+type WaypointRepository interface {
+	CreateWaypoint(ctx context.Context, waypoint *Waypoint) error
+	GetWaypoint(ctx context.Context, id int64) (Waypoint, error)
+	GetWaypoints(ctx context.Context, username, device string) ([]Waypoint, error)
+	UpdateWaypoint(ctx context.Context, waypoint *Waypoint) error
+	DeleteWaypoint(ctx context.Context, id int64) error
+	GetWaypointSync(ctx context.Context, username, device string) (string, error)
+	SaveWaypointSync(ctx context.Context, username, device, hash string, syncedAt int64) error
+}
+
+// WaypointUsecase represent the waypoints usecase contract, caller is the user managing
+// the waypoints, admins manage the waypoints of every user
+type WaypointUsecase interface {
+	Create(c context.Context, caller string, waypoint *Waypoint) (err error)
+	List(c context.Context, caller, username, device string) (waypoints []Waypoint, err error)
+	Update(c context.Context, caller string, id int64, update WaypointUpdate) (waypoint *Waypoint, err error)
+	Delete(c context.Context, caller string, id int64) (err error)
+	Commands(c context.Context, username, device string, tst int64) []interface{}
+}
+
+// DeviceCommander commands for a device, returned in the response of its pings. tst is the time
+// of the message answered, devices resend a message until they get a response so the next message
+// with another tst confirms the commands of the previous response
+type DeviceCommander interface {
+	Commands(c context.Context, username, device string, tst int64) []interface{}
+}
+
+// WaypointsCommand the OwnTracks setWaypoints cmd message, devices add or replace the waypoints
+type WaypointsCommand struct {
+	Type      string          `json:"_type"`
+	Action    string          `json:"action"`
+	Waypoints WaypointsExport `json:"waypoints"`
+}
+
+// WaypointsExport the OwnTracks waypoints message
+type WaypointsExport struct {
+	Type      string           `json:"_type"`
+	Waypoints []DeviceWaypoint `json:"waypoints"`
+}
+
+// DeviceWaypoint the OwnTracks waypoint message
+type DeviceWaypoint struct {
+	Type string  `json:"_type"`
+	Desc string  `json:"desc"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+	Rad  int32   `json:"rad"`
+	Tst  int64   `json:"tst"`
+	Rid  string  `json:"rid"`
+}
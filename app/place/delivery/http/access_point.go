@@ -0,0 +1,84 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AccessPointRequest a wifi access point by its MAC address(bssid) & optional name(ssid)
+type AccessPointRequest struct {
+	BSSID string `json:"bssid"`
+	SSID  string `json:"ssid"`
+}
+
+// ListAccessPoints returns the access points of the caller(x-limit-u)
+func (h *PlaceHandler) ListAccessPoints(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	aps, err := h.PUseCase.AccessPoints(c.Request().Context(), username)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", aps))
+}
+
+// AddAccessPoint maps an access point to a place of the caller(x-limit-u)
+func (h *PlaceHandler) AddAccessPoint(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	placeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("invalid place id")))
+	}
+
+	var apReq AccessPointRequest
+	if err := c.Bind(&apReq); err != nil {
+		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+	}
+
+	ap := &model.AccessPoint{
+		Username: username,
+		PlaceID:  placeID,
+		BSSID:    apReq.BSSID,
+		SSID:     apReq.SSID,
+	}
+
+	if err := h.PUseCase.AddAccessPoint(c.Request().Context(), ap); err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	_, res := response.RespondSuccess("access point added", ap)
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+// DeleteAccessPoint removes an access point of the caller(x-limit-u)
+func (h *PlaceHandler) DeleteAccessPoint(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("invalid access point id")))
+	}
+
+	if err := h.PUseCase.DeleteAccessPoint(c.Request().Context(), id, username); err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("access point deleted", nil))
+}
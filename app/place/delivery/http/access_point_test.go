@@ -0,0 +1,73 @@
+package http_test
+
+import (
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	pHttp "ot-recorder/app/place/delivery/http"
+	"ot-recorder/app/response"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListAccessPoints(t *testing.T) {
+	mockUsecase := new(mocks.PlaceUsecase)
+	mockUsecase.On("AccessPoints", mock.Anything, "dev").Return([]model.AccessPoint{
+		{ID: 1, Username: "dev", PlaceID: 7, BSSID: "c0:00:00:00:00:00", SSID: "office"},
+	}, nil).Once()
+
+	handler := pHttp.PlaceHandler{PUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.GET, "/api/v1/places/access-points", "", "dev")
+	assert.NoError(t, handler.ListAccessPoints(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"place_id":7,"bssid":"c0:00:00:00:00:00","ssid":"office"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestAddAccessPoint(t *testing.T) {
+	mockUsecase := new(mocks.PlaceUsecase)
+	mockUsecase.On("AddAccessPoint", mock.Anything, mock.MatchedBy(func(ap *model.AccessPoint) bool {
+		return ap.Username == "dev" && ap.PlaceID == 7 && ap.BSSID == "c0:0:0:0:0:0" && ap.SSID == "office"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.AccessPoint).ID = 1
+	}).Return(nil).Once()
+
+	handler := pHttp.PlaceHandler{PUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.POST, "/api/v1/places/7/access-points", `{"bssid":"c0:0:0:0:0:0","ssid":"office"}`, "dev")
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	assert.NoError(t, handler.AddAccessPoint(c))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":1`)
+
+	c, rec = buildRequest(echo.POST, "/api/v1/places/office/access-points", `{"bssid":"c0:0:0:0:0:0"}`, "dev")
+	c.SetParamNames("id")
+	c.SetParamValues("office")
+	assert.NoError(t, handler.AddAccessPoint(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestDeleteAccessPoint(t *testing.T) {
+	mockUsecase := new(mocks.PlaceUsecase)
+	mockUsecase.On("DeleteAccessPoint", mock.Anything, int64(1), "dev").Return(nil).Once()
+	mockUsecase.On("DeleteAccessPoint", mock.Anything, int64(1), "mom").Return(response.ErrNotFound).Once()
+
+	handler := pHttp.PlaceHandler{PUseCase: mockUsecase}
+
+	for username, code := range map[string]int{"dev": http.StatusOK, "mom": http.StatusNotFound} {
+		c, rec := buildRequest(echo.DELETE, "/api/v1/places/access-points/1", "", username)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		assert.NoError(t, handler.DeleteAccessPoint(c))
+		assert.Equal(t, code, rec.Code)
+	}
+
+	mockUsecase.AssertExpectations(t)
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PlaceHandler represent the http handler for places
+type PlaceHandler struct {
+	PUseCase model.PlaceUsecase
+}
+
+// CreatePlaceRequest radius in meters, default 100
+type CreatePlaceRequest struct {
+	Name   string  `json:"name"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Radius float64 `json:"radius"`
+}
+
+func NewPlaceHandler(e *echo.Echo, us model.PlaceUsecase) {
+	handler := &PlaceHandler{
+		PUseCase: us,
+	}
+
+	v1 := e.Group("/api/v1")
+	v1.GET("/places", handler.List)
+	v1.POST("/places", handler.Create)
+	v1.POST("/places/refresh", handler.Refresh)
+	v1.PATCH("/places/:id", handler.Update)
+	v1.DELETE("/places/:id", handler.Delete)
+	v1.GET("/places/access-points", handler.ListAccessPoints)
+	v1.POST("/places/:id/access-points", handler.AddAccessPoint)
+	v1.DELETE("/places/access-points/:id", handler.DeleteAccessPoint)
+}
+
+// List returns the places of the caller(x-limit-u)
+func (h *PlaceHandler) List(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	places, err := h.PUseCase.List(c.Request().Context(), username)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", places))
+}
+
+// Create pins a named place of the caller(x-limit-u)
+func (h *PlaceHandler) Create(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	var placeReq CreatePlaceRequest
+	if err := c.Bind(&placeReq); err != nil {
+		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+	}
+
+	place := &model.Place{
+		Username: username,
+		Name:     placeReq.Name,
+		Lat:      placeReq.Lat,
+		Lon:      placeReq.Lon,
+		Radius:   placeReq.Radius,
+	}
+
+	if err := h.PUseCase.Create(c.Request().Context(), place); err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	_, res := response.RespondSuccess("place created", place)
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+// Refresh finds the places of the caller(x-limit-u) from the stays of the last days(default 30)
+func (h *PlaceHandler) Refresh(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	days := 0
+
+	if v := c.QueryParam("days"); v != "" {
+		var err error
+		if days, err = strconv.Atoi(v); err != nil {
+			return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("days must be a number")))
+		}
+	}
+
+	places, err := h.PUseCase.Refresh(c.Request().Context(), username, days)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("places refreshed", places))
+}
+
+// Update names, resizes or pins a place of the caller(x-limit-u)
+func (h *PlaceHandler) Update(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("invalid place id")))
+	}
+
+	var update model.PlaceUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+	}
+
+	place, err := h.PUseCase.Update(c.Request().Context(), id, username, update)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("place updated", place))
+}
+
+// Delete removes a place of the caller(x-limit-u)
+func (h *PlaceHandler) Delete(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("invalid place id")))
+	}
+
+	if err := h.PUseCase.Delete(c.Request().Context(), id, username); err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("place deleted", nil))
+}
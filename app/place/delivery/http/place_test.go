@@ -0,0 +1,127 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	pHttp "ot-recorder/app/place/delivery/http"
+	"ot-recorder/app/response"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func buildRequest(method, path, body, username string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	if username != "" {
+		req.Header.Set("x-limit-u", username)
+	}
+
+	rec := httptest.NewRecorder()
+
+	return echo.New().NewContext(req, rec), rec
+}
+
+func TestList(t *testing.T) {
+	mockUsecase := new(mocks.PlaceUsecase)
+	mockUsecase.On("List", mock.Anything, "dev").Return([]model.Place{
+		{ID: 8, Username: "dev", Kind: model.PlaceHome, Lat: 23.8103, Lon: 90.4125, Radius: 100},
+	}, nil).Once()
+
+	handler := pHttp.PlaceHandler{PUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.GET, "/api/v1/places", "", "dev")
+	assert.NoError(t, handler.List(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `{"id":8,"username":"dev","name":"","kind":"home"`)
+
+	c, rec = buildRequest(echo.GET, "/api/v1/places", "", "")
+	assert.NoError(t, handler.List(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCreate(t *testing.T) {
+	mockUsecase := new(mocks.PlaceUsecase)
+	mockUsecase.On("Create", mock.Anything, mock.MatchedBy(func(p *model.Place) bool {
+		return p.Username == "dev" && p.Name == "Gym" && p.Lat == 23.75 && p.Lon == 90.39 && p.Radius == 80
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.Place).ID = 7
+	}).Return(nil).Once()
+
+	handler := pHttp.PlaceHandler{PUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.POST, "/api/v1/places", `{"name":"Gym","lat":23.75,"lon":90.39,"radius":80}`, "dev")
+	assert.NoError(t, handler.Create(c))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":7`)
+
+	c, rec = buildRequest(echo.POST, "/api/v1/places", `{"name":`, "dev")
+	assert.NoError(t, handler.Create(c))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestRefresh(t *testing.T) {
+	mockUsecase := new(mocks.PlaceUsecase)
+	mockUsecase.On("Refresh", mock.Anything, "dev", 90).Return([]model.Place{}, nil).Once()
+
+	handler := pHttp.PlaceHandler{PUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.POST, "/api/v1/places/refresh?days=90", "", "dev")
+	assert.NoError(t, handler.Refresh(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	c, rec = buildRequest(echo.POST, "/api/v1/places/refresh?days=many", "", "dev")
+	assert.NoError(t, handler.Refresh(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestUpdate(t *testing.T) {
+	mockUsecase := new(mocks.PlaceUsecase)
+	mockUsecase.On("Update", mock.Anything, int64(8), "dev", mock.MatchedBy(func(u model.PlaceUpdate) bool {
+		return *u.Name == "Flat" && u.Radius == nil && u.Pinned == nil
+	})).Return(&model.Place{ID: 8, Name: "Flat", Pinned: true}, nil).Once()
+
+	handler := pHttp.PlaceHandler{PUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.PATCH, "/api/v1/places/8", `{"name":"Flat"}`, "dev")
+	c.SetParamNames("id")
+	c.SetParamValues("8")
+	assert.NoError(t, handler.Update(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"Flat"`)
+
+	c, rec = buildRequest(echo.PATCH, "/api/v1/places/home", `{"name":"Flat"}`, "dev")
+	c.SetParamNames("id")
+	c.SetParamValues("home")
+	assert.NoError(t, handler.Update(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestDelete(t *testing.T) {
+	mockUsecase := new(mocks.PlaceUsecase)
+	mockUsecase.On("Delete", mock.Anything, int64(7), "dev").Return(nil).Once()
+	mockUsecase.On("Delete", mock.Anything, int64(7), "mom").Return(response.ErrNotFound).Once()
+
+	handler := pHttp.PlaceHandler{PUseCase: mockUsecase}
+
+	for username, code := range map[string]int{"dev": http.StatusOK, "mom": http.StatusNotFound} {
+		c, rec := buildRequest(echo.DELETE, "/api/v1/places/7", "", username)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+
+		assert.NoError(t, handler.Delete(c))
+		assert.Equal(t, code, rec.Code)
+	}
+
+	mockUsecase.AssertExpectations(t)
+}
@@ -0,0 +1,314 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/metrics"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//nolint:gochecknoglobals
+var (
+	tracer         = otel.Tracer("ot-recorder/app/place/repository/mysql")
+	spanAttributes = trace.WithAttributes(semconv.DBSystemMySQL, semconv.DBSQLTableKey.String("places"))
+)
+
+type placeRepository struct {
+	db *sql.DB
+}
+
+func NewMysqlPlaceRepository(db *sql.DB) model.PlaceRepository {
+	return &placeRepository{
+		db: db,
+	}
+}
+
+const createPlace = `INSERT INTO places (
+  username, name, kind, lat, lon, radius, visits, duration, first_visit, last_visit, pinned, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func (r *placeRepository) CreatePlace(ctx context.Context, place *model.Place) error {
+	defer metrics.ObserveDBQuery("place", "CreatePlace", time.Now())
+
+	ctx, span := tracer.Start(ctx, "placeRepository.CreatePlace", spanAttributes)
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, createPlace, placeArgs(place)...)
+	if err != nil {
+		return err
+	}
+
+	place.ID, err = res.LastInsertId()
+
+	return err
+}
+
+const placeSelectColumns = `id, username, name, kind, lat, lon, radius, visits, duration, first_visit, last_visit,
+pinned, updated_at`
+
+const getUserPlaces = `SELECT ` + placeSelectColumns + ` FROM places WHERE username = ?
+ORDER BY pinned DESC, duration DESC, id`
+
+// GetUserPlaces returns the places of a user, pinned first then the most stayed
+func (r *placeRepository) GetUserPlaces(ctx context.Context, username string) ([]model.Place, error) {
+	defer metrics.ObserveDBQuery("place", "GetUserPlaces", time.Now())
+
+	ctx, span := tracer.Start(ctx, "placeRepository.GetUserPlaces", spanAttributes)
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, getUserPlaces, username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	places := []model.Place{}
+
+	for rows.Next() {
+		var p model.Place
+
+		err := rows.Scan(
+			&p.ID,
+			&p.Username,
+			&p.Name,
+			&p.Kind,
+			&p.Lat,
+			&p.Lon,
+			&p.Radius,
+			&p.Visits,
+			&p.Duration,
+			&p.FirstVisit,
+			&p.LastVisit,
+			&p.Pinned,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		places = append(places, p)
+	}
+
+	return places, rows.Err()
+}
+
+const updatePlace = `UPDATE places SET name = ?, kind = ?, lat = ?, lon = ?, radius = ?,
+visits = ?, duration = ?, first_visit = ?, last_visit = ?, pinned = ?, updated_at = ?
+WHERE id = ? AND username = ?`
+
+// UpdatePlace returns sql.ErrNoRows when the user has no place with the id
+func (r *placeRepository) UpdatePlace(ctx context.Context, place *model.Place) error {
+	defer metrics.ObserveDBQuery("place", "UpdatePlace", time.Now())
+
+	ctx, span := tracer.Start(ctx, "placeRepository.UpdatePlace", spanAttributes)
+	defer span.End()
+
+	return affectedOne(r.db.ExecContext(ctx, updatePlace, updateArgs(place)...))
+}
+
+const (
+	deletePlace             = `DELETE FROM places WHERE id = ? AND username = ?`
+	deletePlaceAccessPoints = `DELETE FROM access_points WHERE place_id = ? AND username = ?`
+)
+
+// DeletePlace removes the place with its access points, returns sql.ErrNoRows when the user
+// has no place with the id
+func (r *placeRepository) DeletePlace(ctx context.Context, id int64, username string) error {
+	defer metrics.ObserveDBQuery("place", "DeletePlace", time.Now())
+
+	ctx, span := tracer.Start(ctx, "placeRepository.DeletePlace", spanAttributes)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := affectedOne(tx.ExecContext(ctx, deletePlace, id, username)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, deletePlaceAccessPoints, id, username); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const (
+	deleteUnpinnedPlaces     = `DELETE FROM places WHERE username = ? AND pinned = ?`
+	deleteOrphanAccessPoints = `DELETE FROM access_points WHERE username = ?
+AND place_id NOT IN (SELECT id FROM places WHERE username = ?)`
+)
+
+// ReplacePlaces swaps the unpinned places of a user with places in one transaction,
+// places with an id are pinned ones and updated, the others are created. Access points
+// of removed places are removed too
+func (r *placeRepository) ReplacePlaces(ctx context.Context, username string, places []*model.Place) error {
+	defer metrics.ObserveDBQuery("place", "ReplacePlaces", time.Now())
+
+	ctx, span := tracer.Start(ctx, "placeRepository.ReplacePlaces", spanAttributes)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, deleteUnpinnedPlaces, username, false); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, deleteOrphanAccessPoints, username, username); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	insert, err := tx.PrepareContext(ctx, createPlace)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	defer insert.Close()
+
+	for _, p := range places {
+		if p.ID != 0 {
+			_, err = tx.ExecContext(ctx, updatePlace, updateArgs(p)...)
+		} else {
+			var res sql.Result
+			if res, err = insert.ExecContext(ctx, placeArgs(p)...); err == nil {
+				p.ID, err = res.LastInsertId()
+			}
+		}
+
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const (
+	deleteAccessPointByBSSID = `DELETE FROM access_points WHERE username = ? AND bssid = ?`
+	createAccessPoint        = `INSERT INTO access_points (username, place_id, bssid, ssid, created_at)
+VALUES (?, ?, ?, ?, ?)`
+)
+
+// SaveAccessPoint stores the access point, replacing the one of the user with the same bssid
+func (r *placeRepository) SaveAccessPoint(ctx context.Context, ap *model.AccessPoint) error {
+	defer metrics.ObserveDBQuery("place", "SaveAccessPoint", time.Now())
+
+	ctx, span := tracer.Start(ctx, "placeRepository.SaveAccessPoint", spanAttributes)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, deleteAccessPointByBSSID, ap.Username, ap.BSSID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, createAccessPoint, apArgs(ap)...)
+	if err == nil {
+		ap.ID, err = res.LastInsertId()
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const getUserAccessPoints = `SELECT id, username, place_id, bssid, ssid, created_at FROM access_points
+WHERE username = ? ORDER BY id`
+
+func (r *placeRepository) GetUserAccessPoints(ctx context.Context, username string) ([]model.AccessPoint, error) {
+	defer metrics.ObserveDBQuery("place", "GetUserAccessPoints", time.Now())
+
+	ctx, span := tracer.Start(ctx, "placeRepository.GetUserAccessPoints", spanAttributes)
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, getUserAccessPoints, username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	aps := []model.AccessPoint{}
+
+	for rows.Next() {
+		var ap model.AccessPoint
+		if err := rows.Scan(&ap.ID, &ap.Username, &ap.PlaceID, &ap.BSSID, &ap.SSID, &ap.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		aps = append(aps, ap)
+	}
+
+	return aps, rows.Err()
+}
+
+const deleteAccessPoint = `DELETE FROM access_points WHERE id = ? AND username = ?`
+
+// DeleteAccessPoint returns sql.ErrNoRows when the user has no access point with the id
+func (r *placeRepository) DeleteAccessPoint(ctx context.Context, id int64, username string) error {
+	defer metrics.ObserveDBQuery("place", "DeleteAccessPoint", time.Now())
+
+	ctx, span := tracer.Start(ctx, "placeRepository.DeleteAccessPoint", spanAttributes)
+	defer span.End()
+
+	return affectedOne(r.db.ExecContext(ctx, deleteAccessPoint, id, username))
+}
+
+func placeArgs(p *model.Place) []interface{} {
+	return []interface{}{
+		p.Username, p.Name, p.Kind, p.Lat, p.Lon, p.Radius, p.Visits, p.Duration, p.FirstVisit, p.LastVisit,
+		p.Pinned, p.UpdatedAt,
+	}
+}
+
+func updateArgs(p *model.Place) []interface{} {
+	return []interface{}{
+		p.Name, p.Kind, p.Lat, p.Lon, p.Radius, p.Visits, p.Duration, p.FirstVisit, p.LastVisit, p.Pinned,
+		p.UpdatedAt, p.ID, p.Username,
+	}
+}
+
+func apArgs(ap *model.AccessPoint) []interface{} {
+	return []interface{}{ap.Username, ap.PlaceID, ap.BSSID, ap.SSID, ap.CreatedAt}
+}
+
+func affectedOne(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
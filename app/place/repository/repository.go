@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"database/sql"
+	"ot-recorder/app/model"
+	"ot-recorder/app/place/repository/mysql"
+	"ot-recorder/app/place/repository/pgsql"
+	"ot-recorder/app/place/repository/sqlite"
+)
+
+// NewPlaceRepository returns the place repository for the given database type
+func NewPlaceRepository(dbType string, dbClient *sql.DB) model.PlaceRepository {
+	switch dbType {
+	case "postgres":
+		return pgsql.NewPgsqlPlaceRepository(dbClient)
+	case "mysql":
+		return mysql.NewMysqlPlaceRepository(dbClient)
+	default:
+		return sqlite.NewSqlitePlaceRepository(dbClient)
+	}
+}
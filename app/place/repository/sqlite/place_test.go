@@ -0,0 +1,181 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"ot-recorder/app/model"
+	placeRepo "ot-recorder/app/place/repository/sqlite"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:gochecknoglobals
+var placeColumns = []string{
+	"id", "username", "name", "kind", "lat", "lon", "radius", "visits", "duration", "first_visit", "last_visit",
+	"pinned", "updated_at",
+}
+
+func TestCreatePlace(t *testing.T) {
+	p := &model.Place{
+		Username:  "dev",
+		Name:      "Gym",
+		Lat:       23.8103,
+		Lon:       90.4125,
+		Radius:    80,
+		Pinned:    true,
+		UpdatedAt: time.Now().Unix(),
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO places").
+		WithArgs("dev", "Gym", "", 23.8103, 90.4125, 80.0, 0, int64(0), int64(0), int64(0), true, p.UpdatedAt).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	pr := placeRepo.NewSqlitePlaceRepository(db)
+	assert.NoError(t, pr.CreatePlace(context.TODO(), p))
+	assert.Equal(t, int64(7), p.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserPlaces(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows(placeColumns).
+		AddRow(7, "dev", "Gym", "", 23.8103, 90.4125, 80, 3, 7200, 100, 500, true, 1000).
+		AddRow(8, "dev", "", "home", 23.81, 90.41, 100, 20, 360000, 50, 900, false, 1000)
+	mock.ExpectQuery("SELECT (.+) FROM places WHERE username").WithArgs("dev").WillReturnRows(rows)
+
+	pr := placeRepo.NewSqlitePlaceRepository(db)
+	places, err := pr.GetUserPlaces(context.TODO(), "dev")
+	assert.NoError(t, err)
+	assert.Len(t, places, 2)
+	assert.Equal(t, model.Place{
+		ID:         7,
+		Username:   "dev",
+		Name:       "Gym",
+		Lat:        23.8103,
+		Lon:        90.4125,
+		Radius:     80,
+		Visits:     3,
+		Duration:   7200,
+		FirstVisit: 100,
+		LastVisit:  500,
+		Pinned:     true,
+		UpdatedAt:  1000,
+	}, places[0])
+	assert.Equal(t, "home", places[1].Kind)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateAndDeletePlace(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	p := &model.Place{ID: 7, Username: "dev", Name: "Gym", Radius: 80, Pinned: true, UpdatedAt: 1000}
+
+	mock.ExpectExec("UPDATE places SET name").
+		WithArgs("Gym", "", 0.0, 0.0, 80.0, 0, int64(0), int64(0), int64(0), true, int64(1000), int64(7), "dev").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM places WHERE id").WithArgs(int64(7), "mom").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM places WHERE id").WithArgs(int64(7), "dev").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM access_points WHERE place_id").WithArgs(int64(7), "dev").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	pr := placeRepo.NewSqlitePlaceRepository(db)
+	assert.NoError(t, pr.UpdatePlace(context.TODO(), p))
+	assert.ErrorIs(t, pr.DeletePlace(context.TODO(), 7, "mom"), sql.ErrNoRows)
+	assert.NoError(t, pr.DeletePlace(context.TODO(), 7, "dev"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReplacePlaces(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	pinned := &model.Place{ID: 7, Username: "dev", Name: "Gym", Kind: "home", Radius: 80, Pinned: true, UpdatedAt: 1000}
+	work := &model.Place{
+		Username:   "dev",
+		Kind:       "work",
+		Lat:        23.75,
+		Lon:        90.39,
+		Radius:     120,
+		Visits:     4,
+		Duration:   36000,
+		FirstVisit: 100,
+		LastVisit:  900,
+		UpdatedAt:  1000,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM places WHERE username").WithArgs("dev", false).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM access_points WHERE username").WithArgs("dev", "dev").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	insert := mock.ExpectPrepare("INSERT INTO places")
+	mock.ExpectExec("UPDATE places SET name").WithArgs("Gym", "home", 0.0, 0.0, 80.0, 0, int64(0), int64(0), int64(0),
+		true, int64(1000), int64(7), "dev").WillReturnResult(sqlmock.NewResult(0, 1))
+	insert.ExpectExec().WithArgs("dev", "", "work", 23.75, 90.39, 120.0, 4, int64(36000), int64(100), int64(900),
+		false, int64(1000)).WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectCommit()
+
+	pr := placeRepo.NewSqlitePlaceRepository(db)
+	assert.NoError(t, pr.ReplacePlaces(context.TODO(), "dev", []*model.Place{pinned, work}))
+	assert.Equal(t, int64(9), work.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccessPoints(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ap := &model.AccessPoint{Username: "dev", PlaceID: 7, BSSID: "c0:00:00:00:00:01", SSID: "office", CreatedAt: 1000}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM access_points WHERE username").WithArgs("dev", "c0:00:00:00:00:01").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO access_points").WithArgs("dev", int64(7), "c0:00:00:00:00:01", "office", int64(1000)).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	rows := sqlmock.NewRows([]string{"id", "username", "place_id", "bssid", "ssid", "created_at"}).
+		AddRow(3, "dev", 7, "c0:00:00:00:00:01", "office", 1000)
+	mock.ExpectQuery("SELECT id, username, place_id, bssid, ssid, created_at FROM access_points").
+		WithArgs("dev").WillReturnRows(rows)
+	mock.ExpectExec("DELETE FROM access_points WHERE id").WithArgs(int64(4), "dev").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	pr := placeRepo.NewSqlitePlaceRepository(db)
+	assert.NoError(t, pr.SaveAccessPoint(context.TODO(), ap))
+	assert.Equal(t, int64(3), ap.ID)
+
+	aps, err := pr.GetUserAccessPoints(context.TODO(), "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.AccessPoint{*ap}, aps)
+
+	assert.ErrorIs(t, pr.DeleteAccessPoint(context.TODO(), 4, "dev"), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
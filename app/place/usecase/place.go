@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"ot-recorder/app/geo"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	stayRadius      = 100 // meters
+	minStayDuration = 300 // seconds
+	placeRadius     = 100 // meters, stays of a place are within it of each other
+	minPlaceVisits  = 2
+	minPlaceRadius  = 10   // meters
+	maxPlaceRadius  = 1000 // meters
+	maxNameLength   = 50
+
+	defaultPlaceDays = 30
+	maxPlaceDays     = 365
+	// a refresh loads the locations a day at a time, at most this many per day
+	placeLocationLimit = 500000
+
+	// seconds a place must be stayed at in a time window to be inferred as home or work
+	minKindDuration = 4 * 60 * 60
+	nightStart      = 22
+	nightEnd        = 6
+	workStart       = 9
+	workEnd         = 17
+
+	placeCacheTTL = 5 * time.Minute
+	daySeconds    = 24 * 60 * 60
+)
+
+var tracer = otel.Tracer("ot-recorder/app/place/usecase") //nolint:gochecknoglobals
+
+//nolint:gochecknoglobals
+var errInternal = response.WrapError(
+	errors.New("internal server error, please report to admin"),
+	http.StatusInternalServerError,
+)
+
+type cachedPlaces struct {
+	places   []model.Place
+	aps      []model.AccessPoint
+	loadedAt time.Time
+}
+
+type placeUsecase struct {
+	repo           model.PlaceRepository
+	lRepo          model.LocationRepository
+	prefs          model.PreferenceProvider
+	contextTimeout time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedPlaces
+}
+
+// NewPlaceUsecase prefs tells the time zone home & work hours are inferred in, nil uses the one of the server
+func NewPlaceUsecase(
+	repo model.PlaceRepository,
+	lRepo model.LocationRepository,
+	prefs model.PreferenceProvider,
+	timeout time.Duration,
+) model.PlaceUsecase {
+	return &placeUsecase{
+		repo:           repo,
+		lRepo:          lRepo,
+		prefs:          prefs,
+		contextTimeout: timeout,
+		cache:          map[string]cachedPlaces{},
+	}
+}
+
+// Create stores a place named & pinned by its user
+func (u *placeUsecase) Create(c context.Context, place *model.Place) (err error) {
+	c, span := tracer.Start(c, "placeUsecase.Create")
+	defer func() { tracing.End(span, err) }()
+
+	if place.Radius == 0 {
+		place.Radius = placeRadius
+	}
+
+	if err = validatePlace(place); err != nil {
+		return err
+	}
+
+	if place.Name == "" {
+		return response.WrapError(errors.New("name is required"), http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	place.Kind = ""
+	place.Visits = 0
+	place.Duration = 0
+	place.FirstVisit = 0
+	place.LastVisit = 0
+	place.Pinned = true
+	place.UpdatedAt = time.Now().Unix()
+
+	if err = u.repo.CreatePlace(ctx, place); err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	u.invalidate(place.Username)
+
+	return nil
+}
+
+// List returns the places of the user, pinned first then the most stayed
+func (u *placeUsecase) List(c context.Context, username string) (places []model.Place, err error) {
+	c, span := tracer.Start(c, "placeUsecase.List")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	places, err = u.repo.GetUserPlaces(ctx, username)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	return places, nil
+}
+
+// Update names, resizes or pins a place of the user, naming a place pins it
+// unless pinned is given
+func (u *placeUsecase) Update(
+	c context.Context,
+	id int64,
+	username string,
+	update model.PlaceUpdate,
+) (place *model.Place, err error) {
+	c, span := tracer.Start(c, "placeUsecase.Update")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	places, err := u.repo.GetUserPlaces(ctx, username)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	for i := range places {
+		if places[i].ID == id {
+			place = &places[i]
+			break
+		}
+	}
+
+	if place == nil {
+		return nil, response.ErrNotFound
+	}
+
+	if update.Name != nil {
+		place.Name = *update.Name
+		place.Pinned = place.Pinned || place.Name != ""
+	}
+
+	if update.Radius != nil {
+		place.Radius = *update.Radius
+	}
+
+	if update.Pinned != nil {
+		place.Pinned = *update.Pinned
+	}
+
+	if err = validatePlace(place); err != nil {
+		return nil, err
+	}
+
+	place.UpdatedAt = time.Now().Unix()
+
+	if err = u.repo.UpdatePlace(ctx, place); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, response.ErrNotFound
+		}
+
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	u.invalidate(username)
+
+	return place, nil
+}
+
+// Delete removes a place of the user with its access points, a deleted inferred place comes back on refresh
+func (u *placeUsecase) Delete(c context.Context, id int64, username string) (err error) {
+	c, span := tracer.Start(c, "placeUsecase.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if err = u.repo.DeletePlace(ctx, id, username); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response.ErrNotFound
+		}
+
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	u.invalidate(username)
+
+	return nil
+}
+
+// PlaceAt returns the label of the place of the user the coordinate is inside,
+// pinned places first then the nearest, empty when there is none
+func (u *placeUsecase) PlaceAt(c context.Context, username string, lat, lon float64) string {
+	entry, err := u.cached(c, username)
+	if err != nil {
+		logger.FromContext(c).Errorln(err)
+
+		return ""
+	}
+
+	places := entry.places
+
+	var (
+		label   string
+		pinned  bool
+		nearest = math.Inf(1)
+	)
+
+	for i := range places {
+		p := &places[i]
+		if p.Label() == "" {
+			continue
+		}
+
+		d := geo.Distance(lat, lon, p.Lat, p.Lon)
+		if d > p.Radius || (pinned && !p.Pinned) || (pinned == p.Pinned && d >= nearest) {
+			continue
+		}
+
+		label, pinned, nearest = p.Label(), p.Pinned, d
+	}
+
+	return label
+}
+
+// cached returns the places & access points of the user, loaded at most placeCacheTTL ago
+func (u *placeUsecase) cached(c context.Context, username string) (cachedPlaces, error) {
+	u.mu.Lock()
+	entry, ok := u.cache[username]
+	u.mu.Unlock()
+
+	if ok && time.Since(entry.loadedAt) < placeCacheTTL {
+		return entry, nil
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	places, err := u.repo.GetUserPlaces(ctx, username)
+	if err != nil {
+		return cachedPlaces{}, err
+	}
+
+	aps, err := u.repo.GetUserAccessPoints(ctx, username)
+	if err != nil {
+		return cachedPlaces{}, err
+	}
+
+	entry = cachedPlaces{places: places, aps: aps, loadedAt: time.Now()}
+
+	u.mu.Lock()
+	u.cache[username] = entry
+	u.mu.Unlock()
+
+	return entry, nil
+}
+
+func (u *placeUsecase) invalidate(username string) {
+	u.mu.Lock()
+	delete(u.cache, username)
+	u.mu.Unlock()
+}
+
+func validatePlace(place *model.Place) error {
+	if len(place.Name) > maxNameLength {
+		return response.WrapError(errors.New("name must be at most 50 characters"), http.StatusBadRequest)
+	}
+
+	if place.Lat < -90 || place.Lat > 90 || place.Lon < -180 || place.Lon > 180 {
+		return response.WrapError(errors.New("lat or lon is out of range"), http.StatusBadRequest)
+	}
+
+	if place.Radius < minPlaceRadius || place.Radius > maxPlaceRadius {
+		return response.WrapError(errors.New("radius must be between 10 and 1000 meters"), http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/place/usecase"
+	"ot-recorder/app/response"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockPlaceRepo := new(mocks.PlaceRepository)
+		mockPlaceRepo.On("CreatePlace", mock.Anything, mock.AnythingOfType("*model.Place")).Return(nil).Once()
+
+		u := usecase.NewPlaceUsecase(mockPlaceRepo, new(mocks.LocationRepository), nil, time.Second*2)
+
+		place := &model.Place{Username: "dev", Name: "Gym", Lat: 23.8103, Lon: 90.4125}
+		assert.NoError(t, u.Create(context.TODO(), place))
+		assert.True(t, place.Pinned)
+		assert.Equal(t, 100.0, place.Radius)
+		mockPlaceRepo.AssertExpectations(t)
+	})
+
+	for name, place := range map[string]model.Place{
+		"no name":          {Lat: 23.8103, Lon: 90.4125},
+		"long name":        {Name: "a very long name of a place nobody would ever type in", Lat: 23.8, Lon: 90.4},
+		"lat out of range": {Name: "Gym", Lat: 91, Lon: 90.4125},
+		"radius too big":   {Name: "Gym", Lat: 23.8103, Lon: 90.4125, Radius: 5000},
+	} {
+		t.Run(name, func(t *testing.T) {
+			u := usecase.NewPlaceUsecase(new(mocks.PlaceRepository), new(mocks.LocationRepository), nil, time.Second*2)
+
+			place := place
+			code, _ := response.RespondError(u.Create(context.TODO(), &place))
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	places := []model.Place{{ID: 8, Username: "dev", Kind: model.PlaceHome, Lat: 23.81, Lon: 90.41, Radius: 100}}
+
+	mockPlaceRepo := new(mocks.PlaceRepository)
+	mockPlaceRepo.On("GetUserPlaces", mock.Anything, "dev").Return(places, nil).Twice()
+	mockPlaceRepo.On("UpdatePlace", mock.Anything, mock.MatchedBy(func(p *model.Place) bool {
+		return p.ID == 8 && p.Name == "Flat" && p.Pinned && p.Radius == 150
+	})).Return(nil).Once()
+
+	u := usecase.NewPlaceUsecase(mockPlaceRepo, new(mocks.LocationRepository), nil, time.Second*2)
+
+	name, radius := "Flat", 150.0
+	place, err := u.Update(context.TODO(), 8, "dev", model.PlaceUpdate{Name: &name, Radius: &radius})
+	assert.NoError(t, err)
+	assert.Equal(t, "Flat", place.Label())
+
+	_, err = u.Update(context.TODO(), 9, "dev", model.PlaceUpdate{Name: &name})
+	assert.ErrorIs(t, err, response.ErrNotFound)
+	mockPlaceRepo.AssertExpectations(t)
+}
+
+func TestDelete(t *testing.T) {
+	mockPlaceRepo := new(mocks.PlaceRepository)
+	mockPlaceRepo.On("DeletePlace", mock.Anything, int64(7), "dev").Return(nil).Once()
+	mockPlaceRepo.On("DeletePlace", mock.Anything, int64(7), "mom").Return(sql.ErrNoRows).Once()
+
+	u := usecase.NewPlaceUsecase(mockPlaceRepo, new(mocks.LocationRepository), nil, time.Second*2)
+
+	assert.NoError(t, u.Delete(context.TODO(), 7, "dev"))
+	assert.ErrorIs(t, u.Delete(context.TODO(), 7, "mom"), response.ErrNotFound)
+	mockPlaceRepo.AssertExpectations(t)
+}
+
+func TestPlaceAt(t *testing.T) {
+	mockPlaceRepo := new(mocks.PlaceRepository)
+	// loaded once, then cached
+	mockPlaceRepo.On("GetUserPlaces", mock.Anything, "dev").Return([]model.Place{
+		{ID: 7, Name: "Gym", Lat: 23.7500, Lon: 90.3900, Radius: 100, Pinned: true},
+		{ID: 8, Kind: model.PlaceHome, Lat: 23.8103, Lon: 90.4125, Radius: 100},
+		{ID: 9, Name: "Cafe", Lat: 23.8108, Lon: 90.4125, Radius: 100, Pinned: true},
+		{ID: 10, Lat: 23.9000, Lon: 90.5000, Radius: 100},
+	}, nil).Once()
+	mockPlaceRepo.On("GetUserAccessPoints", mock.Anything, "dev").Return([]model.AccessPoint{}, nil).Once()
+	mockPlaceRepo.On("GetUserPlaces", mock.Anything, "mom").Return(nil, errors.New("timeout")).Once()
+
+	u := usecase.NewPlaceUsecase(mockPlaceRepo, new(mocks.LocationRepository), nil, time.Second*2)
+
+	assert.Equal(t, "Gym", u.PlaceAt(context.TODO(), "dev", 23.7501, 90.3901))
+	// pinned places go first
+	assert.Equal(t, "Cafe", u.PlaceAt(context.TODO(), "dev", 23.8103, 90.4125))
+	assert.Equal(t, "Home", u.PlaceAt(context.TODO(), "dev", 23.8095, 90.4125))
+	// places without a label are not told
+	assert.Equal(t, "", u.PlaceAt(context.TODO(), "dev", 23.9000, 90.5000))
+	assert.Equal(t, "", u.PlaceAt(context.TODO(), "mom", 23.8103, 90.4125))
+	mockPlaceRepo.AssertExpectations(t)
+}
+
+func TestRefresh(t *testing.T) {
+	var locations []model.Location
+
+	// monday to wednesday of last week in the time zone of the user, nights at home and work hours at work
+	honolulu, err := time.LoadLocation("Pacific/Honolulu")
+	require.NoError(t, err)
+
+	today := time.Now().In(honolulu)
+	monday := time.Date(today.Year(), today.Month(), today.Day()-int(today.Weekday())-6, 0, 0, 0, 0, honolulu)
+
+	for day := 0; day < 3; day++ {
+		start := monday.AddDate(0, 0, day)
+		for m := 0; m <= 7*60; m += 10 {
+			locations = append(locations, model.Location{
+				Device: "phone", CreatedAt: start.Add(time.Duration(m) * time.Minute).Unix(), Lat: 23.8103, Lon: 90.4125,
+			})
+		}
+
+		for m := 9 * 60; m <= 17*60; m += 10 {
+			locations = append(locations, model.Location{
+				Device: "phone", CreatedAt: start.Add(time.Duration(m) * time.Minute).Unix(), Lat: 23.7500, Lon: 90.3900,
+			})
+		}
+	}
+
+	gym := model.Place{ID: 7, Username: "dev", Name: "Gym", Kind: model.PlaceHome, Lat: 23.9, Lon: 90.5, Radius: 100,
+		Pinned: true}
+
+	// locations are loaded a day at a time
+	var pages int
+
+	mockLocationRepo := new(mocks.LocationRepository)
+	mockLocationRepo.On("GetLocations", mock.Anything, mock.MatchedBy(func(q model.LocationQuery) bool {
+		return q.Username == "dev" && q.To-q.From < 24*60*60
+	})).Return(func(_ context.Context, q model.LocationQuery) []model.Location {
+		pages++
+
+		var page []model.Location
+		for _, l := range locations {
+			if l.CreatedAt >= q.From && l.CreatedAt <= q.To {
+				page = append(page, l)
+			}
+		}
+
+		return page
+	}, nil)
+
+	mockPrefs := new(mocks.PreferenceProvider)
+	mockPrefs.On("For", mock.Anything, "dev").Return(model.Preference{Username: "dev", TimeZone: "Pacific/Honolulu"})
+
+	mockPlaceRepo := new(mocks.PlaceRepository)
+	mockPlaceRepo.On("GetUserPlaces", mock.Anything, "dev").Return([]model.Place{gym}, nil).Once()
+	mockPlaceRepo.On("ReplacePlaces", mock.Anything, "dev", mock.MatchedBy(func(places []*model.Place) bool {
+		if len(places) != 3 {
+			return false
+		}
+
+		pinned, home, work := places[0], places[1], places[2]
+
+		return pinned.ID == 7 && pinned.Name == "Gym" && pinned.Kind == "" &&
+			home.Kind == model.PlaceHome && home.Visits == 3 && home.Duration == 3*7*60*60 &&
+			home.Lat > 23.81 && home.Lat < 23.811 && !home.Pinned &&
+			work.Kind == model.PlaceWork && work.Visits == 3 && work.FirstVisit == monday.Add(9*time.Hour).Unix()
+	})).Return(nil).Once()
+	mockPlaceRepo.On("GetUserPlaces", mock.Anything, "dev").Return([]model.Place{gym}, nil).Once()
+
+	u := usecase.NewPlaceUsecase(mockPlaceRepo, mockLocationRepo, mockPrefs, time.Second*2)
+
+	places, err := u.Refresh(context.TODO(), "dev", 0)
+	assert.NoError(t, err)
+	assert.Len(t, places, 1)
+	assert.Equal(t, 31, pages)
+
+	_, err = u.Refresh(context.TODO(), "dev", 400)
+	code, _ := response.RespondError(err)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	mockLocationRepo.AssertExpectations(t)
+	mockPlaceRepo.AssertExpectations(t)
+}
+
+func TestAccessPoints(t *testing.T) {
+	office := model.Place{ID: 7, Username: "dev", Name: "Office", Lat: 23.75, Lon: 90.39, Radius: 50}
+
+	mockPlaceRepo := new(mocks.PlaceRepository)
+	mockPlaceRepo.On("GetUserPlaces", mock.Anything, "dev").Return([]model.Place{office}, nil).Times(3)
+	mockPlaceRepo.On("UpdatePlace", mock.Anything, mock.MatchedBy(func(p *model.Place) bool {
+		return p.ID == 7 && p.Pinned
+	})).Return(nil).Once()
+	mockPlaceRepo.On("SaveAccessPoint", mock.Anything, mock.MatchedBy(func(ap *model.AccessPoint) bool {
+		return ap.BSSID == "c0:00:00:0a:00:01" && ap.PlaceID == 7 && ap.CreatedAt > 0
+	})).Return(nil).Once()
+	mockPlaceRepo.On("GetUserAccessPoints", mock.Anything, "dev").Return([]model.AccessPoint{
+		{ID: 1, Username: "dev", PlaceID: 7, BSSID: "c0:00:00:0a:00:01", SSID: "office"},
+	}, nil).Once()
+	mockPlaceRepo.On("DeleteAccessPoint", mock.Anything, int64(1), "dev").Return(nil).Once()
+	mockPlaceRepo.On("DeleteAccessPoint", mock.Anything, int64(1), "mom").Return(sql.ErrNoRows).Once()
+
+	u := usecase.NewPlaceUsecase(mockPlaceRepo, new(mocks.LocationRepository), nil, time.Second*2)
+
+	// leading zeros left out by some apps
+	assert.NoError(t, u.AddAccessPoint(context.TODO(), &model.AccessPoint{
+		Username: "dev", PlaceID: 7, BSSID: "C0:0:0:A:0:1", SSID: "office",
+	}))
+
+	err := u.AddAccessPoint(context.TODO(), &model.AccessPoint{Username: "dev", PlaceID: 8, BSSID: "c0:00:00:0a:00:02"})
+	assert.ErrorIs(t, err, response.ErrNotFound)
+
+	err = u.AddAccessPoint(context.TODO(), &model.AccessPoint{Username: "dev", PlaceID: 7, BSSID: "office"})
+	code, _ := response.RespondError(err)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	place := u.WifiPlace(context.TODO(), "dev", "c0:0:0:a:0:1")
+	assert.Equal(t, "Office", place.Label())
+	assert.Nil(t, u.WifiPlace(context.TODO(), "dev", "c0:00:00:0a:00:02"))
+	assert.Nil(t, u.WifiPlace(context.TODO(), "dev", ""))
+
+	assert.NoError(t, u.DeleteAccessPoint(context.TODO(), 1, "dev"))
+	assert.ErrorIs(t, u.DeleteAccessPoint(context.TODO(), 1, "mom"), response.ErrNotFound)
+	mockPlaceRepo.AssertExpectations(t)
+}
+
+func TestNormalizeBSSID(t *testing.T) {
+	for bssid, want := range map[string]string{
+		"c0:ff:ee:00:00:01":  "c0:ff:ee:00:00:01",
+		"C0-FF-EE-0-0-1":     "c0:ff:ee:00:00:01",
+		" c0:0:0:0:0:0 ":     "c0:00:00:00:00:00",
+		"c0:ff:ee:00:00":     "",
+		"c0:ff:ee:00:00:100": "",
+		"c0:ff:ee:00:00:zz":  "",
+		"":                   "",
+	} {
+		got, ok := usecase.NormalizeBSSID(bssid)
+		assert.Equal(t, want, got, bssid)
+		assert.Equal(t, want != "", ok, bssid)
+	}
+}
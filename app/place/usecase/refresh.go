@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"ot-recorder/app/geo"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+	"time"
+)
+
+// stay a device staying at a place between From & To
+type stay struct {
+	lat, lon float64
+	from, to int64
+}
+
+// visits stays at one place with the seconds spent at night & in weekday work hours
+type visits struct {
+	stays []stay
+	night int64
+	work  int64
+}
+
+// Refresh clusters the stays of the user in the last days into places and infers home & work,
+// unpinned places are replaced, pinned ones keep their name and get the stays inside them
+func (u *placeUsecase) Refresh(c context.Context, username string, days int) (places []model.Place, err error) {
+	c, span := tracer.Start(c, "placeUsecase.Refresh")
+	defer func() { tracing.End(span, err) }()
+
+	if days == 0 {
+		days = defaultPlaceDays
+	}
+
+	if days < 0 || days > maxPlaceDays {
+		return nil, response.WrapError(errors.New("days must be between 1 and 365"), http.StatusBadRequest)
+	}
+
+	now := time.Now().Unix()
+
+	tracks, err := u.tracks(c, username, now-int64(days)*daySeconds, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	existing, err := u.repo.GetUserPlaces(ctx, username)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	loc, err := time.LoadLocation(u.timeZone(ctx, username))
+	if err != nil {
+		loc = time.UTC
+	}
+
+	computed := buildPlaces(username, findStays(tracks), existing, loc, now)
+
+	if err = u.repo.ReplacePlaces(ctx, username, computed); err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	u.invalidate(username)
+
+	places, err = u.repo.GetUserPlaces(ctx, username)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	return places, nil
+}
+
+// tracks loads the locations of the user between from & to a day at a time, each day within
+// the context timeout, and returns the time ordered points of every device
+func (u *placeUsecase) tracks(c context.Context, username string, from, to int64) (map[string][]geo.Point, error) {
+	tracks := map[string][]geo.Point{}
+
+	for start := from; start <= to; start += daySeconds {
+		end := start + daySeconds - 1
+		if end > to {
+			end = to
+		}
+
+		ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+		locations, err := u.lRepo.GetLocations(ctx, model.LocationQuery{
+			Username: username,
+			From:     start,
+			To:       end,
+			Limit:    placeLocationLimit,
+		})
+
+		cancel()
+
+		if err != nil {
+			logger.FromContext(c).Errorln(err)
+
+			return nil, errInternal
+		}
+
+		if len(locations) == placeLocationLimit {
+			logger.FromContext(c).Warnf("places of %s: only the first %d locations from %d are used",
+				username, placeLocationLimit, start)
+		}
+
+		for i := range locations {
+			l := &locations[i]
+			tracks[l.Device] = append(tracks[l.Device], geo.Point{Lat: l.Lat, Lon: l.Lon, Tst: l.CreatedAt})
+		}
+	}
+
+	return tracks, nil
+}
+
+// timeZone returns the time zone of the user, the one of the server without preferences
+func (u *placeUsecase) timeZone(ctx context.Context, username string) string {
+	if u.prefs == nil {
+		return config.Get().App.TimeZone
+	}
+
+	return u.prefs.For(ctx, username).TimeZone
+}
+
+// findStays returns the stays in the time ordered points of every device
+func findStays(tracks map[string][]geo.Point) []stay {
+	var stays []stay
+
+	for _, points := range tracks {
+		for _, s := range geo.Stays(points, stayRadius, minStayDuration) {
+			lat, lon := geo.Centroid(points[s.Start : s.End+1])
+			stays = append(stays, stay{lat: lat, lon: lon, from: points[s.Start].Tst, to: points[s.End].Tst})
+		}
+	}
+
+	return stays
+}
+
+// buildPlaces assigns stays inside pinned places to them, clusters the others into new places
+// and marks the most stayed at night as home and the most stayed in work hours as work
+func buildPlaces(username string, stays []stay, existing []model.Place, loc *time.Location, now int64) []*model.Place {
+	var (
+		places  []*model.Place
+		visited []visits
+		free    []geo.Point
+		byPoint []stay
+	)
+
+	pinned := map[int]int{} // index in existing to index in places
+
+	for i := range existing {
+		if existing[i].Pinned {
+			pinned[i] = len(places)
+			places = append(places, &existing[i])
+			visited = append(visited, visits{})
+		}
+	}
+
+	for _, s := range stays {
+		if i, ok := pinnedAt(existing, s); ok {
+			visited[pinned[i]].add(s, loc)
+			continue
+		}
+
+		free = append(free, geo.Point{Lat: s.lat, Lon: s.lon, Tst: s.from})
+		byPoint = append(byPoint, s)
+	}
+
+	for _, cluster := range geo.Cluster(free, placeRadius, minPlaceVisits) {
+		var v visits
+		for _, i := range cluster {
+			v.add(byPoint[i], loc)
+		}
+
+		places = append(places, &model.Place{Username: username})
+		visited = append(visited, v)
+	}
+
+	for i, p := range places {
+		p.Kind = ""
+		p.UpdatedAt = now
+
+		if len(visited[i].stays) > 0 {
+			visited[i].apply(p)
+		}
+	}
+
+	inferKind(places, visited, model.PlaceHome, func(v visits) int64 { return v.night })
+	inferKind(places, visited, model.PlaceWork, func(v visits) int64 { return v.work })
+
+	return places
+}
+
+// pinnedAt returns the pinned place nearest to the stay of those it is inside
+func pinnedAt(existing []model.Place, s stay) (int, bool) {
+	found, nearest := -1, math.Inf(1)
+
+	for i := range existing {
+		p := &existing[i]
+		if !p.Pinned {
+			continue
+		}
+
+		if d := geo.Distance(s.lat, s.lon, p.Lat, p.Lon); d <= p.Radius && d < nearest {
+			found, nearest = i, d
+		}
+	}
+
+	return found, found >= 0
+}
+
+// inferKind gives kind to the place without a kind with the most seconds, at least minKindDuration
+func inferKind(places []*model.Place, visited []visits, kind string, seconds func(visits) int64) {
+	best, most := -1, int64(minKindDuration-1)
+
+	for i, p := range places {
+		if p.Kind == "" && seconds(visited[i]) > most {
+			best, most = i, seconds(visited[i])
+		}
+	}
+
+	if best >= 0 {
+		places[best].Kind = kind
+	}
+}
+
+func (v *visits) add(s stay, loc *time.Location) {
+	night, work := dayparts(s.from, s.to, loc)
+	v.stays = append(v.stays, s)
+	v.night += night
+	v.work += work
+}
+
+// apply sets the position & statistics of the visits to the place, the position of pinned
+// places is chosen by the user and kept
+func (v *visits) apply(p *model.Place) {
+	var lat, lon, weights float64
+
+	p.Visits = len(v.stays)
+	p.Duration = 0
+
+	for _, s := range v.stays {
+		// a stay of a few seconds still counts
+		w := float64(s.to-s.from) + 1
+		lat += s.lat * w
+		lon += s.lon * w
+		weights += w
+		p.Duration += s.to - s.from
+
+		if p.FirstVisit == 0 || s.from < p.FirstVisit {
+			p.FirstVisit = s.from
+		}
+
+		if s.to > p.LastVisit {
+			p.LastVisit = s.to
+		}
+	}
+
+	if p.Pinned {
+		return
+	}
+
+	p.Lat, p.Lon = lat/weights, lon/weights
+	p.Radius = placeRadius
+
+	for _, s := range v.stays {
+		p.Radius = math.Max(p.Radius, math.Ceil(geo.Distance(p.Lat, p.Lon, s.lat, s.lon)))
+	}
+
+	p.Radius = math.Min(p.Radius, maxPlaceRadius)
+}
+
+// dayparts splits the seconds between from & to into those at night(22-6h) and
+// those in weekday work hours(9-17h) of loc
+func dayparts(from, to int64, loc *time.Location) (night, work int64) {
+	for t := from; t < to; {
+		local := time.Unix(t, 0).In(loc)
+
+		next := time.Date(local.Year(), local.Month(), local.Day(), local.Hour()+1, 0, 0, 0, loc).Unix()
+		if next <= t {
+			next = t + int64(time.Hour.Seconds())
+		}
+
+		if next > to {
+			next = to
+		}
+
+		hour, weekday := local.Hour(), local.Weekday()
+
+		switch {
+		case hour >= nightStart || hour < nightEnd:
+			night += next - t
+		case weekday != time.Saturday && weekday != time.Sunday && hour >= workStart && hour < workEnd:
+			work += next - t
+		}
+
+		t = next
+	}
+
+	return night, work
+}
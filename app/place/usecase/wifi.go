@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	bssidOctets   = 6
+	maxSSIDLength = 100
+)
+
+// AddAccessPoint maps a wifi access point of the user to one of its places, the place is
+// pinned so refreshing places keeps it. An access point mapped before is moved
+func (u *placeUsecase) AddAccessPoint(c context.Context, ap *model.AccessPoint) (err error) {
+	c, span := tracer.Start(c, "placeUsecase.AddAccessPoint")
+	defer func() { tracing.End(span, err) }()
+
+	bssid, ok := NormalizeBSSID(ap.BSSID)
+	if !ok {
+		return response.WrapError(errors.New("bssid must be a MAC address like c0:ff:ee:00:00:01"), http.StatusBadRequest)
+	}
+
+	if len(ap.SSID) > maxSSIDLength {
+		return response.WrapError(errors.New("ssid must be at most 100 characters"), http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	places, err := u.repo.GetUserPlaces(ctx, ap.Username)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	var place *model.Place
+
+	for i := range places {
+		if places[i].ID == ap.PlaceID {
+			place = &places[i]
+			break
+		}
+	}
+
+	if place == nil {
+		return response.ErrNotFound
+	}
+
+	if !place.Pinned {
+		place.Pinned = true
+		place.UpdatedAt = time.Now().Unix()
+
+		if err = u.repo.UpdatePlace(ctx, place); err != nil {
+			logger.FromContext(ctx).Errorln(err)
+
+			return errInternal
+		}
+	}
+
+	ap.BSSID = bssid
+	ap.CreatedAt = time.Now().Unix()
+
+	if err = u.repo.SaveAccessPoint(ctx, ap); err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	u.invalidate(ap.Username)
+
+	return nil
+}
+
+// AccessPoints returns the access points of the user
+func (u *placeUsecase) AccessPoints(c context.Context, username string) (aps []model.AccessPoint, err error) {
+	c, span := tracer.Start(c, "placeUsecase.AccessPoints")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	aps, err = u.repo.GetUserAccessPoints(ctx, username)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	return aps, nil
+}
+
+// DeleteAccessPoint removes an access point of the user, its place is kept
+func (u *placeUsecase) DeleteAccessPoint(c context.Context, id int64, username string) (err error) {
+	c, span := tracer.Start(c, "placeUsecase.DeleteAccessPoint")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if err = u.repo.DeleteAccessPoint(ctx, id, username); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response.ErrNotFound
+		}
+
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	u.invalidate(username)
+
+	return nil
+}
+
+// WifiPlace returns the place of the access point of the user with the bssid, nil when unknown
+func (u *placeUsecase) WifiPlace(c context.Context, username, bssid string) *model.Place {
+	bssid, ok := NormalizeBSSID(bssid)
+	if !ok {
+		return nil
+	}
+
+	entry, err := u.cached(c, username)
+	if err != nil {
+		logger.FromContext(c).Errorln(err)
+
+		return nil
+	}
+
+	for i := range entry.aps {
+		if entry.aps[i].BSSID != bssid {
+			continue
+		}
+
+		for j := range entry.places {
+			if entry.places[j].ID == entry.aps[i].PlaceID {
+				place := entry.places[j]
+				return &place
+			}
+		}
+	}
+
+	return nil
+}
+
+// NormalizeBSSID lower cases a MAC address and pads its octets to two digits, some
+// apps leave out leading zeros(c0:0:0:0:0:1), false when it isn't a MAC address
+func NormalizeBSSID(bssid string) (string, bool) {
+	octets := strings.FieldsFunc(strings.TrimSpace(bssid), func(r rune) bool { return r == ':' || r == '-' })
+	if len(octets) != bssidOctets {
+		return "", false
+	}
+
+	for i, octet := range octets {
+		v, err := strconv.ParseUint(octet, 16, 8)
+		if err != nil || len(octet) > 2 {
+			return "", false
+		}
+
+		octets[i] = fmt.Sprintf("%02x", v)
+	}
+
+	return strings.Join(octets, ":"), true
+}
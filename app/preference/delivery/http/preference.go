@@ -0,0 +1,59 @@
+package http
+
+import (
+	"errors"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PreferenceHandler represent the http handler for preferences
+type PreferenceHandler struct {
+	PUseCase model.PreferenceUsecase
+}
+
+func NewPreferenceHandler(e *echo.Echo, us model.PreferenceUsecase) {
+	handler := &PreferenceHandler{
+		PUseCase: us,
+	}
+
+	v1 := e.Group("/api/v1")
+	v1.GET("/preferences", handler.Get)
+	v1.PATCH("/preferences", handler.Update)
+}
+
+// Get returns the preferences of the caller(x-limit-u)
+func (h *PreferenceHandler) Get(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	pref, err := h.PUseCase.Get(c.Request().Context(), username)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", pref))
+}
+
+// Update changes the preferences of the caller(x-limit-u)
+func (h *PreferenceHandler) Update(c echo.Context) error {
+	username := c.Request().Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	var update model.PreferenceUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+	}
+
+	pref, err := h.PUseCase.Update(c.Request().Context(), username, update)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("preferences updated", pref))
+}
@@ -0,0 +1,67 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	pHttp "ot-recorder/app/preference/delivery/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func buildRequest(method, body, username string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, "/api/v1/preferences", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	if username != "" {
+		req.Header.Set("x-limit-u", username)
+	}
+
+	rec := httptest.NewRecorder()
+
+	return echo.New().NewContext(req, rec), rec
+}
+
+func TestGet(t *testing.T) {
+	mockUsecase := new(mocks.PreferenceUsecase)
+	mockUsecase.On("Get", mock.Anything, "dev").Return(&model.Preference{
+		Username: "dev", TimeZone: "UTC", Units: model.UnitsMetric, Clock: model.Clock24h, Language: "en",
+	}, nil).Once()
+
+	handler := pHttp.PreferenceHandler{PUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.GET, "", "dev")
+	assert.NoError(t, handler.Get(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(),
+		`{"username":"dev","time_zone":"UTC","units":"metric","clock":"24h","language":"en"}`)
+
+	c, rec = buildRequest(echo.GET, "", "")
+	assert.NoError(t, handler.Get(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestUpdate(t *testing.T) {
+	mockUsecase := new(mocks.PreferenceUsecase)
+	mockUsecase.On("Update", mock.Anything, "dev", mock.MatchedBy(func(u model.PreferenceUpdate) bool {
+		return *u.Units == model.UnitsImperial && u.TimeZone == nil && u.Clock == nil && u.Language == nil
+	})).Return(&model.Preference{Username: "dev", Units: model.UnitsImperial}, nil).Once()
+
+	handler := pHttp.PreferenceHandler{PUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.PATCH, `{"units":"imperial"}`, "dev")
+	assert.NoError(t, handler.Update(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"units":"imperial"`)
+
+	c, rec = buildRequest(echo.PATCH, `{"units":`, "dev")
+	assert.NoError(t, handler.Update(c))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
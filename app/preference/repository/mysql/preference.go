@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/metrics"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//nolint:gochecknoglobals
+var (
+	tracer         = otel.Tracer("ot-recorder/app/preference/repository/mysql")
+	spanAttributes = trace.WithAttributes(semconv.DBSystemMySQL, semconv.DBSQLTableKey.String("preferences"))
+)
+
+type preferenceRepository struct {
+	db *sql.DB
+}
+
+func NewMysqlPreferenceRepository(db *sql.DB) model.PreferenceRepository {
+	return &preferenceRepository{
+		db: db,
+	}
+}
+
+const getPreference = `SELECT id, username, time_zone, units, clock, language, updated_at FROM preferences
+WHERE username = ?`
+
+// GetPreference returns sql.ErrNoRows when the user has no preferences
+func (r *preferenceRepository) GetPreference(ctx context.Context, username string) (model.Preference, error) {
+	defer metrics.ObserveDBQuery("preference", "GetPreference", time.Now())
+
+	ctx, span := tracer.Start(ctx, "preferenceRepository.GetPreference", spanAttributes)
+	defer span.End()
+
+	var p model.Preference
+
+	err := r.db.QueryRowContext(ctx, getPreference, username).
+		Scan(&p.ID, &p.Username, &p.TimeZone, &p.Units, &p.Clock, &p.Language, &p.UpdatedAt)
+
+	return p, err
+}
+
+const (
+	deletePreference = `DELETE FROM preferences WHERE username = ?`
+	createPreference = `INSERT INTO preferences (username, time_zone, units, clock, language, updated_at)
+VALUES (?, ?, ?, ?, ?, ?)`
+)
+
+// SavePreference stores the preferences, replacing those of the user
+func (r *preferenceRepository) SavePreference(ctx context.Context, pref *model.Preference) error {
+	defer metrics.ObserveDBQuery("preference", "SavePreference", time.Now())
+
+	ctx, span := tracer.Start(ctx, "preferenceRepository.SavePreference", spanAttributes)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, deletePreference, pref.Username); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, createPreference, preferenceArgs(pref)...)
+	if err == nil {
+		pref.ID, err = res.LastInsertId()
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func preferenceArgs(p *model.Preference) []interface{} {
+	return []interface{}{p.Username, p.TimeZone, p.Units, p.Clock, p.Language, p.UpdatedAt}
+}
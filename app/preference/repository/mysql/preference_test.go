@@ -0,0 +1,50 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"ot-recorder/app/model"
+	preferenceRepo "ot-recorder/app/preference/repository/mysql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreference(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	pref := &model.Preference{
+		Username: "dev", TimeZone: "Asia/Dhaka", Units: "imperial", Clock: "12h", Language: "de", UpdatedAt: 1000,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM preferences WHERE username").WithArgs("dev").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO preferences").WithArgs("dev", "Asia/Dhaka", "imperial", "12h", "de", int64(1000)).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	rows := sqlmock.NewRows([]string{"id", "username", "time_zone", "units", "clock", "language", "updated_at"}).
+		AddRow(3, "dev", "Asia/Dhaka", "imperial", "12h", "de", 1000)
+	mock.ExpectQuery("SELECT id, username, time_zone, units, clock, language, updated_at FROM preferences").
+		WithArgs("dev").WillReturnRows(rows)
+	mock.ExpectQuery("SELECT id, username, time_zone, units, clock, language, updated_at FROM preferences").
+		WithArgs("mom").WillReturnError(sql.ErrNoRows)
+
+	pr := preferenceRepo.NewMysqlPreferenceRepository(db)
+	assert.NoError(t, pr.SavePreference(context.TODO(), pref))
+	assert.Equal(t, int64(3), pref.ID)
+
+	stored, err := pr.GetPreference(context.TODO(), "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, *pref, stored)
+
+	_, err = pr.GetPreference(context.TODO(), "mom")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"database/sql"
+	"ot-recorder/app/model"
+	"ot-recorder/app/preference/repository/mysql"
+	"ot-recorder/app/preference/repository/pgsql"
+	"ot-recorder/app/preference/repository/sqlite"
+)
+
+// NewPreferenceRepository returns the preference repository for the given database type
+func NewPreferenceRepository(dbType string, dbClient *sql.DB) model.PreferenceRepository {
+	switch dbType {
+	case "postgres":
+		return pgsql.NewPgsqlPreferenceRepository(dbClient)
+	case "mysql":
+		return mysql.NewMysqlPreferenceRepository(dbClient)
+	default:
+		return sqlite.NewSqlitePreferenceRepository(dbClient)
+	}
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"ot-recorder/app/locale"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+const preferenceCacheTTL = 5 * time.Minute
+
+var tracer = otel.Tracer("ot-recorder/app/preference/usecase") //nolint:gochecknoglobals
+
+//nolint:gochecknoglobals
+var errInternal = response.WrapError(
+	errors.New("internal server error, please report to admin"),
+	http.StatusInternalServerError,
+)
+
+type cachedPreference struct {
+	pref     model.Preference
+	loadedAt time.Time
+}
+
+type preferenceUsecase struct {
+	repo           model.PreferenceRepository
+	contextTimeout time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedPreference
+}
+
+func NewPreferenceUsecase(repo model.PreferenceRepository, timeout time.Duration) model.PreferenceUsecase {
+	return &preferenceUsecase{
+		repo:           repo,
+		contextTimeout: timeout,
+		cache:          map[string]cachedPreference{},
+	}
+}
+
+// Get returns the preferences of the user, unset ones are the defaults
+func (u *preferenceUsecase) Get(c context.Context, username string) (pref *model.Preference, err error) {
+	c, span := tracer.Start(c, "preferenceUsecase.Get")
+	defer func() { tracing.End(span, err) }()
+
+	stored, err := u.stored(c, username)
+	if err != nil {
+		logger.FromContext(c).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	withDefaults := locale.WithDefaults(stored, config.Get().App.TimeZone)
+
+	return &withDefaults, nil
+}
+
+// Update changes the preferences of the user, an empty value resets one to the default
+func (u *preferenceUsecase) Update(
+	c context.Context,
+	username string,
+	update model.PreferenceUpdate,
+) (pref *model.Preference, err error) {
+	c, span := tracer.Start(c, "preferenceUsecase.Update")
+	defer func() { tracing.End(span, err) }()
+
+	if err = validateUpdate(update); err != nil {
+		return nil, err
+	}
+
+	stored, err := u.stored(c, username)
+	if err != nil {
+		logger.FromContext(c).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	if update.TimeZone != nil {
+		stored.TimeZone = *update.TimeZone
+	}
+
+	if update.Units != nil {
+		stored.Units = *update.Units
+	}
+
+	if update.Clock != nil {
+		stored.Clock = *update.Clock
+	}
+
+	if update.Language != nil {
+		stored.Language = *update.Language
+	}
+
+	stored.Username = username
+	stored.UpdatedAt = time.Now().Unix()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if err = u.repo.SavePreference(ctx, &stored); err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	u.mu.Lock()
+	delete(u.cache, username)
+	u.mu.Unlock()
+
+	withDefaults := locale.WithDefaults(stored, config.Get().App.TimeZone)
+
+	return &withDefaults, nil
+}
+
+// For returns the preferences of the user loaded at most preferenceCacheTTL ago,
+// the defaults when they can't be loaded
+func (u *preferenceUsecase) For(c context.Context, username string) model.Preference {
+	u.mu.Lock()
+	entry, ok := u.cache[username]
+	u.mu.Unlock()
+
+	if ok && time.Since(entry.loadedAt) < preferenceCacheTTL {
+		return entry.pref
+	}
+
+	stored, err := u.stored(c, username)
+	if err != nil {
+		logger.FromContext(c).Errorln(err)
+
+		return locale.WithDefaults(model.Preference{Username: username}, config.Get().App.TimeZone)
+	}
+
+	pref := locale.WithDefaults(stored, config.Get().App.TimeZone)
+
+	u.mu.Lock()
+	u.cache[username] = cachedPreference{pref: pref, loadedAt: time.Now()}
+	u.mu.Unlock()
+
+	return pref
+}
+
+// stored returns the preferences stored for the user, empty ones when it has none
+func (u *preferenceUsecase) stored(c context.Context, username string) (model.Preference, error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	pref, err := u.repo.GetPreference(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Preference{Username: username}, nil
+	}
+
+	return pref, err
+}
+
+func validateUpdate(update model.PreferenceUpdate) error {
+	if update.TimeZone != nil && *update.TimeZone != "" {
+		if _, err := time.LoadLocation(*update.TimeZone); err != nil {
+			return response.WrapError(errors.New("time_zone must be an IANA time zone like Asia/Dhaka"),
+				http.StatusBadRequest)
+		}
+	}
+
+	if update.Units != nil && *update.Units != "" && *update.Units != model.UnitsMetric &&
+		*update.Units != model.UnitsImperial {
+		return response.WrapError(errors.New("units must be metric or imperial"), http.StatusBadRequest)
+	}
+
+	if update.Clock != nil && *update.Clock != "" && *update.Clock != model.Clock24h &&
+		*update.Clock != model.Clock12h {
+		return response.WrapError(errors.New("clock must be 24h or 12h"), http.StatusBadRequest)
+	}
+
+	if update.Language != nil && *update.Language != "" && !locale.Supported(*update.Language) {
+		return response.WrapError(errors.New("language must be one of en, de, es or fr"), http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/preference/usecase"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGet(t *testing.T) {
+	config.LoadTestValues()
+
+	mockRepo := new(mocks.PreferenceRepository)
+	mockRepo.On("GetPreference", mock.Anything, "dev").
+		Return(model.Preference{ID: 1, Username: "dev", Units: model.UnitsImperial}, nil).Once()
+	mockRepo.On("GetPreference", mock.Anything, "mom").Return(model.Preference{}, sql.ErrNoRows).Once()
+	mockRepo.On("GetPreference", mock.Anything, "").Return(model.Preference{}, errors.New("db down")).Once()
+
+	u := usecase.NewPreferenceUsecase(mockRepo, time.Second*2)
+
+	pref, err := u.Get(context.TODO(), "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, model.UnitsImperial, pref.Units)
+	assert.Equal(t, model.Clock24h, pref.Clock)
+	assert.Equal(t, "en", pref.Language)
+
+	// without stored preferences the defaults
+	pref, err = u.Get(context.TODO(), "mom")
+	assert.NoError(t, err)
+	assert.Equal(t, "mom", pref.Username)
+	assert.Equal(t, model.UnitsMetric, pref.Units)
+
+	_, err = u.Get(context.TODO(), "")
+	code, _ := response.RespondError(err)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdate(t *testing.T) {
+	tz, clock, units := "Asia/Dhaka", model.Clock12h, ""
+
+	mockRepo := new(mocks.PreferenceRepository)
+	mockRepo.On("GetPreference", mock.Anything, "dev").
+		Return(model.Preference{ID: 1, Username: "dev", Units: model.UnitsImperial, Language: "de"}, nil).Once()
+	mockRepo.On("SavePreference", mock.Anything, mock.MatchedBy(func(p *model.Preference) bool {
+		return p.Username == "dev" && p.TimeZone == tz && p.Clock == clock && p.Units == "" && p.Language == "de" &&
+			p.UpdatedAt > 0
+	})).Return(nil).Once()
+
+	u := usecase.NewPreferenceUsecase(mockRepo, time.Second*2)
+
+	pref, err := u.Update(context.TODO(), "dev", model.PreferenceUpdate{TimeZone: &tz, Clock: &clock, Units: &units})
+	assert.NoError(t, err)
+	assert.Equal(t, model.UnitsMetric, pref.Units)
+	assert.Equal(t, "Asia/Dhaka", pref.TimeZone)
+	mockRepo.AssertExpectations(t)
+
+	bad := "mars"
+	for name, update := range map[string]model.PreferenceUpdate{
+		"time zone": {TimeZone: &bad},
+		"units":     {Units: &bad},
+		"clock":     {Clock: &bad},
+		"language":  {Language: &bad},
+	} {
+		_, err := u.Update(context.TODO(), "dev", update)
+		code, _ := response.RespondError(err)
+		assert.Equal(t, http.StatusBadRequest, code, name)
+	}
+}
+
+func TestFor(t *testing.T) {
+	config.LoadTestValues()
+
+	mockRepo := new(mocks.PreferenceRepository)
+	// loaded once, then cached
+	mockRepo.On("GetPreference", mock.Anything, "dev").
+		Return(model.Preference{ID: 1, Username: "dev", Clock: model.Clock12h}, nil).Once()
+	mockRepo.On("GetPreference", mock.Anything, "mom").Return(model.Preference{}, errors.New("timeout")).Twice()
+
+	u := usecase.NewPreferenceUsecase(mockRepo, time.Second*2)
+
+	assert.Equal(t, model.Clock12h, u.For(context.TODO(), "dev").Clock)
+	assert.Equal(t, model.Clock12h, u.For(context.TODO(), "dev").Clock)
+
+	// failures aren't cached
+	assert.Equal(t, model.Clock24h, u.For(context.TODO(), "mom").Clock)
+	assert.Equal(t, "mom", u.For(context.TODO(), "mom").Username)
+	mockRepo.AssertExpectations(t)
+}
@@ -6,22 +6,44 @@ import (
 	"log"
 	"os"
 	"os/signal"
-	"ot-recorder/app/model"
 	"syscall"
 	"time"
 
 	"ot-recorder/app"
+	commandDelivery "ot-recorder/app/command/delivery/http"
+	commandRepo "ot-recorder/app/command/repository"
+	commandUseCase "ot-recorder/app/command/usecase"
+	deviceDelivery "ot-recorder/app/device/delivery/http"
+	"ot-recorder/app/device/notifier"
+	deviceUseCase "ot-recorder/app/device/usecase"
+	"ot-recorder/app/location/archive"
 	locationDelivery "ot-recorder/app/location/delivery/http"
-	locationMysqlRepo "ot-recorder/app/location/repository/mysql"
-	locationPgsqlRepo "ot-recorder/app/location/repository/pgsql"
-	locationSqliteRepo "ot-recorder/app/location/repository/sqlite"
+	"ot-recorder/app/location/ingest"
+	locationRepo "ot-recorder/app/location/repository"
+	"ot-recorder/app/location/stream"
 	locationUseCase "ot-recorder/app/location/usecase"
+	"ot-recorder/app/model"
+	placeDelivery "ot-recorder/app/place/delivery/http"
+	placeRepo "ot-recorder/app/place/repository"
+	placeUseCase "ot-recorder/app/place/usecase"
+	preferenceDelivery "ot-recorder/app/preference/delivery/http"
+	preferenceRepo "ot-recorder/app/preference/repository"
+	preferenceUseCase "ot-recorder/app/preference/usecase"
+	shareDelivery "ot-recorder/app/share/delivery/http"
+	shareRepo "ot-recorder/app/share/repository"
+	shareUseCase "ot-recorder/app/share/usecase"
 	systemDelivery "ot-recorder/app/system/delivery/http"
 	systemRepo "ot-recorder/app/system/repository"
 	systemUseCase "ot-recorder/app/system/usecase"
+	"ot-recorder/app/ui"
+	waypointDelivery "ot-recorder/app/waypoint/delivery/http"
+	waypointRepo "ot-recorder/app/waypoint/repository"
+	waypointUseCase "ot-recorder/app/waypoint/usecase"
 	"ot-recorder/infrastructure/config"
 	"ot-recorder/infrastructure/db"
+	"ot-recorder/infrastructure/metrics"
 	"ot-recorder/infrastructure/middlewares"
+	"ot-recorder/infrastructure/tracing"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
@@ -31,6 +53,12 @@ const gracefullShutdownTime = 5
 
 type Server struct {
 	ServerReady chan bool
+
+	// SchemaVersion latest embedded migration, reported by the health checks
+	SchemaVersion uint
+
+	// shutdownHooks run after the http server is stopped
+	shutdownHooks []func(ctx context.Context) error
 }
 
 func (s *Server) Serve() {
@@ -42,7 +70,16 @@ func (s *Server) Serve() {
 		defer db.Close()
 	}
 
-	e := setupAPIServer(cfg)
+	e := s.setupAPIServer(cfg)
+
+	// after the other hooks, so spans they record are exported
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Get().Tracing)
+	if err != nil {
+		logrus.Errorln(err)
+		os.Exit(1)
+	}
+
+	s.shutdownHooks = append(s.shutdownHooks, shutdownTracing)
 
 	go func() {
 		printBanner()
@@ -68,9 +105,15 @@ func (s *Server) Serve() {
 	if err := e.Shutdown(ctx); err != nil {
 		logrus.Fatalf("failed to gracefully shutdown the server: %s", err)
 	}
+
+	for _, hook := range s.shutdownHooks {
+		if err := hook(ctx); err != nil {
+			logrus.Errorln(err)
+		}
+	}
 }
 
-func setupAPIServer(cfg config.AppConfig) *echo.Echo {
+func (s *Server) setupAPIServer(cfg config.AppConfig) *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
 	e.Server.ReadTimeout = cfg.ReadTimeout
@@ -89,28 +132,162 @@ func setupAPIServer(cfg config.AppConfig) *echo.Echo {
 	// repository
 	sysRepo := systemRepo.NewSystemRepository(dbClient)
 
-	var lRepo model.LocationRepository
+	lRepo := locationRepo.NewLocationRepository(dbType, dbClient)
+	sRepo := shareRepo.NewShareRepository(dbType, dbClient)
+	pRepo := placeRepo.NewPlaceRepository(dbType, dbClient)
+	prefRepo := preferenceRepo.NewPreferenceRepository(dbType, dbClient)
+	wRepo := waypointRepo.NewWaypointRepository(dbType, dbClient)
+	cRepo := commandRepo.NewCommandRepository(dbType, dbClient)
 
-	switch dbType {
-	case "postgres":
-		lRepo = locationPgsqlRepo.NewPgsqlLocationRepository(dbClient)
-	case "mysql":
-		lRepo = locationMysqlRepo.NewMysqlLocationRepository(dbClient)
-	default:
-		lRepo = locationSqliteRepo.NewSqliteLocationRepository(dbClient)
+	// use cases
+	sysUseCase := systemUseCase.NewSystemUsecase(sysRepo, s.SchemaVersion, contextTimeout)
+
+	ingestCfg := config.Get().Ingest
+	if ingestCfg.Enabled {
+		queue, err := ingest.NewQueue(lRepo, ingestCfg)
+		if err != nil {
+			logrus.Errorln(err)
+			os.Exit(1)
+		}
+
+		lRepo = queue
+		s.shutdownHooks = append(s.shutdownHooks, queue.Close)
 	}
 
-	// use cases
-	sysUseCase := systemUseCase.NewSystemUsecase(sysRepo)
-	lUseCase := locationUseCase.NewLocationUsecase(lRepo, contextTimeout)
+	registerHealthChecks(sysUseCase, lRepo)
+
+	hub := stream.NewHub(config.Get().Stream.Buffer)
+	prefUseCase := preferenceUseCase.NewPreferenceUsecase(prefRepo, contextTimeout)
+	pUseCase := placeUseCase.NewPlaceUsecase(pRepo, lRepo, prefUseCase, contextTimeout)
+	lUseCase := locationUseCase.NewLocationUsecase(lRepo, pUseCase, prefUseCase, hub, contextTimeout)
+	sUseCase := shareUseCase.NewShareUsecase(sRepo, lUseCase, contextTimeout)
+	wUseCase := waypointUseCase.NewWaypointUsecase(wRepo, config.Get().Waypoints.Admins, contextTimeout)
+	cUseCase := commandUseCase.NewCommandUsecase(cRepo, config.Get().Commands, contextTimeout)
+
+	monitorCfg := config.Get().Monitor
+	dUseCase := deviceUseCase.NewDeviceUsecase(
+		lRepo,
+		notifier.FromConfig(monitorCfg, config.Get().Hook.Telegram),
+		monitorCfg,
+		contextTimeout,
+	)
+
+	if monitorCfg.Enabled {
+		monitor := deviceUseCase.StartMonitor(dUseCase, monitorCfg.Interval)
+		s.shutdownHooks = append(s.shutdownHooks, monitor.Close)
+	}
+
+	registerMonitorHealthCheck(sysUseCase, dUseCase)
 
 	// delivery
 	systemDelivery.NewSystemHandler(e, sysUseCase)
-	locationDelivery.NewUserHandler(e, lUseCase)
+	// queued commands go before the waypoints, a clearWaypoints doesn't remove the waypoints sent with it
+	locationDelivery.NewUserHandler(e, lUseCase, s.payloadArchive(), cUseCase, wUseCase)
+	shareDelivery.NewShareHandler(e, sUseCase)
+	placeDelivery.NewPlaceHandler(e, pUseCase)
+	preferenceDelivery.NewPreferenceHandler(e, prefUseCase)
+	deviceDelivery.NewDeviceHandler(e, dUseCase)
+	waypointDelivery.NewWaypointHandler(e, wUseCase)
+	commandDelivery.NewCommandHandler(e, cUseCase)
+	ui.NewUIHandler(e)
 
 	return e
 }
 
+// payloadArchive the raw payload archive of the ping handlers, nil when disabled
+func (s *Server) payloadArchive() model.PayloadArchive {
+	archiveCfg := config.Get().Archive
+	if !archiveCfg.Enabled {
+		return nil
+	}
+
+	a, err := archive.New(archiveCfg.Path)
+	if err != nil {
+		logrus.Errorln(err)
+		os.Exit(1)
+	}
+
+	return a
+}
+
+// registerHealthChecks adds the components of the location domain to the health report
+func registerHealthChecks(sysUseCase systemUseCase.SystemUsecase, lRepo model.LocationRepository) {
+	queue, queueEnabled := lRepo.(*ingest.Queue)
+	capacity := config.Get().Ingest.QueueSize
+
+	sysUseCase.Register(systemUseCase.HealthCheck{
+		Name:     "ingest_queue",
+		Critical: true,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			if !queueEnabled {
+				return nil, systemUseCase.ErrCheckDisabled
+			}
+
+			depth := queue.Depth()
+			details := map[string]interface{}{"depth": depth, "capacity": capacity}
+
+			if depth >= capacity {
+				return details, ingest.ErrQueueFull
+			}
+
+			return details, nil
+		},
+	})
+
+	sysUseCase.Register(systemUseCase.HealthCheck{
+		Name: "ingest",
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			details := map[string]interface{}{"last_ingest": nil}
+			if at := metrics.LastIngest(); !at.IsZero() {
+				details["last_ingest"] = at.UTC().Format(time.RFC3339)
+				details["seconds_since_last_ingest"] = int64(time.Since(at).Seconds())
+			}
+
+			return details, nil
+		},
+	})
+
+	sysUseCase.Register(systemUseCase.HealthCheck{
+		Name: "telegram_hook",
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			if config.Get().Hook.Telegram.SecretToken == "" {
+				return nil, systemUseCase.ErrCheckDisabled
+			}
+
+			return nil, nil
+		},
+	})
+}
+
+// registerMonitorHealthCheck reports the last error of every device alert notifier
+func registerMonitorHealthCheck(sysUseCase systemUseCase.SystemUsecase, dUseCase model.DeviceUsecase) {
+	sysUseCase.Register(systemUseCase.HealthCheck{
+		Name: "device_notifiers",
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			if !config.Get().Monitor.Enabled {
+				return nil, systemUseCase.ErrCheckDisabled
+			}
+
+			details := map[string]interface{}{}
+			failed := 0
+
+			for name, failure := range dUseCase.Notifiers() {
+				details[name] = "ok"
+				if failure != "" {
+					details[name] = failure
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				return details, fmt.Errorf("%d of %d notifiers failed", failed, len(details))
+			}
+
+			return details, nil
+		},
+	})
+}
+
 func printBanner() {
 	log.SetFlags(0)
 	log.Println("=>>")
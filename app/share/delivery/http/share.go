@@ -0,0 +1,149 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/app/ui"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ShareHandler represent the http handler for share links
+type ShareHandler struct {
+	SUseCase model.ShareUsecase
+}
+
+// CreateShareRequest expires_in is a duration like 30m or 2h
+type CreateShareRequest struct {
+	ExpiresIn  string `json:"expires_in"`
+	TrackHours int    `json:"track_hours"`
+}
+
+// ShareResponse a share with the public path of its link
+type ShareResponse struct {
+	model.Share
+	URL string `json:"url"`
+}
+
+func NewShareHandler(e *echo.Echo, us model.ShareUsecase) {
+	handler := &ShareHandler{
+		SUseCase: us,
+	}
+
+	v1 := e.Group("/api/v1")
+	v1.POST("/shares", handler.Create)
+	v1.GET("/shares", handler.List)
+	v1.DELETE("/shares/:id", handler.Revoke)
+
+	// public, the token is the credential
+	e.GET("/s/:token", handler.Page)
+	e.GET("/s/:token/location", handler.Location)
+}
+
+// Create makes a share link of the caller(x-limit-u)
+func (h *ShareHandler) Create(c echo.Context) error {
+	req := c.Request()
+
+	username := req.Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	var shareReq CreateShareRequest
+	if err := c.Bind(&shareReq); err != nil {
+		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+	}
+
+	expiresIn, err := time.ParseDuration(shareReq.ExpiresIn)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest,
+			errors.New("expires_in must be a duration like 30m or 2h")))
+	}
+
+	share := &model.Share{
+		Username:   username,
+		TrackHours: shareReq.TrackHours,
+	}
+
+	if err := h.SUseCase.Create(req.Context(), share, int64(expiresIn.Seconds())); err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	_, res := response.RespondSuccess("share created", toShareResponse(share))
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+// List returns the share links of the caller(x-limit-u)
+func (h *ShareHandler) List(c echo.Context) error {
+	req := c.Request()
+
+	username := req.Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	shares, err := h.SUseCase.List(req.Context(), username)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	res := make([]ShareResponse, len(shares))
+	for i := range shares {
+		res[i] = toShareResponse(&shares[i])
+	}
+
+	return c.JSON(response.RespondSuccess("request success", res))
+}
+
+// Revoke ends a share link of the caller(x-limit-u)
+func (h *ShareHandler) Revoke(c echo.Context) error {
+	req := c.Request()
+
+	username := req.Header.Get("x-limit-u")
+	if username == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("invalid share id")))
+	}
+
+	if err := h.SUseCase.Revoke(req.Context(), id, username); err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("share revoked", nil))
+}
+
+// Page serves the public map of a share link and counts the view
+func (h *ShareHandler) Page(c echo.Context) error {
+	if err := h.SUseCase.View(c.Request().Context(), c.Param("token")); err != nil {
+		code, _ := response.RespondError(err)
+		return c.String(code, err.Error())
+	}
+
+	return c.HTMLBlob(http.StatusOK, ui.SharePage())
+}
+
+// Location returns the shared last location and track, polled by the share page
+func (h *ShareHandler) Location(c echo.Context) error {
+	location, err := h.SUseCase.Shared(c.Request().Context(), c.Param("token"))
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", location))
+}
+
+func toShareResponse(s *model.Share) ShareResponse {
+	return ShareResponse{
+		Share: *s,
+		URL:   "/s/" + s.Token,
+	}
+}
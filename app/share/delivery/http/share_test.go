@@ -0,0 +1,105 @@
+package http_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	sHttp "ot-recorder/app/share/delivery/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func buildRequest(method, path, body, username string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	if username != "" {
+		req.Header.Set("x-limit-u", username)
+	}
+
+	rec := httptest.NewRecorder()
+
+	return echo.New().NewContext(req, rec), rec
+}
+
+func TestCreate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.ShareUsecase)
+		mockUsecase.On("Create", mock.Anything, mock.MatchedBy(func(s *model.Share) bool {
+			return s.Username == "dev" && s.TrackHours == 3
+		}), int64(7200)).Run(func(args mock.Arguments) {
+			args.Get(1).(*model.Share).Token = "token"
+		}).Return(nil).Once()
+
+		c, rec := buildRequest(echo.POST, "/api/v1/shares", `{"expires_in":"2h","track_hours":3}`, "dev")
+
+		handler := sHttp.ShareHandler{SUseCase: mockUsecase}
+		assert.NoError(t, handler.Create(c))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"url":"/s/token"`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	for name, tc := range map[string]struct{ body, username string }{
+		"username missing":   {`{"expires_in":"2h"}`, ""},
+		"invalid expires_in": {`{"expires_in":"tomorrow"}`, "dev"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, rec := buildRequest(echo.POST, "/api/v1/shares", tc.body, tc.username)
+
+			handler := sHttp.ShareHandler{SUseCase: new(mocks.ShareUsecase)}
+			assert.NoError(t, handler.Create(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	mockUsecase := new(mocks.ShareUsecase)
+	mockUsecase.On("Revoke", mock.Anything, int64(7), "dev").Return(nil).Once()
+	mockUsecase.On("Revoke", mock.Anything, int64(7), "mom").Return(response.ErrNotFound).Once()
+
+	handler := sHttp.ShareHandler{SUseCase: mockUsecase}
+
+	for username, code := range map[string]int{"dev": http.StatusOK, "mom": http.StatusNotFound} {
+		c, rec := buildRequest(echo.DELETE, "/api/v1/shares/7", "", username)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+
+		assert.NoError(t, handler.Revoke(c))
+		assert.Equal(t, code, rec.Code)
+	}
+
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPage(t *testing.T) {
+	mockUsecase := new(mocks.ShareUsecase)
+	mockUsecase.On("View", mock.Anything, "token").Return(nil).Once()
+	mockUsecase.On("View", mock.Anything, "gone").
+		Return(response.WrapError(errors.New("share link is expired"), http.StatusGone)).Once()
+
+	handler := sHttp.ShareHandler{SUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.GET, "/s/token", "", "")
+	c.SetParamNames("token")
+	c.SetParamValues("token")
+	assert.NoError(t, handler.Page(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/ui/share.js")
+
+	c, rec = buildRequest(echo.GET, "/s/gone", "", "")
+	c.SetParamNames("token")
+	c.SetParamValues("gone")
+	assert.NoError(t, handler.Page(c))
+	assert.Equal(t, http.StatusGone, rec.Code)
+
+	mockUsecase.AssertExpectations(t)
+}
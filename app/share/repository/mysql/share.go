@@ -0,0 +1,147 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/metrics"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//nolint:gochecknoglobals
+var (
+	tracer         = otel.Tracer("ot-recorder/app/share/repository/mysql")
+	spanAttributes = trace.WithAttributes(semconv.DBSystemMySQL, semconv.DBSQLTableKey.String("shares"))
+)
+
+type shareRepository struct {
+	db *sql.DB
+}
+
+func NewMysqlShareRepository(db *sql.DB) model.ShareRepository {
+	return &shareRepository{
+		db: db,
+	}
+}
+
+const createShare = `INSERT INTO shares (token, username, track_hours, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?)`
+
+func (r *shareRepository) CreateShare(ctx context.Context, share *model.Share) error {
+	defer metrics.ObserveDBQuery("share", "CreateShare", time.Now())
+
+	ctx, span := tracer.Start(ctx, "shareRepository.CreateShare", spanAttributes)
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, createShare,
+		share.Token, share.Username, share.TrackHours, share.ExpiresAt, share.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	share.ID, err = res.LastInsertId()
+
+	return err
+}
+
+const shareSelectColumns = `id, token, username, track_hours, expires_at, revoked_at, views, created_at`
+
+const getShareByToken = `SELECT ` + shareSelectColumns + ` FROM shares WHERE token = ?`
+
+func (r *shareRepository) GetShareByToken(ctx context.Context, token string) (model.Share, error) {
+	defer metrics.ObserveDBQuery("share", "GetShareByToken", time.Now())
+
+	ctx, span := tracer.Start(ctx, "shareRepository.GetShareByToken", spanAttributes)
+	defer span.End()
+
+	return scanShare(r.db.QueryRowContext(ctx, getShareByToken, token))
+}
+
+const getUserShares = `SELECT ` + shareSelectColumns + ` FROM shares WHERE username = ? ORDER BY created_at DESC`
+
+func (r *shareRepository) GetUserShares(ctx context.Context, username string) ([]model.Share, error) {
+	defer metrics.ObserveDBQuery("share", "GetUserShares", time.Now())
+
+	ctx, span := tracer.Start(ctx, "shareRepository.GetUserShares", spanAttributes)
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, getUserShares, username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	shares := []model.Share{}
+
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+const revokeShare = `UPDATE shares SET revoked_at = ? WHERE id = ? AND username = ? AND revoked_at = 0`
+
+// RevokeShare returns sql.ErrNoRows when the user has no active share with the id
+func (r *shareRepository) RevokeShare(ctx context.Context, id int64, username string, revokedAt int64) error {
+	defer metrics.ObserveDBQuery("share", "RevokeShare", time.Now())
+
+	ctx, span := tracer.Start(ctx, "shareRepository.RevokeShare", spanAttributes)
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, revokeShare, revokedAt, id, username)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+const incrementShareViews = `UPDATE shares SET views = views + 1 WHERE id = ?`
+
+func (r *shareRepository) IncrementShareViews(ctx context.Context, id int64) error {
+	defer metrics.ObserveDBQuery("share", "IncrementShareViews", time.Now())
+
+	ctx, span := tracer.Start(ctx, "shareRepository.IncrementShareViews", spanAttributes)
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, incrementShareViews, id)
+
+	return err
+}
+
+func scanShare(row interface{ Scan(dest ...interface{}) error }) (model.Share, error) {
+	var s model.Share
+	err := row.Scan(
+		&s.ID,
+		&s.Token,
+		&s.Username,
+		&s.TrackHours,
+		&s.ExpiresAt,
+		&s.RevokedAt,
+		&s.Views,
+		&s.CreatedAt,
+	)
+
+	return s, err
+}
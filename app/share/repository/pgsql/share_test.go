@@ -0,0 +1,119 @@
+package pgsql_test
+
+import (
+	"context"
+	"database/sql"
+	"ot-recorder/app/model"
+	shareRepo "ot-recorder/app/share/repository/pgsql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:gochecknoglobals
+var shareColumns = []string{"id", "token", "username", "track_hours", "expires_at", "revoked_at", "views", "created_at"}
+
+func TestCreateShare(t *testing.T) {
+	now := time.Now().Unix()
+	s := &model.Share{
+		Token:      "token",
+		Username:   "dev",
+		TrackHours: 2,
+		ExpiresAt:  now + 3600,
+		CreatedAt:  now,
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO shares").
+		WithArgs(s.Token, s.Username, s.TrackHours, s.ExpiresAt, s.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	sr := shareRepo.NewPgsqlShareRepository(db)
+	err = sr.CreateShare(context.TODO(), s)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), s.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetShareByToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows(shareColumns).AddRow(7, "token", "dev", 2, 1700003600, 0, 3, 1700000000)
+	mock.ExpectQuery("SELECT (.+) FROM shares WHERE token").WithArgs("token").WillReturnRows(rows)
+
+	sr := shareRepo.NewPgsqlShareRepository(db)
+	s, err := sr.GetShareByToken(context.TODO(), "token")
+	assert.NoError(t, err)
+	assert.Equal(t, model.Share{
+		ID:         7,
+		Token:      "token",
+		Username:   "dev",
+		TrackHours: 2,
+		ExpiresAt:  1700003600,
+		Views:      3,
+		CreatedAt:  1700000000,
+	}, s)
+}
+
+func TestGetUserShares(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows(shareColumns).
+		AddRow(8, "token2", "dev", 0, 1700007200, 0, 0, 1700003600).
+		AddRow(7, "token", "dev", 2, 1700003600, 1700001000, 3, 1700000000)
+	mock.ExpectQuery("SELECT (.+) FROM shares WHERE username").WithArgs("dev").WillReturnRows(rows)
+
+	sr := shareRepo.NewPgsqlShareRepository(db)
+	shares, err := sr.GetUserShares(context.TODO(), "dev")
+	assert.NoError(t, err)
+	assert.Len(t, shares, 2)
+	assert.Equal(t, "token2", shares[0].Token)
+	assert.Equal(t, int64(1700001000), shares[1].RevokedAt)
+}
+
+func TestRevokeShare(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE shares SET revoked_at").WithArgs(1700001000, 7, "dev").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE shares SET revoked_at").WithArgs(1700001000, 7, "mom").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	sr := shareRepo.NewPgsqlShareRepository(db)
+	assert.NoError(t, sr.RevokeShare(context.TODO(), 7, "dev", 1700001000))
+	assert.ErrorIs(t, sr.RevokeShare(context.TODO(), 7, "mom", 1700001000), sql.ErrNoRows)
+}
+
+func TestIncrementShareViews(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE shares SET views = views \\+ 1").WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	sr := shareRepo.NewPgsqlShareRepository(db)
+	assert.NoError(t, sr.IncrementShareViews(context.TODO(), 7))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
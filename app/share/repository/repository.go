@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"database/sql"
+	"ot-recorder/app/model"
+	"ot-recorder/app/share/repository/mysql"
+	"ot-recorder/app/share/repository/pgsql"
+	"ot-recorder/app/share/repository/sqlite"
+)
+
+// NewShareRepository returns the share repository for the given database type
+func NewShareRepository(dbType string, dbClient *sql.DB) model.ShareRepository {
+	switch dbType {
+	case "postgres":
+		return pgsql.NewPgsqlShareRepository(dbClient)
+	case "mysql":
+		return mysql.NewMysqlShareRepository(dbClient)
+	default:
+		return sqlite.NewSqliteShareRepository(dbClient)
+	}
+}
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	tokenBytes       = 32
+	maxShareDuration = 7 * 24 * 60 * 60 // seconds
+	maxTrackHours    = 7 * 24
+	trackLimit       = 5000
+)
+
+var tracer = otel.Tracer("ot-recorder/app/share/usecase") //nolint:gochecknoglobals
+
+//nolint:gochecknoglobals
+var (
+	// ErrShareExpired share link is expired or revoked
+	ErrShareExpired = response.WrapError(errors.New("share link is expired"), http.StatusGone)
+
+	errInternal = response.WrapError(
+		errors.New("internal server error, please report to admin"),
+		http.StatusInternalServerError,
+	)
+)
+
+type shareUsecase struct {
+	repo           model.ShareRepository
+	lUsecase       model.LocationUsecase
+	contextTimeout time.Duration
+}
+
+func NewShareUsecase(
+	repo model.ShareRepository,
+	lUsecase model.LocationUsecase,
+	timeout time.Duration,
+) model.ShareUsecase {
+	return &shareUsecase{
+		repo:           repo,
+		lUsecase:       lUsecase,
+		contextTimeout: timeout,
+	}
+}
+
+// Create stores a new share of share.Username valid for expiresIn seconds, the token is generated
+func (u *shareUsecase) Create(c context.Context, share *model.Share, expiresIn int64) (err error) {
+	c, span := tracer.Start(c, "shareUsecase.Create")
+	defer func() { tracing.End(span, err) }()
+
+	if expiresIn <= 0 || expiresIn > maxShareDuration {
+		return response.WrapError(errors.New("expires_in must be between 1s and 168h"), http.StatusBadRequest)
+	}
+
+	if share.TrackHours < 0 || share.TrackHours > maxTrackHours {
+		return response.WrapError(errors.New("track_hours must be between 0 and 168"), http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	share.Token, err = newToken()
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	share.CreatedAt = time.Now().Unix()
+	share.ExpiresAt = share.CreatedAt + expiresIn
+	share.RevokedAt = 0
+	share.Views = 0
+
+	if err = u.repo.CreateShare(ctx, share); err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	return nil
+}
+
+// List returns every share of the user, newest first
+func (u *shareUsecase) List(c context.Context, username string) (shares []model.Share, err error) {
+	c, span := tracer.Start(c, "shareUsecase.List")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	shares, err = u.repo.GetUserShares(ctx, username)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	return shares, nil
+}
+
+// Revoke ends an active share of the user before it expires
+func (u *shareUsecase) Revoke(c context.Context, id int64, username string) (err error) {
+	c, span := tracer.Start(c, "shareUsecase.Revoke")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	err = u.repo.RevokeShare(ctx, id, username, time.Now().Unix())
+	if errors.Is(err, sql.ErrNoRows) {
+		return response.ErrNotFound
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	return nil
+}
+
+// View counts a visit of an active share link
+func (u *shareUsecase) View(c context.Context, token string) (err error) {
+	c, span := tracer.Start(c, "shareUsecase.View")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	share, err := u.activeShare(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if err = u.repo.IncrementShareViews(ctx, share.ID); err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	return nil
+}
+
+// Shared returns the last location and the track of the last TrackHours of an active share,
+// only with the fields of model.SharedPosition & model.SharedPoint
+func (u *shareUsecase) Shared(c context.Context, token string) (location *model.SharedLocation, err error) {
+	c, span := tracer.Start(c, "shareUsecase.Shared")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	share, err := u.activeShare(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := u.lUsecase.LastLocation(ctx, share.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	location = &model.SharedLocation{
+		Username:  share.Username,
+		ExpiresAt: share.ExpiresAt,
+		Location: &model.SharedPosition{
+			Latitude:     last.Latitude,
+			Longitude:    last.Longitude,
+			Accuracy:     last.Accuracy,
+			Timestamp:    last.Timestamp,
+			DateTime:     last.DateTime,
+			DateTimeISO:  last.DateTimeISO,
+			BatteryLevel: last.BatteryLevel,
+		},
+		Track: []model.SharedPoint{},
+	}
+
+	if share.TrackHours > 0 {
+		now := time.Now().Unix()
+
+		track, err := u.lUsecase.History(ctx, model.LocationQuery{
+			Username: share.Username,
+			From:     now - int64(share.TrackHours)*60*60,
+			To:       now,
+			Limit:    trackLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range track {
+			location.Track = append(location.Track, model.SharedPoint{
+				CreatedAt: p.CreatedAt,
+				Lat:       p.Lat,
+				Lon:       p.Lon,
+				Acc:       p.Acc,
+				Batt:      p.Batt,
+			})
+		}
+	}
+
+	return location, nil
+}
+
+// activeShare returns the share of the token, unknown tokens are not found and
+// expired or revoked shares are gone
+func (u *shareUsecase) activeShare(ctx context.Context, token string) (model.Share, error) {
+	share, err := u.repo.GetShareByToken(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return share, response.ErrNotFound
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return share, errInternal
+	}
+
+	if share.RevokedAt != 0 || share.ExpiresAt <= time.Now().Unix() {
+		return share, ErrShareExpired
+	}
+
+	return share, nil
+}
+
+// newToken returns a random url safe token of 43 characters
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
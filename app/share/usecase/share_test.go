@@ -0,0 +1,165 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"ot-recorder/app/share/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockShareRepo := new(mocks.ShareRepository)
+		mockShareRepo.On("CreateShare", mock.Anything, mock.AnythingOfType("*model.Share")).Return(nil).Once()
+
+		u := usecase.NewShareUsecase(mockShareRepo, new(mocks.LocationUsecase), time.Second*2)
+
+		share := &model.Share{Username: "dev", TrackHours: 2}
+		err := u.Create(context.TODO(), share, 3600)
+		assert.NoError(t, err)
+		assert.Len(t, share.Token, 43)
+		assert.Equal(t, share.CreatedAt+3600, share.ExpiresAt)
+		mockShareRepo.AssertExpectations(t)
+	})
+
+	for name, tc := range map[string]struct {
+		trackHours int
+		expiresIn  int64
+	}{
+		"no expiry":       {0, 0},
+		"expiry too long": {0, 8 * 24 * 60 * 60},
+		"negative track":  {-1, 60},
+		"track too long":  {169, 60},
+	} {
+		t.Run(name, func(t *testing.T) {
+			u := usecase.NewShareUsecase(new(mocks.ShareRepository), new(mocks.LocationUsecase), time.Second*2)
+
+			err := u.Create(context.TODO(), &model.Share{Username: "dev", TrackHours: tc.trackHours}, tc.expiresIn)
+			code, _ := response.RespondError(err)
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	mockShareRepo := new(mocks.ShareRepository)
+	mockShareRepo.On("RevokeShare", mock.Anything, int64(7), "dev", mock.AnythingOfType("int64")).Return(nil).Once()
+	mockShareRepo.On("RevokeShare", mock.Anything, int64(7), "mom", mock.AnythingOfType("int64")).
+		Return(sql.ErrNoRows).Once()
+
+	u := usecase.NewShareUsecase(mockShareRepo, new(mocks.LocationUsecase), time.Second*2)
+
+	assert.NoError(t, u.Revoke(context.TODO(), 7, "dev"))
+	assert.ErrorIs(t, u.Revoke(context.TODO(), 7, "mom"), response.ErrNotFound)
+	mockShareRepo.AssertExpectations(t)
+}
+
+func TestView(t *testing.T) {
+	now := time.Now().Unix()
+
+	t.Run("active", func(t *testing.T) {
+		mockShareRepo := new(mocks.ShareRepository)
+		mockShareRepo.On("GetShareByToken", mock.Anything, "token").
+			Return(model.Share{ID: 7, Username: "dev", ExpiresAt: now + 60}, nil).Once()
+		mockShareRepo.On("IncrementShareViews", mock.Anything, int64(7)).Return(nil).Once()
+
+		u := usecase.NewShareUsecase(mockShareRepo, new(mocks.LocationUsecase), time.Second*2)
+
+		assert.NoError(t, u.View(context.TODO(), "token"))
+		mockShareRepo.AssertExpectations(t)
+	})
+
+	for name, share := range map[string]model.Share{
+		"expired": {ID: 7, Username: "dev", ExpiresAt: now - 1},
+		"revoked": {ID: 7, Username: "dev", ExpiresAt: now + 60, RevokedAt: now - 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockShareRepo := new(mocks.ShareRepository)
+			mockShareRepo.On("GetShareByToken", mock.Anything, "token").Return(share, nil).Once()
+
+			u := usecase.NewShareUsecase(mockShareRepo, new(mocks.LocationUsecase), time.Second*2)
+
+			err := u.View(context.TODO(), "token")
+			code, _ := response.RespondError(err)
+			assert.Equal(t, http.StatusGone, code)
+			mockShareRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		mockShareRepo := new(mocks.ShareRepository)
+		mockShareRepo.On("GetShareByToken", mock.Anything, "token").Return(model.Share{}, sql.ErrNoRows).Once()
+
+		u := usecase.NewShareUsecase(mockShareRepo, new(mocks.LocationUsecase), time.Second*2)
+
+		assert.ErrorIs(t, u.View(context.TODO(), "token"), response.ErrNotFound)
+	})
+}
+
+func TestShared(t *testing.T) {
+	now := time.Now().Unix()
+
+	last := func() *model.LocationDetails {
+		return &model.LocationDetails{
+			Username:  "dev",
+			Device:    "phone",
+			Latitude:  23,
+			Longitude: 90,
+			Accuracy:  12,
+			Timestamp: now - 30,
+			WifiName:  "home",
+			WifiMAC:   "c0:00:00:00:00:00",
+			IPAddress: "127.0.0.1",
+			Topic:     "owntracks/dev/phone",
+			Place:     "Home",
+			InRegions: []string{"home"},
+		}
+	}
+
+	t.Run("with track", func(t *testing.T) {
+		mockShareRepo := new(mocks.ShareRepository)
+		mockShareRepo.On("GetShareByToken", mock.Anything, "token").
+			Return(model.Share{ID: 7, Username: "dev", TrackHours: 2, ExpiresAt: now + 60}, nil).Once()
+
+		mockLocationUsecase := new(mocks.LocationUsecase)
+		mockLocationUsecase.On("LastLocation", mock.Anything, "dev").Return(last(), nil).Once()
+		mockLocationUsecase.On("History", mock.Anything, mock.MatchedBy(func(q model.LocationQuery) bool {
+			return q.Username == "dev" && q.To-q.From == 2*60*60 && q.Device == ""
+		})).Return([]model.TrackPoint{{Device: "phone", CreatedAt: now - 60, Lat: 23, Lon: 90}}, nil).Once()
+
+		u := usecase.NewShareUsecase(mockShareRepo, mockLocationUsecase, time.Second*2)
+
+		shared, err := u.Shared(context.TODO(), "token")
+		assert.NoError(t, err)
+		assert.Equal(t, "dev", shared.Username)
+		assert.Equal(t, now+60, shared.ExpiresAt)
+		assert.Equal(t, []model.SharedPoint{{CreatedAt: now - 60, Lat: 23, Lon: 90}}, shared.Track)
+		assert.Equal(t, &model.SharedPosition{Latitude: 23, Longitude: 90, Accuracy: 12, Timestamp: now - 30},
+			shared.Location)
+		mockLocationUsecase.AssertExpectations(t)
+	})
+
+	t.Run("without track", func(t *testing.T) {
+		mockShareRepo := new(mocks.ShareRepository)
+		mockShareRepo.On("GetShareByToken", mock.Anything, "token").
+			Return(model.Share{ID: 7, Username: "dev", ExpiresAt: now + 60}, nil).Once()
+
+		mockLocationUsecase := new(mocks.LocationUsecase)
+		mockLocationUsecase.On("LastLocation", mock.Anything, "dev").Return(last(), nil).Once()
+
+		u := usecase.NewShareUsecase(mockShareRepo, mockLocationUsecase, time.Second*2)
+
+		shared, err := u.Shared(context.TODO(), "token")
+		assert.NoError(t, err)
+		assert.Empty(t, shared.Track)
+		mockLocationUsecase.AssertExpectations(t)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"ot-recorder/app/system/usecase"
 
 	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // SystemHandler  represent the httphandler for system
@@ -21,6 +22,10 @@ func NewSystemHandler(e *echo.Echo, us usecase.SystemUsecase) {
 
 	e.GET("/", handler.Root)
 	e.GET("/health", handler.Health)
+	e.GET("/health/live", handler.Live)
+	e.GET("/health/ready", handler.Ready)
+	e.GET("/health/details", handler.Details)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
 }
 
 // Root will let you know, whoami
@@ -30,10 +35,35 @@ func (sh *SystemHandler) Root(c echo.Context) error {
 
 // Health will let you know the heart beats
 func (sh *SystemHandler) Health(c echo.Context) error {
-	err := sh.Usecase.GetHealth()
+	err := sh.Usecase.Ready(c.Request().Context())
 	if err != nil {
 		return c.JSON(response.RespondError(err))
 	}
 
 	return c.JSON(http.StatusOK, &response.Response{Message: "I'm healthy :)"})
 }
+
+// Live answers as long as the process serves requests
+func (sh *SystemHandler) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, &response.Response{Message: "alive"})
+}
+
+// Ready fails while a critical component is down
+func (sh *SystemHandler) Ready(c echo.Context) error {
+	err := sh.Usecase.Ready(c.Request().Context())
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(http.StatusOK, &response.Response{Message: "ready"})
+}
+
+// Details reports the state of every component
+func (sh *SystemHandler) Details(c echo.Context) error {
+	report := sh.Usecase.Details(c.Request().Context())
+	if report.Status == usecase.StatusDown {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
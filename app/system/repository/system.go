@@ -1,11 +1,16 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"ot-recorder/infrastructure/metrics"
+	"time"
 )
 
 type SystemRepository interface {
-	DBCheck() (bool, error)
+	DBCheck(ctx context.Context) (time.Duration, error)
+	SchemaVersion(ctx context.Context) (version uint, dirty bool, err error)
 }
 
 type systemRepository struct {
@@ -18,10 +23,27 @@ func NewSystemRepository(db *sql.DB) SystemRepository {
 	}
 }
 
-func (r *systemRepository) DBCheck() (bool, error) {
-	if err := r.db.Ping(); err == nil {
-		return true, nil
+// DBCheck pings the database and returns the round trip latency
+func (r *systemRepository) DBCheck(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	defer metrics.ObserveDBQuery("system", "DBCheck", start)
+
+	err := r.db.PingContext(ctx)
+
+	return time.Since(start), err
+}
+
+// golang-migrate keeps a single row with the applied version
+const getSchemaVersion = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+// SchemaVersion returns the applied migration version, 0 when no migration is applied
+func (r *systemRepository) SchemaVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	defer metrics.ObserveDBQuery("system", "SchemaVersion", time.Now())
+
+	err = r.db.QueryRowContext(ctx, getSchemaVersion).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
 	}
 
-	return false, nil
+	return version, dirty, err
 }
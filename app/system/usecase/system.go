@@ -1,36 +1,178 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"ot-recorder/app"
 	"ot-recorder/app/response"
 	"ot-recorder/app/system/repository"
+	"sync"
+	"time"
 )
 
+// component statuses of the health report
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDisabled = "disabled"
+)
+
+// ErrCheckDisabled returned by a HealthCheck of a component which is turned off
+var ErrCheckDisabled = errors.New("disabled")
+
+// HealthCheck reports the state of a component, the service is not ready while a critical one is down
+type HealthCheck struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) (details map[string]interface{}, err error)
+}
+
+// ComponentHealth result of a HealthCheck
+type ComponentHealth struct {
+	Name     string                 `json:"name"`
+	Status   string                 `json:"status"`
+	Critical bool                   `json:"critical"`
+	Error    string                 `json:"error,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthReport detailed health of the service
+type HealthReport struct {
+	Status     string            `json:"status"`
+	Version    string            `json:"version"`
+	Uptime     string            `json:"uptime"`
+	Components []ComponentHealth `json:"components"`
+}
+
 type SystemUsecase interface {
-	GetHealth() error
+	Register(check HealthCheck)
+	Ready(ctx context.Context) error
+	Details(ctx context.Context) *HealthReport
 }
 
 type systemUsecase struct {
-	repo repository.SystemRepository
+	repo           repository.SystemRepository
+	schemaVersion  uint
+	contextTimeout time.Duration
+	startedAt      time.Time
+
+	mu     sync.RWMutex
+	checks []HealthCheck
 }
 
-func NewSystemUsecase(repo repository.SystemRepository) SystemUsecase {
-	return &systemUsecase{
-		repo: repo,
+// NewSystemUsecase schemaVersion is the latest embedded migration, 0 skips the schema comparison
+func NewSystemUsecase(repo repository.SystemRepository, schemaVersion uint, timeout time.Duration) SystemUsecase {
+	u := &systemUsecase{
+		repo:           repo,
+		schemaVersion:  schemaVersion,
+		contextTimeout: timeout,
+		startedAt:      time.Now(),
 	}
+
+	u.Register(HealthCheck{Name: "database", Critical: true, Check: u.checkDB})
+	u.Register(HealthCheck{Name: "schema", Critical: true, Check: u.checkSchema})
+
+	return u
+}
+
+// Register adds a component to the readiness and detailed health checks
+func (u *systemUsecase) Register(check HealthCheck) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.checks = append(u.checks, check)
+}
+
+// Ready returns an error when a critical component is down
+func (u *systemUsecase) Ready(c context.Context) error {
+	for _, component := range u.run(c) {
+		if component.Critical && component.Status == StatusDown {
+			return response.WrapError(
+				fmt.Errorf("%s is down: %s", component.Name, component.Error),
+				http.StatusServiceUnavailable,
+			)
+		}
+	}
+
+	return nil
 }
 
-func (u *systemUsecase) GetHealth() error {
-	// check db
-	dbOnline, err := u.repo.DBCheck()
+// Details reports every component, status is down when a critical component is down
+func (u *systemUsecase) Details(c context.Context) *HealthReport {
+	report := &HealthReport{
+		Status:     StatusUp,
+		Version:    app.Version,
+		Uptime:     time.Since(u.startedAt).Round(time.Second).String(),
+		Components: u.run(c),
+	}
+
+	for _, component := range report.Components {
+		if component.Critical && component.Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (u *systemUsecase) run(c context.Context) []ComponentHealth {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	u.mu.RLock()
+	checks := make([]HealthCheck, len(u.checks))
+	copy(checks, u.checks)
+	u.mu.RUnlock()
+
+	components := make([]ComponentHealth, len(checks))
+
+	for i, check := range checks {
+		details, err := check.Check(ctx)
+
+		components[i] = ComponentHealth{
+			Name:     check.Name,
+			Status:   StatusUp,
+			Critical: check.Critical,
+			Details:  details,
+		}
+
+		switch {
+		case errors.Is(err, ErrCheckDisabled):
+			components[i].Status = StatusDisabled
+		case err != nil:
+			components[i].Status = StatusDown
+			components[i].Error = err.Error()
+		}
+	}
+
+	return components
+}
+
+func (u *systemUsecase) checkDB(ctx context.Context) (map[string]interface{}, error) {
+	latency, err := u.repo.DBCheck(ctx)
+
+	return map[string]interface{}{"latency_ms": float64(latency.Microseconds()) / 1000}, err
+}
+
+func (u *systemUsecase) checkSchema(ctx context.Context) (map[string]interface{}, error) {
+	version, dirty, err := u.repo.SchemaVersion(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !dbOnline {
-		return response.WrapError(errors.New("DB is offline"), http.StatusServiceUnavailable)
+	details := map[string]interface{}{"version": version, "dirty": dirty}
+	if u.schemaVersion > 0 {
+		details["expected"] = u.schemaVersion
 	}
 
-	return nil
+	switch {
+	case dirty:
+		return details, fmt.Errorf("migration %d failed, fix it and force the version", version)
+	case version < u.schemaVersion:
+		return details, fmt.Errorf("schema version %d is behind %d, run migrate up", version, u.schemaVersion)
+	}
+
+	return details, nil
 }
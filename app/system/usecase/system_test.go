@@ -0,0 +1,91 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"ot-recorder/app/system/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type systemRepo struct {
+	dbErr   error
+	version uint
+	dirty   bool
+}
+
+func (r *systemRepo) DBCheck(ctx context.Context) (time.Duration, error) {
+	return time.Millisecond, r.dbErr
+}
+
+func (r *systemRepo) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	return r.version, r.dirty, nil
+}
+
+func TestReady(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		u := usecase.NewSystemUsecase(&systemRepo{version: 2}, 2, time.Second)
+		assert.NoError(t, u.Ready(context.TODO()))
+	})
+
+	t.Run("db down", func(t *testing.T) {
+		u := usecase.NewSystemUsecase(&systemRepo{version: 2, dbErr: errors.New("connection refused")}, 2, time.Second)
+		assert.EqualError(t, u.Ready(context.TODO()), "database is down: connection refused")
+	})
+
+	t.Run("schema behind", func(t *testing.T) {
+		u := usecase.NewSystemUsecase(&systemRepo{version: 1}, 2, time.Second)
+		assert.Error(t, u.Ready(context.TODO()))
+	})
+
+	t.Run("schema dirty", func(t *testing.T) {
+		u := usecase.NewSystemUsecase(&systemRepo{version: 2, dirty: true}, 2, time.Second)
+		assert.Error(t, u.Ready(context.TODO()))
+	})
+
+	t.Run("non critical component down", func(t *testing.T) {
+		u := usecase.NewSystemUsecase(&systemRepo{version: 2}, 2, time.Second)
+		u.Register(usecase.HealthCheck{
+			Name: "webhook",
+			Check: func(ctx context.Context) (map[string]interface{}, error) {
+				return nil, errors.New("timeout")
+			},
+		})
+		assert.NoError(t, u.Ready(context.TODO()))
+	})
+}
+
+func TestDetails(t *testing.T) {
+	u := usecase.NewSystemUsecase(&systemRepo{version: 2}, 2, time.Second)
+	u.Register(usecase.HealthCheck{
+		Name:     "queue",
+		Critical: true,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, usecase.ErrCheckDisabled
+		},
+	})
+	u.Register(usecase.HealthCheck{
+		Name: "webhook",
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, errors.New("timeout")
+		},
+	})
+
+	report := u.Details(context.TODO())
+	assert.Equal(t, usecase.StatusUp, report.Status)
+
+	statuses := map[string]string{}
+	for _, c := range report.Components {
+		statuses[c.Name] = c.Status
+	}
+
+	assert.Equal(t, map[string]string{
+		"database": usecase.StatusUp,
+		"schema":   usecase.StatusUp,
+		"queue":    usecase.StatusDisabled,
+		"webhook":  usecase.StatusDown,
+	}, statuses)
+	assert.Equal(t, uint(2), report.Components[1].Details["version"])
+}
@@ -0,0 +1,302 @@
+'use strict';
+
+const api = '/api/v1';
+const playbackInterval = 200; // ms per track point
+
+const map = L.map('map').setView([23.8103, 90.4125], 12);
+L.tileLayer('https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png', {
+  maxZoom: 19,
+  attribution: '&copy; <a href="https://www.openstreetmap.org/copyright">OpenStreetMap</a> contributors',
+}).addTo(map);
+
+const state = {
+  users: [],
+  markers: {}, // username/device -> marker of the last location
+  selected: null,
+  track: [],
+  layers: L.layerGroup().addTo(map),
+  playbackMarker: null,
+  timer: null,
+};
+
+const $ = (id) => document.getElementById(id);
+
+async function getJSON(path, params) {
+  const query = new URLSearchParams(params).toString();
+  const res = await fetch(`${api}${path}?${query}`);
+  const body = await res.json();
+  if (!res.ok) {
+    throw new Error(body.message || res.statusText);
+  }
+
+  return body.data;
+}
+
+function formatTime(epoch) {
+  return new Date(epoch * 1000).toLocaleString();
+}
+
+function formatDuration(seconds) {
+  const h = Math.floor(seconds / 3600);
+  const m = Math.round((seconds % 3600) / 60);
+
+  return h > 0 ? `${h}h ${m}m` : `${m}m`;
+}
+
+function escapeHTML(text) {
+  const div = document.createElement('div');
+  div.textContent = text;
+
+  return div.innerHTML;
+}
+
+function popupHTML(l) {
+  return `<strong>${escapeHTML(l.username)}</strong> &middot; ${escapeHTML(l.device)}<br>
+    ${escapeHTML(l.date_time)}<br>
+    <span class="muted">battery ${escapeHTML(l.battery_level || '?')} &middot; accuracy ${l.accuracy || '?'} m</span>`;
+}
+
+// last locations, one marker per device
+
+const markerKey = (l) => `${l.username}/${l.device}`;
+
+function showLastLocation(l) {
+  const latLng = [l.latitude, l.longitude];
+  let marker = state.markers[markerKey(l)];
+  if (!marker) {
+    marker = L.marker(latLng, { title: `${l.username} · ${l.device}` }).addTo(map);
+    state.markers[markerKey(l)] = marker;
+  }
+
+  marker.setLatLng(latLng).bindPopup(popupHTML(l));
+
+  const list = document.querySelector(`#user-list li[data-user="${CSS.escape(l.username)}"] .devices`);
+  if (!list) {
+    return;
+  }
+
+  let item = list.querySelector(`[data-device="${CSS.escape(l.device)}"]`);
+  if (!item) {
+    item = document.createElement('div');
+    item.className = 'muted';
+    item.dataset.device = l.device;
+    item.addEventListener('click', (e) => {
+      e.stopPropagation();
+      map.setView(state.markers[markerKey(l)].getLatLng(), 16);
+    });
+    list.appendChild(item);
+  }
+  item.textContent = `${l.device} · ${l.date_time}`;
+}
+
+function addUser(username) {
+  if (state.users.includes(username)) {
+    return;
+  }
+
+  state.users.push(username);
+
+  const li = document.createElement('li');
+  li.dataset.user = username;
+  li.innerHTML = `<strong>${escapeHTML(username)}</strong><div class="devices"></div>`;
+  li.addEventListener('click', () => selectUser(username));
+  $('user-list').appendChild(li);
+}
+
+async function loadUsers() {
+  const filter = (new URLSearchParams(window.location.search).get('users') || '')
+    .split(',').map((u) => u.trim()).filter(Boolean);
+  const shown = (username) => filter.length === 0 || filter.includes(username);
+
+  try {
+    const [users, locations] = await Promise.all([getJSON('/users'), getJSON('/last-locations')]);
+
+    (users || []).filter(shown).forEach(addUser);
+
+    const bounds = [];
+    (locations || []).filter((l) => shown(l.username)).forEach((l) => {
+      showLastLocation(l);
+      bounds.push([l.latitude, l.longitude]);
+    });
+
+    if (bounds.length > 0) {
+      map.fitBounds(bounds, { maxZoom: 15, padding: [40, 40] });
+    }
+  } catch (err) {
+    $('user-list').textContent = err.message;
+  }
+
+  return shown;
+}
+
+function listenLive(shown) {
+  if (!window.EventSource) {
+    return;
+  }
+
+  const source = new EventSource(`${api}/stream`);
+  source.addEventListener('location', (e) => {
+    const l = JSON.parse(e.data);
+    if (shown(l.username)) {
+      addUser(l.username);
+      showLastLocation(l);
+    }
+  });
+}
+
+// history of the selected user
+
+function dayRange() {
+  const day = $('day').valueAsDate || new Date();
+  const from = new Date(day.getFullYear(), day.getMonth(), day.getDate());
+  const to = new Date(from.getTime() + 24 * 3600 * 1000 - 1000);
+
+  return { from: Math.floor(from.getTime() / 1000), to: Math.floor(to.getTime() / 1000) };
+}
+
+function selectUser(username) {
+  state.selected = username;
+  document.querySelectorAll('#user-list li').forEach((li) => {
+    li.classList.toggle('active', li.dataset.user === username);
+  });
+
+  $('history').hidden = false;
+  $('history-title').textContent = `History of ${username}`;
+  loadHistory();
+}
+
+async function loadHistory() {
+  stopPlayback();
+  state.layers.clearLayers();
+  state.playbackMarker = null;
+  $('trips').innerHTML = '';
+  $('stays').innerHTML = '';
+
+  const params = { username: state.selected, ...dayRange() };
+
+  try {
+    const [track, trips, stays] = await Promise.all([
+      getJSON('/history', params),
+      getJSON('/trips', params),
+      getJSON('/stays', params),
+    ]);
+
+    showTrack(track || []);
+    showTrips(trips || []);
+    showStays(stays || []);
+  } catch (err) {
+    $('slider-time').textContent = err.message;
+  }
+}
+
+function showTrack(track) {
+  state.track = track;
+
+  const slider = $('slider');
+  slider.max = Math.max(track.length - 1, 0);
+  slider.value = 0;
+
+  if (track.length === 0) {
+    $('slider-time').textContent = 'no locations on this day';
+    return;
+  }
+
+  const byDevice = {};
+  track.forEach((p) => {
+    (byDevice[p.device] = byDevice[p.device] || []).push([p.lat, p.lon]);
+  });
+
+  const lines = Object.values(byDevice).map((latLngs) => L.polyline(latLngs, { weight: 3 }).addTo(state.layers));
+  map.fitBounds(L.featureGroup(lines).getBounds(), { maxZoom: 16, padding: [40, 40] });
+
+  state.playbackMarker = L.circleMarker([track[0].lat, track[0].lon], {
+    radius: 7, color: '#d33', fillOpacity: 0.9,
+  }).addTo(state.layers);
+  movePlayback(0);
+}
+
+function segmentItem(list, html, onClick) {
+  const li = document.createElement('li');
+  li.innerHTML = html;
+  li.addEventListener('click', onClick);
+  list.appendChild(li);
+}
+
+function showTrips(trips) {
+  trips.forEach((t) => {
+    segmentItem($('trips'), `${formatTime(t.from)} &middot; ${escapeHTML(t.device)}<br>
+      <span class="muted">${(t.distance / 1000).toFixed(1)} km in ${formatDuration(t.duration)}</span>`, () => {
+      map.fitBounds([[t.start_lat, t.start_lon], [t.end_lat, t.end_lon]], { padding: [60, 60] });
+      seekTo(t.from);
+    });
+  });
+}
+
+function showStays(stays) {
+  stays.forEach((s) => {
+    L.circle([s.lat, s.lon], { radius: 100, color: '#2a2' })
+      .bindPopup(`${formatTime(s.from)} – ${formatTime(s.to)}`)
+      .addTo(state.layers);
+
+    segmentItem($('stays'), `${formatTime(s.from)} &middot; ${escapeHTML(s.device)}<br>
+      <span class="muted">${formatDuration(s.duration)}</span>`, () => {
+      map.setView([s.lat, s.lon], 17);
+      seekTo(s.from);
+    });
+  });
+}
+
+// playback
+
+function movePlayback(index) {
+  const p = state.track[index];
+  if (!p || !state.playbackMarker) {
+    return;
+  }
+
+  state.playbackMarker.setLatLng([p.lat, p.lon]);
+  $('slider').value = index;
+  $('slider-time').textContent = `${formatTime(p.created_at)} · ${p.device}` + (p.vel ? ` · ${p.vel} km/h` : '');
+}
+
+function seekTo(epoch) {
+  const index = state.track.findIndex((p) => p.created_at >= epoch);
+  movePlayback(index < 0 ? state.track.length - 1 : index);
+}
+
+function stopPlayback() {
+  clearInterval(state.timer);
+  state.timer = null;
+  $('play').innerHTML = '&#9654;';
+}
+
+function togglePlayback() {
+  if (state.timer) {
+    stopPlayback();
+    return;
+  }
+
+  if (Number($('slider').value) >= state.track.length - 1) {
+    movePlayback(0);
+  }
+
+  $('play').innerHTML = '&#10074;&#10074;';
+  state.timer = setInterval(() => {
+    const next = Number($('slider').value) + 1;
+    if (next >= state.track.length) {
+      stopPlayback();
+      return;
+    }
+
+    movePlayback(next);
+  }, playbackInterval);
+}
+
+// wiring
+
+$('day').valueAsDate = new Date();
+$('day').addEventListener('change', () => state.selected && loadHistory());
+$('slider').addEventListener('input', (e) => movePlayback(Number(e.target.value)));
+$('play').addEventListener('click', togglePlayback);
+
+loadUsers().then(listenLive);
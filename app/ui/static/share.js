@@ -0,0 +1,73 @@
+'use strict';
+
+const refreshInterval = 30 * 1000; // ms
+
+const token = window.location.pathname.split('/').filter(Boolean).pop();
+
+const map = L.map('map').setView([23.8103, 90.4125], 12);
+L.tileLayer('https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png', {
+  maxZoom: 19,
+  attribution: '&copy; <a href="https://www.openstreetmap.org/copyright">OpenStreetMap</a> contributors',
+}).addTo(map);
+
+const state = {
+  marker: null,
+  track: null,
+  fitted: false,
+  timer: null,
+};
+
+const $ = (id) => document.getElementById(id);
+
+function formatTime(epoch) {
+  return new Date(epoch * 1000).toLocaleString();
+}
+
+function show(shared) {
+  const l = shared.location;
+  const latLng = [l.latitude, l.longitude];
+
+  $('share-title').textContent = `Location of ${shared.username}`;
+  $('share-info').textContent = `${l.date_time} · link expires ${formatTime(shared.expires_at)}`;
+
+  if (!state.marker) {
+    state.marker = L.marker(latLng, { title: shared.username }).addTo(map);
+  }
+  state.marker.setLatLng(latLng);
+
+  if (state.track) {
+    state.track.remove();
+    state.track = null;
+  }
+
+  if (shared.track && shared.track.length > 1) {
+    state.track = L.polyline(shared.track.map((p) => [p.lat, p.lon]), { weight: 3 }).addTo(map);
+  }
+
+  if (!state.fitted) {
+    state.fitted = true;
+    if (state.track) {
+      map.fitBounds(state.track.getBounds().extend(latLng), { maxZoom: 16, padding: [40, 40] });
+    } else {
+      map.setView(latLng, 16);
+    }
+  }
+}
+
+async function refresh() {
+  try {
+    const res = await fetch(`/s/${encodeURIComponent(token)}/location`);
+    const body = await res.json();
+    if (!res.ok) {
+      throw new Error(body.message || res.statusText);
+    }
+
+    show(body.data);
+  } catch (err) {
+    $('share-info').textContent = err.message;
+    clearInterval(state.timer);
+  }
+}
+
+refresh();
+state.timer = setInterval(refresh, refreshInterval);
@@ -0,0 +1,43 @@
+// Package ui serves the embedded web map, a single page using the recorder JSON APIs
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed static
+var static embed.FS
+
+// SharePage returns the page of public share links, it loads its data from /s/<token>/location
+func SharePage() []byte {
+	page, err := static.ReadFile("static/share.html")
+	if err != nil {
+		panic(err)
+	}
+
+	return page
+}
+
+// NewUIHandler serves the web map at /ui and its assets under /ui/
+func NewUIHandler(e *echo.Echo) {
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	index, err := fs.ReadFile(assets, "index.html")
+	if err != nil {
+		panic(err)
+	}
+
+	fileServer := http.StripPrefix("/ui/", http.FileServer(http.FS(assets)))
+
+	e.GET("/ui", func(c echo.Context) error {
+		return c.HTMLBlob(http.StatusOK, index)
+	})
+	e.GET("/ui/*", echo.WrapHandler(fileServer))
+}
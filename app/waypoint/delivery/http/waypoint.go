@@ -0,0 +1,131 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/middlewares"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WaypointHandler represent the http handler for waypoints
+type WaypointHandler struct {
+	WUseCase model.WaypointUsecase
+}
+
+// CreateWaypointRequest username "*" for the whole fleet(admins only), default the caller.
+// An empty device is every device of the user, radius in meters, default 100
+type CreateWaypointRequest struct {
+	Username    string  `json:"username"`
+	Device      string  `json:"device"`
+	Description string  `json:"description"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Radius      int32   `json:"radius"`
+}
+
+func NewWaypointHandler(e *echo.Echo, us model.WaypointUsecase) {
+	handler := &WaypointHandler{
+		WUseCase: us,
+	}
+
+	// admins are told apart by the caller, the authenticated user
+	v1 := e.Group("/api/v1", middlewares.AuthenticatedCaller)
+	v1.GET("/waypoints", handler.List)
+	v1.POST("/waypoints", handler.Create)
+	v1.PATCH("/waypoints/:id", handler.Update)
+	v1.DELETE("/waypoints/:id", handler.Delete)
+}
+
+// List returns the waypoints of the caller(x-limit-u) or of ?username= for admins, of ?device=
+func (h *WaypointHandler) List(c echo.Context) error {
+	caller := c.Request().Header.Get("x-limit-u")
+	if caller == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	waypoints, err := h.WUseCase.List(c.Request().Context(), caller, c.QueryParam("username"),
+		c.QueryParam("device"))
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("request success", waypoints))
+}
+
+// Create defines a waypoint pushed to the devices of a user or of the whole fleet
+func (h *WaypointHandler) Create(c echo.Context) error {
+	caller := c.Request().Header.Get("x-limit-u")
+	if caller == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	var waypointReq CreateWaypointRequest
+	if err := c.Bind(&waypointReq); err != nil {
+		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+	}
+
+	waypoint := &model.Waypoint{
+		Username:    waypointReq.Username,
+		Device:      waypointReq.Device,
+		Description: waypointReq.Description,
+		Lat:         waypointReq.Lat,
+		Lon:         waypointReq.Lon,
+		Radius:      waypointReq.Radius,
+	}
+
+	if err := h.WUseCase.Create(c.Request().Context(), caller, waypoint); err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	_, res := response.RespondSuccess("waypoint created", waypoint)
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+// Update moves, resizes or renames a waypoint the caller(x-limit-u) manages
+func (h *WaypointHandler) Update(c echo.Context) error {
+	caller := c.Request().Header.Get("x-limit-u")
+	if caller == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("invalid waypoint id")))
+	}
+
+	var update model.WaypointUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(response.RespondError(response.ErrUnprocessableEntity, err))
+	}
+
+	waypoint, err := h.WUseCase.Update(c.Request().Context(), caller, id, update)
+	if err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("waypoint updated", waypoint))
+}
+
+// Delete removes a waypoint the caller(x-limit-u) manages, devices that got it keep it
+func (h *WaypointHandler) Delete(c echo.Context) error {
+	caller := c.Request().Header.Get("x-limit-u")
+	if caller == "" {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("x-limit-u header is missing")))
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(response.RespondError(response.ErrBadRequest, errors.New("invalid waypoint id")))
+	}
+
+	if err := h.WUseCase.Delete(c.Request().Context(), caller, id); err != nil {
+		return c.JSON(response.RespondError(err))
+	}
+
+	return c.JSON(response.RespondSuccess("waypoint deleted", nil))
+}
@@ -0,0 +1,144 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	wHttp "ot-recorder/app/waypoint/delivery/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func buildRequest(method, path, body, username string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	if username != "" {
+		req.Header.Set("x-limit-u", username)
+	}
+
+	rec := httptest.NewRecorder()
+
+	return echo.New().NewContext(req, rec), rec
+}
+
+func TestList(t *testing.T) {
+	mockUsecase := new(mocks.WaypointUsecase)
+	mockUsecase.On("List", mock.Anything, "admin", "dev", "phone").Return([]model.Waypoint{
+		{ID: 3, Username: "dev", Device: "phone", Description: "Office", Lat: 23.8, Lon: 90.4, Radius: 100},
+	}, nil).Once()
+
+	handler := wHttp.WaypointHandler{WUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.GET, "/api/v1/waypoints?username=dev&device=phone", "", "admin")
+	assert.NoError(t, handler.List(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `{"id":3,"username":"dev","device":"phone","description":"Office"`)
+
+	c, rec = buildRequest(echo.GET, "/api/v1/waypoints", "", "")
+	assert.NoError(t, handler.List(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCreate(t *testing.T) {
+	mockUsecase := new(mocks.WaypointUsecase)
+	mockUsecase.On("Create", mock.Anything, "admin", mock.MatchedBy(func(w *model.Waypoint) bool {
+		return w.Username == "*" && w.Description == "HQ" && w.Lat == 23.7 && w.Lon == 90.4 && w.Radius == 250
+	})).Run(func(args mock.Arguments) {
+		args.Get(2).(*model.Waypoint).ID = 7
+	}).Return(nil).Once()
+	mockUsecase.On("Create", mock.Anything, "dev", mock.Anything).
+		Return(response.WrapError(response.ErrForbidden, http.StatusForbidden)).Once()
+
+	handler := wHttp.WaypointHandler{WUseCase: mockUsecase}
+
+	body := `{"username":"*","description":"HQ","lat":23.7,"lon":90.4,"radius":250}`
+
+	c, rec := buildRequest(echo.POST, "/api/v1/waypoints", body, "admin")
+	assert.NoError(t, handler.Create(c))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":7`)
+
+	c, rec = buildRequest(echo.POST, "/api/v1/waypoints", body, "dev")
+	assert.NoError(t, handler.Create(c))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	c, rec = buildRequest(echo.POST, "/api/v1/waypoints", `{"lat":`, "dev")
+	assert.NoError(t, handler.Create(c))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestUpdate(t *testing.T) {
+	mockUsecase := new(mocks.WaypointUsecase)
+	mockUsecase.On("Update", mock.Anything, "dev", int64(3), mock.MatchedBy(func(u model.WaypointUpdate) bool {
+		return *u.Radius == 300 && u.Description == nil && u.Lat == nil
+	})).Return(&model.Waypoint{ID: 3, Username: "dev", Radius: 300}, nil).Once()
+
+	handler := wHttp.WaypointHandler{WUseCase: mockUsecase}
+
+	c, rec := buildRequest(echo.PATCH, "/api/v1/waypoints/3", `{"radius":300}`, "dev")
+	c.SetParamNames("id")
+	c.SetParamValues("3")
+	assert.NoError(t, handler.Update(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"radius":300`)
+
+	c, rec = buildRequest(echo.PATCH, "/api/v1/waypoints/x", `{"radius":300}`, "dev")
+	c.SetParamNames("id")
+	c.SetParamValues("x")
+	assert.NoError(t, handler.Update(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestDelete(t *testing.T) {
+	mockUsecase := new(mocks.WaypointUsecase)
+	mockUsecase.On("Delete", mock.Anything, "dev", int64(3)).Return(nil).Once()
+	mockUsecase.On("Delete", mock.Anything, "dev", int64(4)).Return(response.ErrNotFound).Once()
+
+	handler := wHttp.WaypointHandler{WUseCase: mockUsecase}
+
+	for id, code := range map[string]int{"3": http.StatusOK, "4": http.StatusNotFound} {
+		c, rec := buildRequest(echo.DELETE, "/api/v1/waypoints/"+id, "", "dev")
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		assert.NoError(t, handler.Delete(c))
+		assert.Equal(t, code, rec.Code)
+	}
+
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestSpoofedCaller(t *testing.T) {
+	mockUsecase := new(mocks.WaypointUsecase)
+
+	e := echo.New()
+	wHttp.NewWaypointHandler(e, mockUsecase)
+
+	// a user authenticated by the proxy claims to be an admin to push a waypoint to the whole fleet
+	for _, req := range []*http.Request{
+		httptest.NewRequest(echo.POST, "/api/v1/waypoints",
+			strings.NewReader(`{"username":"*","description":"Office","lat":23.8,"lon":90.4}`)),
+		httptest.NewRequest(echo.PATCH, "/api/v1/waypoints/3", strings.NewReader(`{"radius":500}`)),
+		httptest.NewRequest(echo.DELETE, "/api/v1/waypoints/3", nil),
+		httptest.NewRequest(echo.GET, "/api/v1/waypoints?username=mom", nil),
+	} {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("x-limit-u", "admin")
+		req.SetBasicAuth("dev", "secret")
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code, req.Method)
+	}
+
+	assert.Empty(t, mockUsecase.Calls)
+}
@@ -0,0 +1,75 @@
+package pgsql_test
+
+import (
+	"context"
+	"database/sql"
+	"ot-recorder/app/model"
+	waypointRepo "ot-recorder/app/waypoint/repository/pgsql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaypoint(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	w := &model.Waypoint{
+		Username: "dev", Description: "Office", Lat: 23.8103, Lon: 90.4125, Radius: 100, Rid: "a1b2", Tst: 1000,
+		UpdatedAt: 1000,
+	}
+
+	mock.ExpectQuery("INSERT INTO waypoints").
+		WithArgs("dev", "", "Office", 23.8103, 90.4125, int32(100), "a1b2", int64(1000), int64(1000)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	columns := []string{"id", "username", "device", "description", "lat", "lon", "radius", "rid", "tst", "updated_at"}
+	mock.ExpectQuery("FROM waypoints WHERE username IN \\(\\$1, \\$2\\) AND device IN \\('', \\$3\\) ORDER BY id").
+		WithArgs("dev", model.WaypointsEveryone, "phone").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(7, "dev", "", "Office", 23.8103, 90.4125, 100, "a1b2", 1000, 1000).
+			AddRow(9, "*", "", "HQ", 23.7, 90.4, 250, "c3d4", 1200, 1200))
+	mock.ExpectQuery("FROM waypoints ORDER BY id").WithArgs().WillReturnRows(sqlmock.NewRows(columns))
+
+	mock.ExpectExec("UPDATE waypoints SET").
+		WithArgs("phone", "Office", 23.8103, 90.4125, int32(150), int64(1100), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM waypoints WHERE id").WithArgs(int64(8)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectQuery("SELECT hash FROM waypoint_syncs").WithArgs("dev", "phone").WillReturnError(sql.ErrNoRows)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM waypoint_syncs").WithArgs("dev", "phone").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO waypoint_syncs").WithArgs("dev", "phone", "f00d", int64(1100)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	wr := waypointRepo.NewPgsqlWaypointRepository(db)
+	assert.NoError(t, wr.CreateWaypoint(context.TODO(), w))
+	assert.Equal(t, int64(7), w.ID)
+
+	waypoints, err := wr.GetWaypoints(context.TODO(), "dev", "phone")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Waypoint{*w, {
+		ID: 9, Username: "*", Description: "HQ", Lat: 23.7, Lon: 90.4, Radius: 250, Rid: "c3d4", Tst: 1200,
+		UpdatedAt: 1200,
+	}}, waypoints)
+
+	waypoints, err = wr.GetWaypoints(context.TODO(), "", "")
+	assert.NoError(t, err)
+	assert.Empty(t, waypoints)
+
+	w.Device = "phone"
+	w.Radius = 150
+	w.UpdatedAt = 1100
+	assert.NoError(t, wr.UpdateWaypoint(context.TODO(), w))
+	assert.ErrorIs(t, wr.DeleteWaypoint(context.TODO(), 8), sql.ErrNoRows)
+
+	_, err = wr.GetWaypointSync(context.TODO(), "dev", "phone")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, wr.SaveWaypointSync(context.TODO(), "dev", "phone", "f00d", 1100))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"database/sql"
+	"ot-recorder/app/model"
+	"ot-recorder/app/waypoint/repository/mysql"
+	"ot-recorder/app/waypoint/repository/pgsql"
+	"ot-recorder/app/waypoint/repository/sqlite"
+)
+
+// NewWaypointRepository returns the waypoint repository for the given database type
+func NewWaypointRepository(dbType string, dbClient *sql.DB) model.WaypointRepository {
+	switch dbType {
+	case "postgres":
+		return pgsql.NewPgsqlWaypointRepository(dbClient)
+	case "mysql":
+		return mysql.NewMysqlWaypointRepository(dbClient)
+	default:
+		return sqlite.NewSqliteWaypointRepository(dbClient)
+	}
+}
@@ -0,0 +1,233 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/metrics"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//nolint:gochecknoglobals
+var (
+	tracer         = otel.Tracer("ot-recorder/app/waypoint/repository/sqlite")
+	spanAttributes = trace.WithAttributes(semconv.DBSystemSqlite, semconv.DBSQLTableKey.String("waypoints"))
+)
+
+type waypointRepository struct {
+	db *sql.DB
+}
+
+func NewSqliteWaypointRepository(db *sql.DB) model.WaypointRepository {
+	return &waypointRepository{
+		db: db,
+	}
+}
+
+const createWaypoint = `INSERT INTO waypoints (
+  username, device, description, lat, lon, radius, rid, tst, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func (r *waypointRepository) CreateWaypoint(ctx context.Context, waypoint *model.Waypoint) error {
+	defer metrics.ObserveDBQuery("waypoint", "CreateWaypoint", time.Now())
+
+	ctx, span := tracer.Start(ctx, "waypointRepository.CreateWaypoint", spanAttributes)
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, createWaypoint, waypointArgs(waypoint)...)
+	if err != nil {
+		return err
+	}
+
+	waypoint.ID, err = res.LastInsertId()
+
+	return err
+}
+
+const waypointSelectColumns = `id, username, device, description, lat, lon, radius, rid, tst, updated_at`
+
+const getWaypoint = `SELECT ` + waypointSelectColumns + ` FROM waypoints WHERE id = ?`
+
+// GetWaypoint returns sql.ErrNoRows when there is no waypoint with the id
+func (r *waypointRepository) GetWaypoint(ctx context.Context, id int64) (model.Waypoint, error) {
+	defer metrics.ObserveDBQuery("waypoint", "GetWaypoint", time.Now())
+
+	ctx, span := tracer.Start(ctx, "waypointRepository.GetWaypoint", spanAttributes)
+	defer span.End()
+
+	return scanWaypoint(r.db.QueryRowContext(ctx, getWaypoint, id))
+}
+
+// GetWaypoints returns the waypoints of a user, with those of every user, of every user when
+// username is empty. Those of a device are the ones of every device of the user & its own.
+func (r *waypointRepository) GetWaypoints(ctx context.Context, username, device string) ([]model.Waypoint, error) {
+	defer metrics.ObserveDBQuery("waypoint", "GetWaypoints", time.Now())
+
+	ctx, span := tracer.Start(ctx, "waypointRepository.GetWaypoints", spanAttributes)
+	defer span.End()
+
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	if username != "" {
+		where = append(where, "username IN (?, ?)")
+		args = append(args, username, model.WaypointsEveryone)
+	}
+
+	if device != "" {
+		where = append(where, "device IN ('', ?)")
+		args = append(args, device)
+	}
+
+	query := `SELECT ` + waypointSelectColumns + ` FROM waypoints`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+
+	rows, err := r.db.QueryContext(ctx, query+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	waypoints := []model.Waypoint{}
+
+	for rows.Next() {
+		w, err := scanWaypoint(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		waypoints = append(waypoints, w)
+	}
+
+	return waypoints, rows.Err()
+}
+
+const updateWaypoint = `UPDATE waypoints SET device = ?, description = ?, lat = ?, lon = ?, radius = ?,
+updated_at = ? WHERE id = ?`
+
+// UpdateWaypoint returns sql.ErrNoRows when there is no waypoint with the id
+func (r *waypointRepository) UpdateWaypoint(ctx context.Context, waypoint *model.Waypoint) error {
+	defer metrics.ObserveDBQuery("waypoint", "UpdateWaypoint", time.Now())
+
+	ctx, span := tracer.Start(ctx, "waypointRepository.UpdateWaypoint", spanAttributes)
+	defer span.End()
+
+	return affectedOne(r.db.ExecContext(ctx, updateWaypoint, waypoint.Device, waypoint.Description,
+		waypoint.Lat, waypoint.Lon, waypoint.Radius, waypoint.UpdatedAt, waypoint.ID))
+}
+
+const deleteWaypoint = `DELETE FROM waypoints WHERE id = ?`
+
+// DeleteWaypoint returns sql.ErrNoRows when there is no waypoint with the id
+func (r *waypointRepository) DeleteWaypoint(ctx context.Context, id int64) error {
+	defer metrics.ObserveDBQuery("waypoint", "DeleteWaypoint", time.Now())
+
+	ctx, span := tracer.Start(ctx, "waypointRepository.DeleteWaypoint", spanAttributes)
+	defer span.End()
+
+	return affectedOne(r.db.ExecContext(ctx, deleteWaypoint, id))
+}
+
+const getWaypointSync = `SELECT hash FROM waypoint_syncs WHERE username = ? AND device = ?`
+
+// GetWaypointSync returns the hash of the waypoints last sent to the device,
+// sql.ErrNoRows when none were sent
+func (r *waypointRepository) GetWaypointSync(ctx context.Context, username, device string) (string, error) {
+	defer metrics.ObserveDBQuery("waypoint", "GetWaypointSync", time.Now())
+
+	ctx, span := tracer.Start(ctx, "waypointRepository.GetWaypointSync", spanAttributes)
+	defer span.End()
+
+	var hash string
+	err := r.db.QueryRowContext(ctx, getWaypointSync, username, device).Scan(&hash)
+
+	return hash, err
+}
+
+const (
+	deleteWaypointSync = `DELETE FROM waypoint_syncs WHERE username = ? AND device = ?`
+	createWaypointSync = `INSERT INTO waypoint_syncs (username, device, hash, synced_at) VALUES (?, ?, ?, ?)`
+)
+
+// SaveWaypointSync stores the hash of the waypoints sent to the device, replacing the previous one
+func (r *waypointRepository) SaveWaypointSync(
+	ctx context.Context,
+	username, device, hash string,
+	syncedAt int64,
+) error {
+	defer metrics.ObserveDBQuery("waypoint", "SaveWaypointSync", time.Now())
+
+	ctx, span := tracer.Start(ctx, "waypointRepository.SaveWaypointSync", spanAttributes)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, deleteWaypointSync, username, device); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, createWaypointSync, username, device, hash, syncedAt); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func waypointArgs(w *model.Waypoint) []interface{} {
+	return []interface{}{w.Username, w.Device, w.Description, w.Lat, w.Lon, w.Radius, w.Rid, w.Tst, w.UpdatedAt}
+}
+
+// rowScanner a row of QueryRowContext or rows of QueryContext
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWaypoint(row rowScanner) (model.Waypoint, error) {
+	var w model.Waypoint
+	err := row.Scan(
+		&w.ID,
+		&w.Username,
+		&w.Device,
+		&w.Description,
+		&w.Lat,
+		&w.Lon,
+		&w.Radius,
+		&w.Rid,
+		&w.Tst,
+		&w.UpdatedAt,
+	)
+
+	return w, err
+}
+
+func affectedOne(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
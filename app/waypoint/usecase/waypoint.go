@@ -0,0 +1,421 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/response"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/tracing"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	defaultRadius     = 100
+	maxDescriptionLen = 50
+	maxNameLen        = 20
+	ridBytes          = 16
+
+	// waypoints of a device are reloaded after this long when unchanged here, changes by
+	// other instances reach devices within it
+	syncCacheTTL = 5 * time.Minute
+)
+
+var tracer = otel.Tracer("ot-recorder/app/waypoint/usecase") //nolint:gochecknoglobals
+
+//nolint:gochecknoglobals
+var errInternal = response.WrapError(
+	errors.New("internal server error, please report to admin"),
+	http.StatusInternalServerError,
+)
+
+type waypointUsecase struct {
+	repo           model.WaypointRepository
+	admins         map[string]bool
+	contextTimeout time.Duration
+
+	mu      sync.Mutex
+	version uint64 // changes of waypoints here
+	syncs   map[string]deviceSync
+}
+
+// deviceSync the waypoints of a device & those it got
+type deviceSync struct {
+	hash     string // of the waypoints of the device when loaded
+	none     bool   // the device has no waypoints
+	version  uint64
+	loadedAt time.Time
+	synced   string // hash of the waypoints the device confirmed
+	sent     string // hash of the waypoints sent in the response to the message of sentTst
+	sentTst  int64
+}
+
+func NewWaypointUsecase(repo model.WaypointRepository, admins []string, timeout time.Duration) model.WaypointUsecase {
+	u := &waypointUsecase{
+		repo:           repo,
+		admins:         map[string]bool{},
+		contextTimeout: timeout,
+		syncs:          map[string]deviceSync{},
+	}
+
+	for _, admin := range admins {
+		u.admins[admin] = true
+	}
+
+	return u
+}
+
+// Create stores a waypoint of the caller, admins may create those of every user & of the whole fleet
+func (u *waypointUsecase) Create(c context.Context, caller string, waypoint *model.Waypoint) (err error) {
+	c, span := tracer.Start(c, "waypointUsecase.Create")
+	defer func() { tracing.End(span, err) }()
+
+	if waypoint.Username == "" {
+		waypoint.Username = caller
+	}
+
+	if waypoint.Username != caller && !u.admins[caller] {
+		return response.WrapError(errors.New("only admins may create waypoints of other users"), http.StatusForbidden)
+	}
+
+	if waypoint.Radius == 0 {
+		waypoint.Radius = defaultRadius
+	}
+
+	if err = validateWaypoint(waypoint); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	// devices know a waypoint by its tst, it must not be the one of another waypoint they get
+	existing, err := u.repo.GetWaypoints(ctx, usersOf(waypoint.Username), "")
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	waypoint.Tst = time.Now().Unix()
+	for _, w := range existing {
+		if w.Tst >= waypoint.Tst {
+			waypoint.Tst = w.Tst + 1
+		}
+	}
+
+	if waypoint.Rid, err = newRid(); err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	waypoint.UpdatedAt = time.Now().Unix()
+
+	if err = u.repo.CreateWaypoint(ctx, waypoint); err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	u.changed()
+
+	return nil
+}
+
+// List returns the waypoints of the user & of the whole fleet, of the device & every device
+// when it is set. Admins without a username get every waypoint, others those of themselves
+func (u *waypointUsecase) List(
+	c context.Context,
+	caller, username, device string,
+) (waypoints []model.Waypoint, err error) {
+	c, span := tracer.Start(c, "waypointUsecase.List")
+	defer func() { tracing.End(span, err) }()
+
+	if !u.admins[caller] {
+		if username != "" && username != caller {
+			return nil, response.WrapError(errors.New("only admins may list waypoints of other users"),
+				http.StatusForbidden)
+		}
+
+		username = caller
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	waypoints, err = u.repo.GetWaypoints(ctx, username, device)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	return waypoints, nil
+}
+
+// Update changes a waypoint the caller may manage, nil fields are kept
+func (u *waypointUsecase) Update(
+	c context.Context,
+	caller string,
+	id int64,
+	update model.WaypointUpdate,
+) (waypoint *model.Waypoint, err error) {
+	c, span := tracer.Start(c, "waypointUsecase.Update")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	w, err := u.manageable(ctx, caller, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Device != nil {
+		w.Device = *update.Device
+	}
+
+	if update.Description != nil {
+		w.Description = *update.Description
+	}
+
+	if update.Lat != nil {
+		w.Lat = *update.Lat
+	}
+
+	if update.Lon != nil {
+		w.Lon = *update.Lon
+	}
+
+	if update.Radius != nil {
+		w.Radius = *update.Radius
+	}
+
+	if err = validateWaypoint(&w); err != nil {
+		return nil, err
+	}
+
+	w.UpdatedAt = time.Now().Unix()
+
+	err = u.repo.UpdateWaypoint(ctx, &w)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, response.ErrNotFound
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil, errInternal
+	}
+
+	u.changed()
+
+	return &w, nil
+}
+
+// Delete removes a waypoint the caller may manage, devices that got it keep it
+func (u *waypointUsecase) Delete(c context.Context, caller string, id int64) (err error) {
+	c, span := tracer.Start(c, "waypointUsecase.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if _, err = u.manageable(ctx, caller, id); err != nil {
+		return err
+	}
+
+	err = u.repo.DeleteWaypoint(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return response.ErrNotFound
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return errInternal
+	}
+
+	u.changed()
+
+	return nil
+}
+
+// Commands returns a setWaypoints cmd with the waypoints of the device when they changed since
+// it confirmed them, none when they didn't or can't be loaded. They are sent again until a message
+// with another tst than the one answered confirms them, unchanged waypoints aren't loaded again
+// for syncCacheTTL
+func (u *waypointUsecase) Commands(c context.Context, username, device string, tst int64) []interface{} {
+	c, span := tracer.Start(c, "waypointUsecase.Commands")
+	defer span.End()
+
+	key := username + "/" + device
+
+	u.mu.Lock()
+	state, cached := u.syncs[key]
+	version := u.version
+	u.mu.Unlock()
+
+	if cached && state.sent == "" && (state.hash == state.synced || state.none) && state.version == version &&
+		time.Since(state.loadedAt) < syncCacheTTL {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if !cached {
+		synced, err := u.repo.GetWaypointSync(ctx, username, device)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logger.FromContext(ctx).Errorln(err)
+
+			return nil
+		}
+
+		state.synced = synced
+	}
+
+	waypoints, err := u.repo.GetWaypoints(ctx, username, device)
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return nil
+	}
+
+	state.hash, state.none = hashWaypoints(waypoints), len(waypoints) == 0
+	state.version, state.loadedAt = version, time.Now()
+
+	// the device got the previous response, it sends another message only after one
+	if state.sent != "" && tst != state.sentTst {
+		if err := u.repo.SaveWaypointSync(ctx, username, device, state.sent, time.Now().Unix()); err != nil {
+			logger.FromContext(ctx).Errorln(err)
+
+			return nil
+		}
+
+		state.synced, state.sent = state.sent, ""
+	}
+
+	// devices can't be told to remove waypoints, nothing to send without any
+	if state.hash == state.synced || state.none {
+		state.sent = ""
+		u.store(key, state)
+
+		return nil
+	}
+
+	state.sent, state.sentTst = state.hash, tst
+	u.store(key, state)
+
+	export := model.WaypointsExport{Type: "waypoints", Waypoints: make([]model.DeviceWaypoint, 0, len(waypoints))}
+	for _, w := range waypoints {
+		export.Waypoints = append(export.Waypoints, model.DeviceWaypoint{
+			Type: "waypoint",
+			Desc: w.Description,
+			Lat:  w.Lat,
+			Lon:  w.Lon,
+			Rad:  w.Radius,
+			Tst:  w.Tst,
+			Rid:  w.Rid,
+		})
+	}
+
+	return []interface{}{model.WaypointsCommand{Type: "cmd", Action: "setWaypoints", Waypoints: export}}
+}
+
+// changed makes devices load their waypoints again
+func (u *waypointUsecase) changed() {
+	u.mu.Lock()
+	u.version++
+	u.mu.Unlock()
+}
+
+func (u *waypointUsecase) store(key string, state deviceSync) {
+	u.mu.Lock()
+	u.syncs[key] = state
+	u.mu.Unlock()
+}
+
+// manageable returns the waypoint when the caller may manage it, response.ErrNotFound otherwise
+func (u *waypointUsecase) manageable(ctx context.Context, caller string, id int64) (model.Waypoint, error) {
+	w, err := u.repo.GetWaypoint(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return w, response.ErrNotFound
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).Errorln(err)
+
+		return w, errInternal
+	}
+
+	if w.Username != caller && !u.admins[caller] {
+		return w, response.ErrNotFound
+	}
+
+	return w, nil
+}
+
+// usersOf returns the username whose waypoints devices of the user get along its own,
+// every user for those of the whole fleet
+func usersOf(username string) string {
+	if username == model.WaypointsEveryone {
+		return ""
+	}
+
+	return username
+}
+
+func validateWaypoint(w *model.Waypoint) error {
+	w.Description = strings.TrimSpace(w.Description)
+
+	switch {
+	case w.Description == "":
+		return response.WrapError(errors.New("description is required"), http.StatusBadRequest)
+	case len(w.Description) > maxDescriptionLen:
+		return response.WrapError(fmt.Errorf("description must be at most %d characters", maxDescriptionLen),
+			http.StatusBadRequest)
+	case len(w.Username) > maxNameLen || len(w.Device) > maxNameLen:
+		return response.WrapError(fmt.Errorf("username and device must be at most %d characters", maxNameLen),
+			http.StatusBadRequest)
+	case w.Lat < -90 || w.Lat > 90:
+		return response.WrapError(errors.New("lat must be between -90 and 90"), http.StatusBadRequest)
+	case w.Lon < -180 || w.Lon > 180:
+		return response.WrapError(errors.New("lon must be between -180 and 180"), http.StatusBadRequest)
+	case w.Radius <= 0:
+		return response.WrapError(errors.New("radius must be positive"), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// hashWaypoints returns a hash of everything of the waypoints sent to devices
+func hashWaypoints(waypoints []model.Waypoint) string {
+	h := sha256.New()
+	for _, w := range waypoints {
+		fmt.Fprintf(h, "%s|%f|%f|%d|%d|%s\n", w.Description, w.Lat, w.Lon, w.Radius, w.Tst, w.Rid)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newRid returns a random region id
+func newRid() (string, error) {
+	b := make([]byte, ridBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,178 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"ot-recorder/app/model"
+	"ot-recorder/app/model/mocks"
+	"ot-recorder/app/response"
+	"ot-recorder/app/waypoint/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreate(t *testing.T) {
+	future := time.Now().Unix() + 100
+
+	mockRepo := new(mocks.WaypointRepository)
+	mockRepo.On("GetWaypoints", mock.Anything, "dev", "").
+		Return([]model.Waypoint{{ID: 1, Username: "dev", Tst: future}}, nil).Once()
+	mockRepo.On("GetWaypoints", mock.Anything, "", "").Return([]model.Waypoint{}, nil).Once()
+	mockRepo.On("CreateWaypoint", mock.Anything, mock.MatchedBy(func(w *model.Waypoint) bool {
+		return w.Username == "dev" && w.Description == "Office" && w.Radius == 100 && w.Tst == future+1 &&
+			len(w.Rid) == 32 && w.UpdatedAt > 0
+	})).Return(nil).Once()
+	mockRepo.On("CreateWaypoint", mock.Anything, mock.MatchedBy(func(w *model.Waypoint) bool {
+		return w.Username == model.WaypointsEveryone && w.Radius == 250
+	})).Return(nil).Once()
+
+	u := usecase.NewWaypointUsecase(mockRepo, []string{"admin"}, time.Second*2)
+
+	// the tst of the new waypoint must not be the one of another
+	assert.NoError(t, u.Create(context.TODO(), "dev", &model.Waypoint{Description: " Office ", Lat: 23.8, Lon: 90.4}))
+
+	assert.NoError(t, u.Create(context.TODO(), "admin", &model.Waypoint{
+		Username: model.WaypointsEveryone, Description: "HQ", Lat: 23.7, Lon: 90.4, Radius: 250,
+	}))
+
+	err := u.Create(context.TODO(), "dev", &model.Waypoint{Username: "mom", Description: "Home", Lat: 1, Lon: 1})
+	code, _ := response.RespondError(err)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	for _, w := range []model.Waypoint{
+		{Lat: 1, Lon: 1},
+		{Description: "Home", Lat: 91, Lon: 1},
+		{Description: "Home", Lat: 1, Lon: -181},
+		{Description: "Home", Lat: 1, Lon: 1, Radius: -5},
+		{Description: "Home", Lat: 1, Lon: 1, Device: "a-very-long-device-name"},
+	} {
+		w := w
+		err := u.Create(context.TODO(), "dev", &w)
+		code, _ := response.RespondError(err)
+		assert.Equal(t, http.StatusBadRequest, code, w)
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestList(t *testing.T) {
+	mockRepo := new(mocks.WaypointRepository)
+	mockRepo.On("GetWaypoints", mock.Anything, "dev", "phone").Return([]model.Waypoint{{ID: 1}}, nil).Once()
+	mockRepo.On("GetWaypoints", mock.Anything, "", "").Return([]model.Waypoint{{ID: 1}, {ID: 2}}, nil).Once()
+	mockRepo.On("GetWaypoints", mock.Anything, "mom", "").Return(nil, errors.New("db down")).Once()
+
+	u := usecase.NewWaypointUsecase(mockRepo, []string{"admin"}, time.Second*2)
+
+	waypoints, err := u.List(context.TODO(), "dev", "", "phone")
+	assert.NoError(t, err)
+	assert.Len(t, waypoints, 1)
+
+	waypoints, err = u.List(context.TODO(), "admin", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, waypoints, 2)
+
+	_, err = u.List(context.TODO(), "dev", "mom", "")
+	code, _ := response.RespondError(err)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	_, err = u.List(context.TODO(), "admin", "mom", "")
+	code, _ = response.RespondError(err)
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateDelete(t *testing.T) {
+	radius, device := int32(300), "phone"
+	stored := model.Waypoint{ID: 1, Username: "dev", Description: "Office", Lat: 23.8, Lon: 90.4, Radius: 100,
+		Rid: "a1b2", Tst: 1000, UpdatedAt: 1000}
+
+	mockRepo := new(mocks.WaypointRepository)
+	mockRepo.On("GetWaypoint", mock.Anything, int64(1)).Return(stored, nil)
+	mockRepo.On("GetWaypoint", mock.Anything, int64(2)).Return(model.Waypoint{}, sql.ErrNoRows)
+	mockRepo.On("UpdateWaypoint", mock.Anything, mock.MatchedBy(func(w *model.Waypoint) bool {
+		return w.ID == 1 && w.Radius == radius && w.Device == device && w.Description == "Office" &&
+			w.Tst == 1000 && w.UpdatedAt > 1000
+	})).Return(nil).Once()
+	mockRepo.On("DeleteWaypoint", mock.Anything, int64(1)).Return(nil).Once()
+
+	u := usecase.NewWaypointUsecase(mockRepo, []string{"admin"}, time.Second*2)
+
+	w, err := u.Update(context.TODO(), "dev", 1, model.WaypointUpdate{Radius: &radius, Device: &device})
+	assert.NoError(t, err)
+	assert.Equal(t, radius, w.Radius)
+
+	// waypoints of other users are not found by others than admins
+	_, err = u.Update(context.TODO(), "mom", 1, model.WaypointUpdate{Radius: &radius})
+	assert.ErrorIs(t, err, response.ErrNotFound)
+
+	_, err = u.Update(context.TODO(), "dev", 2, model.WaypointUpdate{Radius: &radius})
+	assert.ErrorIs(t, err, response.ErrNotFound)
+
+	radius = 0
+	_, err = u.Update(context.TODO(), "dev", 1, model.WaypointUpdate{Radius: &radius})
+	code, _ := response.RespondError(err)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	assert.ErrorIs(t, u.Delete(context.TODO(), "mom", 1), response.ErrNotFound)
+	assert.ErrorIs(t, u.Delete(context.TODO(), "admin", 2), response.ErrNotFound)
+	assert.NoError(t, u.Delete(context.TODO(), "admin", 1))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCommands(t *testing.T) {
+	waypoints := []model.Waypoint{
+		{ID: 1, Username: "dev", Description: "Office", Lat: 23.8, Lon: 90.4, Radius: 100, Rid: "a1b2", Tst: 1000},
+		{ID: 2, Username: "*", Description: "HQ", Lat: 23.7, Lon: 90.3, Radius: 250, Rid: "c3d4", Tst: 1001},
+	}
+	setWaypoints := []interface{}{model.WaypointsCommand{Type: "cmd", Action: "setWaypoints",
+		Waypoints: model.WaypointsExport{Type: "waypoints", Waypoints: []model.DeviceWaypoint{
+			{Type: "waypoint", Desc: "Office", Lat: 23.8, Lon: 90.4, Rad: 100, Tst: 1000, Rid: "a1b2"},
+			{Type: "waypoint", Desc: "HQ", Lat: 23.7, Lon: 90.3, Rad: 250, Tst: 1001, Rid: "c3d4"},
+		}}}}
+
+	var synced string
+
+	mockRepo := new(mocks.WaypointRepository)
+	mockRepo.On("GetWaypointSync", mock.Anything, "dev", "phone").Return("", sql.ErrNoRows).Once()
+	mockRepo.On("GetWaypoints", mock.Anything, "dev", "phone").Return(waypoints, nil).Times(3)
+	mockRepo.On("SaveWaypointSync", mock.Anything, "dev", "phone", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { synced = args.String(3) }).Return(nil).Once()
+	mockRepo.On("GetWaypointSync", mock.Anything, "mom", "tablet").Return("", sql.ErrNoRows).Once()
+	mockRepo.On("GetWaypoints", mock.Anything, "mom", "tablet").Return([]model.Waypoint{}, nil).Once()
+	mockRepo.On("GetWaypoint", mock.Anything, int64(2)).Return(waypoints[1], nil).Once()
+	mockRepo.On("DeleteWaypoint", mock.Anything, int64(2)).Return(nil).Once()
+	mockRepo.On("GetWaypoints", mock.Anything, "dev", "phone").Return(waypoints[:1], nil).Once()
+
+	u := usecase.NewWaypointUsecase(mockRepo, []string{"admin"}, time.Second*2)
+
+	assert.Equal(t, setWaypoints, u.Commands(context.TODO(), "dev", "phone", 1))
+	assert.Empty(t, synced)
+
+	// the device sends the message again when it didn't get the response
+	assert.Equal(t, setWaypoints, u.Commands(context.TODO(), "dev", "phone", 1))
+	assert.Empty(t, synced)
+
+	// the next message confirms them
+	assert.Empty(t, u.Commands(context.TODO(), "dev", "phone", 2))
+	assert.NotEmpty(t, synced)
+
+	// unchanged since they were confirmed, not loaded again
+	assert.Empty(t, u.Commands(context.TODO(), "dev", "phone", 3))
+
+	// loaded again once changed
+	assert.NoError(t, u.Delete(context.TODO(), "admin", 2))
+	assert.Len(t, u.Commands(context.TODO(), "dev", "phone", 4), 1)
+
+	// nothing to send without waypoints, nothing recorded either
+	assert.Empty(t, u.Commands(context.TODO(), "mom", "tablet", 1))
+	assert.Empty(t, u.Commands(context.TODO(), "mom", "tablet", 2))
+
+	mockRepo.AssertExpectations(t)
+}
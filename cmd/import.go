@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"ot-recorder/app/location/importer"
+	locationRepo "ot-recorder/app/location/repository"
+	"ot-recorder/app/model"
+	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/db"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const maxNameLength = 20
+
+//nolint:gochecknoglobals
+var (
+	importUsername string
+	importDevice   string
+	importParsers  = map[string]func(r io.Reader) importer.Parser{
+		"gpx":     func(r io.Reader) importer.Parser { return importer.NewGPXParser(r) },
+		"csv":     func(r io.Reader) importer.Parser { return importer.NewCSVParser(r) },
+		"takeout": func(r io.Reader) importer.Parser { return importer.NewTakeoutParser(r) },
+	}
+	importCmd = &cobra.Command{
+		Use:   "import",
+		Short: "import location history",
+		Long: `import location history for a username & device from
+GPX track points, CSV files or Google Takeout location history(Records.json & Semantic Location History)`,
+		TraverseChildren: true,
+	}
+)
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.PersistentFlags().StringVarP(&importUsername, "username", "u", "", "username of the imported points")
+	importCmd.PersistentFlags().StringVarP(&importDevice, "device", "d", "", "device of the imported points")
+	_ = importCmd.MarkPersistentFlagRequired("username")
+	_ = importCmd.MarkPersistentFlagRequired("device")
+
+	// add subcommands
+	importCmd.AddCommand(&cobra.Command{
+		Use:   "gpx <file>...",
+		Short: "import gpx track points",
+		Long:  `import track points(trkpt) of gpx files`,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			handleImportCommand("gpx", args)
+		},
+	})
+
+	importCmd.AddCommand(&cobra.Command{
+		Use:   "csv <file>...",
+		Short: "import csv points",
+		Long: `import csv points, first row must be a header with
+tst(epoch) or time(RFC3339), lat, lon and optional acc, alt, vac, vel, batt, bs, m, t, tid, ssid, bssid columns`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			handleImportCommand("csv", args)
+		},
+	})
+
+	importCmd.AddCommand(&cobra.Command{
+		Use:   "takeout <file>...",
+		Short: "import google takeout location history",
+		Long:  `import google takeout Records.json or Semantic Location History json files`,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			handleImportCommand("takeout", args)
+		},
+	})
+}
+
+func handleImportCommand(format string, files []string) {
+	if len(importUsername) > maxNameLength || len(importDevice) > maxNameLength {
+		logrus.Printf("username & device should be at most %d characters\n", maxNameLength)
+		os.Exit(1)
+	}
+
+	if err := importFiles(format, files); err != nil {
+		logrus.Errorln(err)
+		os.Exit(1)
+	}
+}
+
+func importFiles(format string, files []string) error {
+	db.Connect()
+	defer db.Close()
+
+	repo := locationRepo.NewLocationRepository(config.Get().Database.Type, db.GetClient())
+
+	for _, file := range files {
+		if err := importFile(repo, format, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func importFile(repo model.LocationRepository, format, file string) error {
+	f, err := os.Open(file) //nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	res, err := importer.Import(context.Background(), repo, importParsers[format](f), importUsername, importDevice,
+		config.Get().App.ContextTimeout)
+
+	logrus.Infof("%s: imported %d points, skipped %d duplicates", file, res.Imported, res.Duplicates)
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	return nil
+}
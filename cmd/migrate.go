@@ -5,6 +5,7 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"ot-recorder/infrastructure/config"
 	"strconv"
@@ -93,12 +94,16 @@ func migrateDatabase(state string, step int) error {
 
 	defer db.Close()
 
-	instance, err := getDBInstance(db)
+	return runMigrations(config.Get().Database.Type, driverName, db, state, step)
+}
+
+func runMigrations(dbType, driverName string, db *sql.DB, state string, step int) error {
+	instance, err := getDBInstance(dbType, db)
 	if err != nil {
 		return err
 	}
 
-	schemaPath := fmt.Sprintf("migrations/%s", migrationDirMap[config.Get().Database.Type])
+	schemaPath := fmt.Sprintf("migrations/%s", migrationDirMap[dbType])
 
 	sDriver, err := iofs.New(migrationsFS, schemaPath)
 	if err != nil {
@@ -165,8 +170,8 @@ func getDBDriverAndURL() (driver, dbURL string) {
 	return driver, dbURL
 }
 
-func getDBInstance(db *sql.DB) (instance database.Driver, err error) {
-	switch config.Get().Database.Type {
+func getDBInstance(dbType string, db *sql.DB) (instance database.Driver, err error) {
+	switch dbType {
 	case DBPgsql:
 		instance, err = pgx.WithInstance(db, &pgx.Config{})
 		if err != nil {
@@ -186,3 +191,31 @@ func getDBInstance(db *sql.DB) (instance database.Driver, err error) {
 
 	return instance, err
 }
+
+// latestMigration returns the version of the last embedded migration of the database type
+func latestMigration(dbType string) (uint, error) {
+	sDriver, err := iofs.New(migrationsFS, fmt.Sprintf("migrations/%s", migrationDirMap[dbType]))
+	if err != nil {
+		return 0, err
+	}
+
+	defer sDriver.Close()
+
+	version, err := sDriver.First()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := sDriver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		version = next
+	}
+}
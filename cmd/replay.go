@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"ot-recorder/app/location/archive"
+	locationDelivery "ot-recorder/app/location/delivery/http"
+	locationRepo "ot-recorder/app/location/repository"
+	"ot-recorder/app/location/stream"
+	locationUseCase "ot-recorder/app/location/usecase"
+	"ot-recorder/app/model"
+	placeRepo "ot-recorder/app/place/repository"
+	placeUseCase "ot-recorder/app/place/usecase"
+	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const replayDateLayout = "2006-01-02"
+
+//nolint:gochecknoglobals
+var (
+	replayFrom string
+	replayTo   string
+	replayDir  string
+	replayCmd  = &cobra.Command{
+		Use:   "replay",
+		Short: "replay archived payloads",
+		Long: `replay payloads of the raw payload archive received between --from & --to through the
+location ping again, e.g. after a mapping fix. Times are RFC3339 or dates(UTC), a date of --to
+includes the whole day. Already stored locations are skipped as duplicates, delete them first
+to store them again.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := replayArchive(); err != nil {
+				logrus.Errorln(err)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(replayCmd)
+
+	replayCmd.Flags().StringVar(&replayFrom, "from", "", "replay payloads received from, RFC3339 or date")
+	replayCmd.Flags().StringVar(&replayTo, "to", "", "replay payloads received until, RFC3339 or date")
+	replayCmd.Flags().StringVar(&replayDir, "dir", "", "archive directory (default archive.path)")
+	_ = replayCmd.MarkFlagRequired("from")
+	_ = replayCmd.MarkFlagRequired("to")
+}
+
+func replayArchive() error {
+	from, err := parseReplayTime(replayFrom, false)
+	if err != nil {
+		return fmt.Errorf("from: %w", err)
+	}
+
+	to, err := parseReplayTime(replayTo, true)
+	if err != nil {
+		return fmt.Errorf("to: %w", err)
+	}
+
+	if to.Before(from) {
+		return fmt.Errorf("to %s is before from %s", replayTo, replayFrom)
+	}
+
+	if replayDir == "" {
+		replayDir = config.Get().Archive.Path
+	}
+
+	if _, err := os.Stat(replayDir); err != nil {
+		return err
+	}
+
+	a, err := archive.New(replayDir)
+	if err != nil {
+		return err
+	}
+
+	db.Connect()
+	defer db.Close()
+
+	dbType := config.Get().Database.Type
+	contextTimeout := config.Get().App.ContextTimeout
+
+	lRepo := locationRepo.NewLocationRepository(dbType, db.GetClient())
+	pUseCase := placeUseCase.NewPlaceUsecase(placeRepo.NewPlaceRepository(dbType, db.GetClient()), lRepo, nil,
+		contextTimeout)
+	lUseCase := locationUseCase.NewLocationUsecase(lRepo, pUseCase, nil, stream.NewHub(1), contextTimeout)
+
+	var payloads, replayed, skipped, failed int
+
+	err = a.Read(from, to, func(payload *model.Payload) error {
+		payloads++
+
+		locations, n, err := locationDelivery.PayloadLocations(payload)
+		if err != nil {
+			logrus.Warnf("payload of %s/%s at %d: %v", payload.Username, payload.Device, payload.ReceivedAt, err)
+			failed++
+
+			return nil
+		}
+
+		skipped += n
+
+		for _, l := range locations {
+			if err := lUseCase.Ping(context.Background(), l); err != nil {
+				logrus.Warnf("location of %s/%s at %d: %v", l.Username, l.Device, l.CreatedAt, err)
+				failed++
+
+				continue
+			}
+
+			replayed++
+		}
+
+		return nil
+	})
+
+	logrus.Infof("replayed %d locations of %d payloads, skipped %d other or invalid messages, %d failed",
+		replayed, payloads, skipped, failed)
+
+	return err
+}
+
+// parseReplayTime a date is the start of the day or its end with endOfDay
+func parseReplayTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(replayDateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither RFC3339 nor a date(%s)", value, replayDateLayout)
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	return t, nil
+}
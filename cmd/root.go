@@ -5,6 +5,7 @@ import (
 	"os"
 	"ot-recorder/app"
 	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/logger"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -35,6 +36,11 @@ func initConfig() {
 		logrus.Errorln(err)
 		os.Exit(1)
 	}
+
+	if err := logger.Setup(config.Get().App.Log); err != nil {
+		logrus.Errorln(err)
+		os.Exit(1)
+	}
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"os"
 	"ot-recorder/app/server"
+	"ot-recorder/infrastructure/config"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -17,8 +20,14 @@ var serveCmd = &cobra.Command{
 	Short: "Serve serves the OwnTracks recorder api service",
 	Long:  `Serve serves the OwnTracks recorder api service`,
 	Run: func(cmd *cobra.Command, args []string) {
+		schemaVersion, err := latestMigration(config.Get().Database.Type)
+		if err != nil {
+			logrus.Errorln(err)
+			os.Exit(1)
+		}
+
 		serverReady := make(chan bool)
-		s := server.Server{ServerReady: serverReady}
+		s := server.Server{ServerReady: serverReady, SchemaVersion: schemaVersion}
 		s.Serve()
 	},
 }
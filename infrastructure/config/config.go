@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -9,9 +10,17 @@ import (
 )
 
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Hook     HooksConfig    `mapstructure:"hook"`
+	App       AppConfig       `mapstructure:"app"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Hook      HooksConfig     `mapstructure:"hook"`
+	Ingest    IngestConfig    `mapstructure:"ingest"`
+	Tracing   TracingConfig   `mapstructure:"tracing"`
+	Stream    StreamConfig    `mapstructure:"stream"`
+	Monitor   MonitorConfig   `mapstructure:"monitor"`
+	Places    PlacesConfig    `mapstructure:"places"`
+	Archive   ArchiveConfig   `mapstructure:"archive"`
+	Waypoints WaypointsConfig `mapstructure:"waypoints"`
+	Commands  CommandsConfig  `mapstructure:"commands"`
 }
 
 // AppConfig app specific config
@@ -25,8 +34,17 @@ type AppConfig struct {
 	WriteTimeout     time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout      time.Duration `mapstructure:"idle_timeout"`
 	ContextTimeout   time.Duration `mapstructure:"context_timeout"`
+	Log              LogConfig     `mapstructure:"log"`
 	Port             int           `mapstructure:"port"`
 	Debug            bool          `mapstructure:"debug"`
+	StoreRaw         bool          `mapstructure:"store_raw"`
+}
+
+// LogConfig log level, format(json or text) and output(stdout, stderr or a file path)
+type LogConfig struct {
+	Level  string `mapstructure:"level"`
+	Format string `mapstructure:"format"`
+	Output string `mapstructure:"output"`
 }
 
 // DatabaseConfig DB specific config
@@ -44,15 +62,107 @@ type DatabaseConfig struct {
 	Debug       bool          `mapstructure:"debug"`
 }
 
+// IngestConfig write-behind ingestion queue config, pings are buffered
+// and stored in batches by size or time when enabled
+type IngestConfig struct {
+	SpillFile      string        `mapstructure:"spill_file"`
+	DeadLetterFile string        `mapstructure:"dead_letter_file"`
+	FlushInterval  time.Duration `mapstructure:"flush_interval"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	QueueSize      int           `mapstructure:"queue_size"`
+	Enabled        bool          `mapstructure:"enabled"`
+}
+
+// TracingConfig OpenTelemetry tracing config, spans are exported to an OTLP/HTTP collector
+type TracingConfig struct {
+	Endpoint    string  `mapstructure:"endpoint"`
+	URLPath     string  `mapstructure:"url_path"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+	Insecure    bool    `mapstructure:"insecure"`
+	Enabled     bool    `mapstructure:"enabled"`
+}
+
+// StreamConfig live location stream config, without access rules every
+// viewer may see every user
+type StreamConfig struct {
+	Access    []StreamAccess `mapstructure:"access"`
+	KeepAlive time.Duration  `mapstructure:"keep_alive"`
+	Buffer    int            `mapstructure:"buffer"`
+}
+
+// StreamAccess users a viewer may see besides itself, "*" for all
+type StreamAccess struct {
+	Viewer string   `mapstructure:"viewer"`
+	Users  []string `mapstructure:"users"`
+}
+
+// MonitorConfig device health monitoring, alerts are sent to the telegram chat
+// of the hook(needs its bot_token) and posted to the webhooks when enabled
+type MonitorConfig struct {
+	Webhooks       []string      `mapstructure:"webhooks"`
+	Interval       time.Duration `mapstructure:"interval"`
+	Window         time.Duration `mapstructure:"window"`
+	InactiveAfter  time.Duration `mapstructure:"inactive_after"`
+	UnpluggedAfter time.Duration `mapstructure:"unplugged_after"`
+	BatteryLow     int8          `mapstructure:"battery_low"`
+	Telegram       bool          `mapstructure:"telegram"`
+	Enabled        bool          `mapstructure:"enabled"`
+}
+
+// PlacesConfig places config, details of fixes less accurate than WifiFixAccuracy meters while
+// connected to a known access point get the position of its place as wifi_fix, 0 leaves it out
+type PlacesConfig struct {
+	WifiFixAccuracy int16 `mapstructure:"wifi_fix_accuracy"`
+}
+
+// ArchiveConfig raw payload archive config, every received message is appended to a
+// gzipped daily file in Path when enabled, so history can be replayed after a mapping fix
+type ArchiveConfig struct {
+	Path    string `mapstructure:"path"`
+	Enabled bool   `mapstructure:"enabled"`
+}
+
+// WaypointsConfig waypoints config, Admins may manage the waypoints of every user
+// & those of the whole fleet, others only their own
+type WaypointsConfig struct {
+	Admins []string `mapstructure:"admins"`
+}
+
+// CommandsConfig device command queue config, Admins may queue commands for every device,
+// pending commands older than ExpireAfter are not delivered anymore
+type CommandsConfig struct {
+	Admins      []string      `mapstructure:"admins"`
+	ExpireAfter time.Duration `mapstructure:"expire_after"`
+}
+
 type HooksConfig struct {
 	Telegram TelegramHook `mapstructure:"telegram"`
 }
 
 type TelegramHook struct {
 	SecretToken string `mapstructure:"secret_token"`
+	BotToken    string `mapstructure:"bot_token"`
 	ChatID      int64  `mapstructure:"chat_id"`
 }
 
+const (
+	defaultIngestBatchSize = 500
+	defaultIngestQueueSize = 10000
+	defaultTracingEndpoint = "localhost:4318"
+	defaultServiceName     = "ot-recorder"
+	defaultStreamBuffer    = 16
+	defaultStreamKeepAlive = 15 * time.Second
+
+	defaultMonitorInterval       = time.Minute
+	defaultMonitorWindow         = 48 * time.Hour
+	defaultMonitorInactiveAfter  = 2 * time.Hour
+	defaultMonitorUnpluggedAfter = 24 * time.Hour
+	defaultMonitorBatteryLow     = 20
+
+	defaultCommandsExpireAfter = 24 * time.Hour
+)
+
 // c is the configuration instance
 var c Config //nolint:gochecknoglobals
 
@@ -65,6 +175,10 @@ func Get() Config {
 func LoadTestValues() {
 	c.Hook.Telegram.SecretToken = "secret"
 	c.Hook.Telegram.ChatID = 1
+
+	setStreamDefaults(&c.Stream)
+	setMonitorDefaults(&c.Monitor)
+	setCommandsDefaults(&c.Commands)
 }
 
 // Load the config
@@ -96,5 +210,116 @@ func Load(path string) error {
 		c.App.TimeZone = "UTC"
 	}
 
+	setLogDefaults(&c.App.Log)
+	setIngestDefaults(&c.Ingest, dataPath)
+	setTracingDefaults(&c.Tracing)
+	setStreamDefaults(&c.Stream)
+	setMonitorDefaults(&c.Monitor)
+	setArchiveDefaults(&c.Archive, dataPath)
+	setCommandsDefaults(&c.Commands)
+
 	return nil
 }
+
+func setLogDefaults(lc *LogConfig) {
+	if strings.TrimSpace(lc.Level) == "" {
+		lc.Level = "info"
+	}
+
+	if strings.TrimSpace(lc.Format) == "" {
+		lc.Format = "text"
+	}
+
+	if strings.TrimSpace(lc.Output) == "" {
+		lc.Output = "stdout"
+	}
+}
+
+func setIngestDefaults(ic *IngestConfig, dataPath string) {
+	if ic.BatchSize <= 0 {
+		ic.BatchSize = defaultIngestBatchSize
+	}
+
+	if ic.QueueSize <= 0 {
+		ic.QueueSize = defaultIngestQueueSize
+	}
+
+	if ic.QueueSize < ic.BatchSize {
+		ic.QueueSize = ic.BatchSize
+	}
+
+	if ic.FlushInterval <= 0 {
+		ic.FlushInterval = time.Second
+	}
+
+	if strings.TrimSpace(ic.SpillFile) == "" {
+		ic.SpillFile = filepath.Join(dataPath, "ingest-spill.ndjson")
+	}
+
+	if strings.TrimSpace(ic.DeadLetterFile) == "" {
+		ic.DeadLetterFile = filepath.Join(dataPath, "ingest-failed.ndjson")
+	}
+}
+
+func setArchiveDefaults(ac *ArchiveConfig, dataPath string) {
+	if strings.TrimSpace(ac.Path) == "" {
+		ac.Path = filepath.Join(dataPath, "archive")
+	}
+}
+
+func setTracingDefaults(tc *TracingConfig) {
+	if strings.TrimSpace(tc.Endpoint) == "" {
+		tc.Endpoint = defaultTracingEndpoint
+	}
+
+	if strings.TrimSpace(tc.ServiceName) == "" {
+		tc.ServiceName = defaultServiceName
+	}
+
+	if tc.SampleRatio <= 0 || tc.SampleRatio > 1 {
+		tc.SampleRatio = 1
+	}
+}
+
+func setStreamDefaults(sc *StreamConfig) {
+	if sc.Buffer <= 0 {
+		sc.Buffer = defaultStreamBuffer
+	}
+
+	if sc.KeepAlive <= 0 {
+		sc.KeepAlive = defaultStreamKeepAlive
+	}
+}
+
+func setMonitorDefaults(mc *MonitorConfig) {
+	if mc.Interval <= 0 {
+		mc.Interval = defaultMonitorInterval
+	}
+
+	if mc.InactiveAfter <= 0 {
+		mc.InactiveAfter = defaultMonitorInactiveAfter
+	}
+
+	if mc.UnpluggedAfter <= 0 {
+		mc.UnpluggedAfter = defaultMonitorUnpluggedAfter
+	}
+
+	if mc.Window <= 0 {
+		mc.Window = defaultMonitorWindow
+	}
+
+	// the window must see the whole unplugged time
+	if mc.Window < mc.UnpluggedAfter+mc.Interval {
+		mc.Window = mc.UnpluggedAfter + mc.Interval
+	}
+
+	if mc.BatteryLow <= 0 {
+		mc.BatteryLow = defaultMonitorBatteryLow
+	}
+}
+
+func setCommandsDefaults(cc *CommandsConfig) {
+	if cc.ExpireAfter <= 0 {
+		cc.ExpireAfter = defaultCommandsExpireAfter
+	}
+}
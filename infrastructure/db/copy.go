@@ -0,0 +1,385 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	TypePgsql  = "postgres"
+	TypeMysql  = "mysql"
+	TypeSqlite = "sqlite"
+
+	// maxCopyParams keeps multi row inserts under the bind parameter limit of every backend
+	maxCopyParams = 30000
+	fileMode      = 0o600
+)
+
+// CopyTable a table copied by `db copy` with the columns every backend has, the first one is
+// its auto increment `id`. Backend specific columns are left out
+type CopyTable struct {
+	Name    string
+	Columns []string
+}
+
+// CopyTables tables copied by `db copy`
+//
+//nolint:gochecknoglobals
+var CopyTables = []CopyTable{
+	{Name: "locations", Columns: []string{
+		"id", "username", "device", "created_at", "acc", "alt", "batt", "bs", "lat", "lon", "m", "t", "tid",
+		"vac", "vel", "bssid", "ssid", "ip", "geohash", "cog", "rad", "p", "poi", "tag", "conn", "topic", "msg_id",
+		"msg_created_at", "inregions", "inrids", "motion_activities", "raw",
+	}},
+	{Name: "shares", Columns: []string{
+		"id", "token", "username", "track_hours", "expires_at", "revoked_at", "views", "created_at",
+	}},
+	{Name: "places", Columns: []string{
+		"id", "username", "name", "kind", "lat", "lon", "radius", "visits", "duration", "first_visit",
+		"last_visit", "pinned", "updated_at",
+	}},
+	{Name: "access_points", Columns: []string{"id", "username", "place_id", "bssid", "ssid", "created_at"}},
+	{Name: "preferences", Columns: []string{
+		"id", "username", "time_zone", "units", "clock", "language", "updated_at",
+	}},
+	{Name: "waypoints", Columns: []string{
+		"id", "username", "device", "description", "lat", "lon", "radius", "rid", "tst", "updated_at",
+	}},
+	{Name: "waypoint_syncs", Columns: []string{"id", "username", "device", "hash", "synced_at"}},
+	{Name: "device_commands", Columns: []string{
+		"id", "username", "device", "action", "payload", "created_by", "created_at", "delivered_at",
+	}},
+}
+
+// Endpoint an opened database and its type
+type Endpoint struct {
+	Type string
+	DB   *sql.DB
+}
+
+// Checkpoint last copied id per table, saved after every batch so an interrupted copy can resume
+type Checkpoint struct {
+	path string
+
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Tables map[string]int64 `json:"tables"`
+}
+
+// Open opens a database of the given type(sqlite, mysql, postgres) with a driver specific dsn
+func Open(dbType, dsn string) (*sql.DB, error) {
+	driver := "sqlite3"
+
+	switch dbType {
+	case TypePgsql:
+		driver = "pgx"
+	case TypeMysql:
+		driver = "mysql"
+	case TypeSqlite:
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", dbType)
+	}
+
+	return sql.Open(driver, dsn)
+}
+
+// LoadCheckpoint reads the checkpoint file, a missing file or a checkpoint of
+// another source/destination pair starts from the beginning
+func LoadCheckpoint(path, from, to string) (*Checkpoint, error) {
+	cp := &Checkpoint{path: path, From: from, To: to, Tables: map[string]int64{}}
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var saved Checkpoint
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %w", path, err)
+	}
+
+	if saved.From == from && saved.To == to && saved.Tables != nil {
+		cp.Tables = saved.Tables
+	}
+
+	return cp, nil
+}
+
+func (cp *Checkpoint) save() error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := cp.path + ".tmp"
+	if err := os.WriteFile(tmp, data, fileMode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, cp.path)
+}
+
+// Copy streams every table of CopyTables from src to dst in batches ordered by id.
+// Rows already present in dst are skipped, so re-running a copy is safe.
+func Copy(ctx context.Context, src, dst Endpoint, batchSize int, cp *Checkpoint) error {
+	for _, table := range CopyTables {
+		copied, err := copyTable(ctx, src, dst, table, batchSize, cp)
+		if err != nil {
+			return fmt.Errorf("%s: %w", table.Name, err)
+		}
+
+		logrus.Infof("%s: copied %d rows", table.Name, copied)
+
+		if dst.Type == TypePgsql {
+			if err := resetPgsqlSequence(ctx, dst.DB, table.Name); err != nil {
+				return fmt.Errorf("%s: %w", table.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func copyTable(ctx context.Context, src, dst Endpoint, table CopyTable, batchSize int, cp *Checkpoint) (int64, error) {
+	var total int64
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id > %s ORDER BY id LIMIT %d",
+		quoteIdents(src.Type, table.Columns), quoteIdent(src.Type, table.Name), placeholder(src.Type, 1), batchSize)
+
+	types, err := columnTypes(ctx, dst, table)
+	if err != nil {
+		return total, err
+	}
+
+	for {
+		rows, err := readBatch(ctx, src.DB, query, types, cp.Tables[table.Name])
+		if err != nil {
+			return total, err
+		}
+
+		if len(rows) == 0 {
+			return total, nil
+		}
+
+		if err := writeBatch(ctx, dst, table, rows); err != nil {
+			return total, err
+		}
+
+		lastID, err := rowID(rows[len(rows)-1])
+		if err != nil {
+			return total, err
+		}
+
+		cp.Tables[table.Name] = lastID
+		if err := cp.save(); err != nil {
+			return total, err
+		}
+
+		total += int64(len(rows))
+		logrus.Infof("%s: %d rows copied, last id %d", table.Name, total, lastID)
+
+		if len(rows) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// columnTypes the database type names of the columns of the table in dst
+func columnTypes(ctx context.Context, dst Endpoint, table CopyTable) ([]string, error) {
+	rows, err := dst.DB.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE 1 = 0",
+		quoteIdents(dst.Type, table.Columns), quoteIdent(dst.Type, table.Name)))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, len(columns))
+	for i, column := range columns {
+		types[i] = strings.ToUpper(column.DatabaseTypeName())
+	}
+
+	return types, rows.Err()
+}
+
+// readBatch reads the rows after afterID with their values converted to the destination column types
+func readBatch(ctx context.Context, db *sql.DB, query string, types []string, afterID int64) ([][]interface{}, error) {
+	rows, err := db.QueryContext(ctx, query, afterID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var batch [][]interface{}
+
+	for rows.Next() {
+		values := make([]interface{}, len(types))
+		pointers := make([]interface{}, len(types))
+
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		for i, v := range values {
+			values[i] = convertValue(v, types[i])
+		}
+
+		batch = append(batch, values)
+	}
+
+	return batch, rows.Err()
+}
+
+// convertValue converts a value read from the source to one the destination column of dbType accepts
+func convertValue(v interface{}, dbType string) interface{} {
+	// drivers return text & decimal columns as bytes, every backend accepts them as string
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+
+	if dbType != "BOOL" && dbType != "BOOLEAN" {
+		return v
+	}
+
+	// sqlite & mysql keep booleans as integers, postgres only takes a bool
+	switch b := v.(type) {
+	case int64:
+		return b != 0
+	case int32:
+		return b != 0
+	case string:
+		if parsed, err := strconv.ParseBool(b); err == nil {
+			return parsed
+		}
+
+		return v
+	default:
+		return v
+	}
+}
+
+func writeBatch(ctx context.Context, dst Endpoint, table CopyTable, rows [][]interface{}) error {
+	tx, err := dst.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	rowsPerInsert := maxCopyParams / len(table.Columns)
+
+	for start := 0; start < len(rows); start += rowsPerInsert {
+		end := start + rowsPerInsert
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		query, args := buildInsert(dst.Type, table, rows[start:end])
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func buildInsert(dbType string, table CopyTable, rows [][]interface{}) (string, []interface{}) {
+	args := make([]interface{}, 0, len(rows)*len(table.Columns))
+	values := make([]string, len(rows))
+
+	for r, row := range rows {
+		holders := make([]string, len(row))
+		for i, v := range row {
+			args = append(args, v)
+			holders[i] = placeholder(dbType, len(args))
+		}
+
+		values[r] = "(" + strings.Join(holders, ", ") + ")"
+	}
+
+	insert := "INSERT INTO"
+	suffix := ""
+
+	switch dbType {
+	case TypePgsql:
+		suffix = " ON CONFLICT DO NOTHING"
+	case TypeMysql:
+		insert = "INSERT IGNORE INTO"
+	default:
+		insert = "INSERT OR IGNORE INTO"
+	}
+
+	return fmt.Sprintf("%s %s (%s) VALUES %s%s",
+		insert, quoteIdent(dbType, table.Name), quoteIdents(dbType, table.Columns), strings.Join(values, ", "), suffix), args
+}
+
+// rowID the id of a row, the first column of every copied table
+func rowID(row []interface{}) (int64, error) {
+	switch v := row[0].(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case string:
+		var id int64
+		_, err := fmt.Sscan(v, &id)
+
+		return id, err
+	default:
+		return 0, fmt.Errorf("unexpected id type %T", v)
+	}
+}
+
+func resetPgsqlSequence(ctx context.Context, db *sql.DB, table string) error {
+	query := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM %s`,
+		table, quoteIdent(TypePgsql, table))
+	_, err := db.ExecContext(ctx, query)
+
+	return err
+}
+
+func placeholder(dbType string, n int) string {
+	if dbType == TypePgsql {
+		return fmt.Sprintf("$%d", n)
+	}
+
+	return "?"
+}
+
+func quoteIdent(dbType, name string) string {
+	if dbType == TypePgsql {
+		return `"` + name + `"`
+	}
+
+	return "`" + name + "`"
+}
+
+func quoteIdents(dbType string, names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(dbType, name)
+	}
+
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,58 @@
+package db_test
+
+import (
+	"context"
+	"ot-recorder/infrastructure/db"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopySqliteToPgsql(t *testing.T) {
+	src, srcMock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dst, dstMock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	for _, table := range db.CopyTables {
+		columns := make([]*sqlmock.Column, len(table.Columns))
+		for i, name := range table.Columns {
+			columns[i] = sqlmock.NewColumn(name).OfType("TEXT", "")
+			if name == "pinned" {
+				columns[i] = sqlmock.NewColumn(name).OfType("BOOL", false)
+			}
+		}
+
+		dstMock.ExpectQuery(regexp.QuoteMeta(`FROM "` + table.Name + `" WHERE 1 = 0`)).
+			WillReturnRows(sqlmock.NewRowsWithColumnDefinition(columns...))
+
+		rows := sqlmock.NewRows(table.Columns)
+
+		// sqlite keeps pinned as an integer
+		if table.Name == "places" {
+			rows.AddRow(int64(1), "dev", "Home", "home", 23.8, 90.4, 50, 3, 600, 100, 200, int64(1), 300)
+
+			dstMock.ExpectBegin()
+			dstMock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "places"`)).
+				WithArgs(int64(1), "dev", "Home", "home", 23.8, 90.4, 50, 3, 600, 100, 200, true, 300).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			dstMock.ExpectCommit()
+		}
+
+		srcMock.ExpectQuery(regexp.QuoteMeta("FROM `" + table.Name + "` WHERE id > ?")).WillReturnRows(rows)
+		dstMock.ExpectExec("SELECT setval").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	cp, err := db.LoadCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"), "src", "dst")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Copy(context.TODO(), db.Endpoint{Type: db.TypeSqlite, DB: src},
+		db.Endpoint{Type: db.TypePgsql, DB: dst}, 100, cp))
+
+	assert.NoError(t, srcMock.ExpectationsWereMet())
+	assert.NoError(t, dstMock.ExpectationsWereMet())
+	assert.Equal(t, int64(1), cp.Tables["places"])
+}
@@ -0,0 +1 @@
+DROP INDEX locations_unique_udcll ON locations;
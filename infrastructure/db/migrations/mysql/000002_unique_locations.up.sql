@@ -0,0 +1,10 @@
+DELETE a FROM `locations` a
+INNER JOIN `locations` b
+  ON a.id > b.id
+  AND a.username = b.username
+  AND a.device = b.device
+  AND a.created_at = b.created_at
+  AND a.lat = b.lat
+  AND a.lon = b.lon;
+
+CREATE UNIQUE INDEX locations_unique_udcll ON locations (username, device, created_at, lat, lon);
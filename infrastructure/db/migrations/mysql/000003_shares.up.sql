@@ -0,0 +1,13 @@
+CREATE TABLE `shares` (
+  `id` bigint PRIMARY KEY AUTO_INCREMENT,
+  `token` varchar(43) NOT NULL,
+  `username` varchar(20) NOT NULL,
+  `track_hours` smallint NOT NULL DEFAULT 0,
+  `expires_at` bigint NOT NULL,
+  `revoked_at` bigint NOT NULL DEFAULT 0,
+  `views` bigint NOT NULL DEFAULT 0,
+  `created_at` bigint NOT NULL
+);
+
+CREATE UNIQUE INDEX shares_unique_token ON shares (token);
+CREATE INDEX shares_index_username ON shares (username);
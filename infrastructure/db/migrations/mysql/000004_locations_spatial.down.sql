@@ -0,0 +1 @@
+DROP INDEX locations_index_lat_lon ON locations;
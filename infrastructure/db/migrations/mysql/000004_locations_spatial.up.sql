@@ -0,0 +1 @@
+CREATE INDEX locations_index_lat_lon ON locations (lat, lon);
@@ -0,0 +1,3 @@
+DROP INDEX locations_index_geohash ON locations;
+
+ALTER TABLE locations DROP COLUMN geohash;
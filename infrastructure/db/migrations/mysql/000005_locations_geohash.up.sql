@@ -0,0 +1,3 @@
+ALTER TABLE locations ADD COLUMN geohash varchar(12) NULL;
+
+CREATE INDEX locations_index_geohash ON locations (geohash, created_at);
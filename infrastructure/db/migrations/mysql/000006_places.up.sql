@@ -0,0 +1,17 @@
+CREATE TABLE `places` (
+  `id` bigint PRIMARY KEY AUTO_INCREMENT,
+  `username` varchar(20) NOT NULL,
+  `name` varchar(50) NOT NULL DEFAULT '',
+  `kind` varchar(10) NOT NULL DEFAULT '',
+  `lat` decimal(8,6) NOT NULL,
+  `lon` decimal(9,6) NOT NULL,
+  `radius` double NOT NULL,
+  `visits` int NOT NULL DEFAULT 0,
+  `duration` bigint NOT NULL DEFAULT 0,
+  `first_visit` bigint NOT NULL DEFAULT 0,
+  `last_visit` bigint NOT NULL DEFAULT 0,
+  `pinned` boolean NOT NULL DEFAULT false,
+  `updated_at` bigint NOT NULL
+);
+
+CREATE INDEX places_index_username ON places (username);
@@ -0,0 +1,10 @@
+CREATE TABLE `access_points` (
+  `id` bigint PRIMARY KEY AUTO_INCREMENT,
+  `username` varchar(20) NOT NULL,
+  `place_id` bigint NOT NULL,
+  `bssid` varchar(17) NOT NULL,
+  `ssid` varchar(100) NOT NULL DEFAULT '',
+  `created_at` bigint NOT NULL
+);
+
+CREATE UNIQUE INDEX access_points_unique_bssid ON access_points (username, bssid);
@@ -0,0 +1,14 @@
+ALTER TABLE locations
+  DROP COLUMN cog,
+  DROP COLUMN rad,
+  DROP COLUMN p,
+  DROP COLUMN poi,
+  DROP COLUMN tag,
+  DROP COLUMN conn,
+  DROP COLUMN topic,
+  DROP COLUMN msg_id,
+  DROP COLUMN msg_created_at,
+  DROP COLUMN inregions,
+  DROP COLUMN inrids,
+  DROP COLUMN motion_activities,
+  DROP COLUMN raw;
@@ -0,0 +1,14 @@
+ALTER TABLE locations
+  ADD COLUMN cog smallint NOT NULL DEFAULT 0,
+  ADD COLUMN rad int NOT NULL DEFAULT 0,
+  ADD COLUMN p double NOT NULL DEFAULT 0,
+  ADD COLUMN poi varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN tag varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN conn varchar(1) NOT NULL DEFAULT '',
+  ADD COLUMN topic varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN msg_id varchar(100) NOT NULL DEFAULT '',
+  ADD COLUMN msg_created_at bigint NOT NULL DEFAULT 0,
+  ADD COLUMN inregions text NOT NULL DEFAULT (_utf8mb4''),
+  ADD COLUMN inrids text NOT NULL DEFAULT (_utf8mb4''),
+  ADD COLUMN motion_activities text NOT NULL DEFAULT (_utf8mb4''),
+  ADD COLUMN raw json NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS waypoint_syncs;
+DROP TABLE IF EXISTS waypoints;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS locations_unique_udcll;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS locations_index_geography;
+DROP INDEX IF EXISTS locations_index_lat_lon;
@@ -0,0 +1,10 @@
+CREATE INDEX locations_index_lat_lon ON "locations" ("lat", "lon");
+
+-- radius lookups use PostGIS when the extension is installed
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'postgis') THEN
+    CREATE INDEX locations_index_geography ON "locations" USING GIST ((geography(ST_MakePoint("lon", "lat"))));
+  END IF;
+END
+$$;
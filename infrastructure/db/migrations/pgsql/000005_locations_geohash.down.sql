@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS locations_index_geohash;
+
+ALTER TABLE "locations" DROP COLUMN IF EXISTS "geohash";
@@ -0,0 +1,17 @@
+CREATE TABLE "places" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar(20) NOT NULL,
+  "name" varchar(50) NOT NULL DEFAULT '',
+  "kind" varchar(10) NOT NULL DEFAULT '',
+  "lat" double precision NOT NULL,
+  "lon" double precision NOT NULL,
+  "radius" double precision NOT NULL,
+  "visits" integer NOT NULL DEFAULT 0,
+  "duration" bigint NOT NULL DEFAULT 0,
+  "first_visit" bigint NOT NULL DEFAULT 0,
+  "last_visit" bigint NOT NULL DEFAULT 0,
+  "pinned" boolean NOT NULL DEFAULT false,
+  "updated_at" bigint NOT NULL
+);
+
+CREATE INDEX places_index_username ON "places" ("username");
@@ -0,0 +1,11 @@
+CREATE TABLE "preferences" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar(20) NOT NULL,
+  "time_zone" varchar(50) NOT NULL DEFAULT '',
+  "units" varchar(10) NOT NULL DEFAULT '',
+  "clock" varchar(3) NOT NULL DEFAULT '',
+  "language" varchar(2) NOT NULL DEFAULT '',
+  "updated_at" bigint NOT NULL
+);
+
+CREATE UNIQUE INDEX preferences_unique_username ON "preferences" ("username");
@@ -0,0 +1,14 @@
+ALTER TABLE "locations"
+  DROP COLUMN IF EXISTS "cog",
+  DROP COLUMN IF EXISTS "rad",
+  DROP COLUMN IF EXISTS "p",
+  DROP COLUMN IF EXISTS "poi",
+  DROP COLUMN IF EXISTS "tag",
+  DROP COLUMN IF EXISTS "conn",
+  DROP COLUMN IF EXISTS "topic",
+  DROP COLUMN IF EXISTS "msg_id",
+  DROP COLUMN IF EXISTS "msg_created_at",
+  DROP COLUMN IF EXISTS "inregions",
+  DROP COLUMN IF EXISTS "inrids",
+  DROP COLUMN IF EXISTS "motion_activities",
+  DROP COLUMN IF EXISTS "raw";
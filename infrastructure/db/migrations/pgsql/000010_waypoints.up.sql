@@ -0,0 +1,24 @@
+CREATE TABLE "waypoints" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar(20) NOT NULL,
+  "device" varchar(20) NOT NULL DEFAULT '',
+  "description" varchar(50) NOT NULL,
+  "lat" double precision NOT NULL,
+  "lon" double precision NOT NULL,
+  "radius" integer NOT NULL,
+  "rid" varchar(40) NOT NULL,
+  "tst" bigint NOT NULL,
+  "updated_at" bigint NOT NULL
+);
+
+CREATE INDEX waypoints_index_username ON "waypoints" ("username");
+
+CREATE TABLE "waypoint_syncs" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar(20) NOT NULL,
+  "device" varchar(20) NOT NULL,
+  "hash" varchar(64) NOT NULL,
+  "synced_at" bigint NOT NULL
+);
+
+CREATE UNIQUE INDEX waypoint_syncs_unique_device ON "waypoint_syncs" ("username", "device");
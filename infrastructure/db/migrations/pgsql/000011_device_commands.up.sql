@@ -0,0 +1,12 @@
+CREATE TABLE "device_commands" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar(20) NOT NULL,
+  "device" varchar(20) NOT NULL,
+  "action" varchar(20) NOT NULL,
+  "payload" json NOT NULL,
+  "created_by" varchar(20) NOT NULL,
+  "created_at" bigint NOT NULL,
+  "delivered_at" bigint NOT NULL DEFAULT 0
+);
+
+CREATE INDEX device_commands_index_device ON "device_commands" ("username", "device", "delivered_at");
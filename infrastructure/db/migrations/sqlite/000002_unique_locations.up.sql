@@ -0,0 +1,6 @@
+DELETE FROM locations
+WHERE id NOT IN (
+  SELECT MIN(id) FROM locations GROUP BY username, device, created_at, lat, lon
+);
+
+CREATE UNIQUE INDEX locations_unique_udcll ON locations (username, device, created_at, lat, lon);
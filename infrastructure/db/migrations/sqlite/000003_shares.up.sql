@@ -0,0 +1,14 @@
+CREATE TABLE `shares` (
+  `id` INTEGER NOT NULL,
+  `token` TEXT NOT NULL,
+  `username` TEXT NOT NULL,
+  `track_hours` INTEGER NOT NULL DEFAULT 0,
+  `expires_at` INTEGER NOT NULL,
+  `revoked_at` INTEGER NOT NULL DEFAULT 0,
+  `views` INTEGER NOT NULL DEFAULT 0,
+  `created_at` INTEGER NOT NULL,
+  CONSTRAINT shares_PK PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX shares_unique_token ON shares (token);
+CREATE INDEX shares_index_username ON shares (username);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS locations_index_created_at;
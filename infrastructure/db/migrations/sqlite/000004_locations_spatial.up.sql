@@ -0,0 +1,2 @@
+-- lat & lon are TEXT on sqlite, spatial queries across users are bounded by time instead
+CREATE INDEX locations_index_created_at ON locations (created_at);
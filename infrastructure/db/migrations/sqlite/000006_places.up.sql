@@ -0,0 +1,18 @@
+CREATE TABLE `places` (
+  `id` INTEGER NOT NULL,
+  `username` TEXT NOT NULL,
+  `name` TEXT NOT NULL DEFAULT '',
+  `kind` TEXT NOT NULL DEFAULT '',
+  `lat` REAL NOT NULL,
+  `lon` REAL NOT NULL,
+  `radius` REAL NOT NULL,
+  `visits` INTEGER NOT NULL DEFAULT 0,
+  `duration` INTEGER NOT NULL DEFAULT 0,
+  `first_visit` INTEGER NOT NULL DEFAULT 0,
+  `last_visit` INTEGER NOT NULL DEFAULT 0,
+  `pinned` INTEGER NOT NULL DEFAULT 0,
+  `updated_at` INTEGER NOT NULL,
+  CONSTRAINT places_PK PRIMARY KEY(id)
+);
+
+CREATE INDEX places_index_username ON places (username);
@@ -0,0 +1,11 @@
+CREATE TABLE `access_points` (
+  `id` INTEGER NOT NULL,
+  `username` TEXT NOT NULL,
+  `place_id` INTEGER NOT NULL,
+  `bssid` TEXT NOT NULL,
+  `ssid` TEXT NOT NULL DEFAULT '',
+  `created_at` INTEGER NOT NULL,
+  CONSTRAINT access_points_PK PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX access_points_unique_bssid ON access_points (username, bssid);
@@ -0,0 +1,12 @@
+CREATE TABLE `preferences` (
+  `id` INTEGER NOT NULL,
+  `username` TEXT NOT NULL,
+  `time_zone` TEXT NOT NULL DEFAULT '',
+  `units` TEXT NOT NULL DEFAULT '',
+  `clock` TEXT NOT NULL DEFAULT '',
+  `language` TEXT NOT NULL DEFAULT '',
+  `updated_at` INTEGER NOT NULL,
+  CONSTRAINT preferences_PK PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX preferences_unique_username ON preferences (username);
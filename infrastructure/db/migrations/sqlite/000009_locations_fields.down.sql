@@ -0,0 +1,13 @@
+ALTER TABLE locations DROP COLUMN cog;
+ALTER TABLE locations DROP COLUMN rad;
+ALTER TABLE locations DROP COLUMN p;
+ALTER TABLE locations DROP COLUMN poi;
+ALTER TABLE locations DROP COLUMN tag;
+ALTER TABLE locations DROP COLUMN conn;
+ALTER TABLE locations DROP COLUMN topic;
+ALTER TABLE locations DROP COLUMN msg_id;
+ALTER TABLE locations DROP COLUMN msg_created_at;
+ALTER TABLE locations DROP COLUMN inregions;
+ALTER TABLE locations DROP COLUMN inrids;
+ALTER TABLE locations DROP COLUMN motion_activities;
+ALTER TABLE locations DROP COLUMN raw;
@@ -0,0 +1,13 @@
+ALTER TABLE locations ADD COLUMN cog INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE locations ADD COLUMN rad INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE locations ADD COLUMN p REAL NOT NULL DEFAULT 0;
+ALTER TABLE locations ADD COLUMN poi TEXT NOT NULL DEFAULT '';
+ALTER TABLE locations ADD COLUMN tag TEXT NOT NULL DEFAULT '';
+ALTER TABLE locations ADD COLUMN conn TEXT NOT NULL DEFAULT '';
+ALTER TABLE locations ADD COLUMN topic TEXT NOT NULL DEFAULT '';
+ALTER TABLE locations ADD COLUMN msg_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE locations ADD COLUMN msg_created_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE locations ADD COLUMN inregions TEXT NOT NULL DEFAULT '';
+ALTER TABLE locations ADD COLUMN inrids TEXT NOT NULL DEFAULT '';
+ALTER TABLE locations ADD COLUMN motion_activities TEXT NOT NULL DEFAULT '';
+ALTER TABLE locations ADD COLUMN raw TEXT;
@@ -0,0 +1,26 @@
+CREATE TABLE `waypoints` (
+  `id` INTEGER NOT NULL,
+  `username` TEXT NOT NULL,
+  `device` TEXT NOT NULL DEFAULT '',
+  `description` TEXT NOT NULL,
+  `lat` REAL NOT NULL,
+  `lon` REAL NOT NULL,
+  `radius` INTEGER NOT NULL,
+  `rid` TEXT NOT NULL,
+  `tst` INTEGER NOT NULL,
+  `updated_at` INTEGER NOT NULL,
+  CONSTRAINT waypoints_PK PRIMARY KEY(id)
+);
+
+CREATE INDEX waypoints_index_username ON waypoints (username);
+
+CREATE TABLE `waypoint_syncs` (
+  `id` INTEGER NOT NULL,
+  `username` TEXT NOT NULL,
+  `device` TEXT NOT NULL,
+  `hash` TEXT NOT NULL,
+  `synced_at` INTEGER NOT NULL,
+  CONSTRAINT waypoint_syncs_PK PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX waypoint_syncs_unique_device ON waypoint_syncs (username, device);
@@ -0,0 +1,13 @@
+CREATE TABLE `device_commands` (
+  `id` INTEGER NOT NULL,
+  `username` TEXT NOT NULL,
+  `device` TEXT NOT NULL,
+  `action` TEXT NOT NULL,
+  `payload` TEXT NOT NULL,
+  `created_by` TEXT NOT NULL,
+  `created_at` INTEGER NOT NULL,
+  `delivered_at` INTEGER NOT NULL DEFAULT 0,
+  CONSTRAINT device_commands_PK PRIMARY KEY(id)
+);
+
+CREATE INDEX device_commands_index_device ON device_commands (username, device, delivered_at);
@@ -18,12 +18,12 @@ var dbc = &dbClient{}
 func Connect() {
 	dbType := config.Get().Database.Type
 	switch dbType {
-	case "postgres":
+	case TypePgsql:
 		if err := connectPG(); err != nil {
 			logrus.Errorln(err)
 			os.Exit(1)
 		}
-	case "mysql":
+	case TypeMysql:
 		if err := connectMysql(); err != nil {
 			logrus.Errorln(err)
 			os.Exit(1)
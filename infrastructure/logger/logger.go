@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"ot-recorder/infrastructure/config"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fields attached to request scoped loggers
+const (
+	FieldRequestID = "request_id"
+	FieldUsername  = "username"
+	FieldDevice    = "device"
+)
+
+const logFileMode = 0o644
+
+type ctxKey struct{}
+
+// Setup configures the standard logrus logger from the log config
+func Setup(cfg config.LogConfig) error {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return fmt.Errorf("log level: %w", err)
+	}
+
+	logrus.SetLevel(level)
+
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	case "text":
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return fmt.Errorf("unknown log format %q, use json or text", cfg.Format)
+	}
+
+	out, err := openOutput(cfg.Output)
+	if err != nil {
+		return err
+	}
+
+	logrus.SetOutput(out)
+
+	return nil
+}
+
+func openOutput(output string) (io.Writer, error) {
+	switch output {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, logFileMode)
+		if err != nil {
+			return nil, fmt.Errorf("log output: %w", err)
+		}
+
+		return f, nil
+	}
+}
+
+// WithContext returns a copy of ctx carrying the logger entry
+func WithContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, ctxKey{}, entry)
+}
+
+// FromContext returns the request scoped logger of ctx, or the standard logger
+func FromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(ctxKey{}).(*logrus.Entry); ok {
+		return entry
+	}
+
+	return logrus.NewEntry(logrus.StandardLogger())
+}
@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "ot_recorder"
+
+//nolint:gochecknoglobals
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// Pings received location messages by username, device & _type
+	Pings = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pings_total",
+		Help:      "Received OwnTracks messages by username, device and _type.",
+	}, []string{"username", "device", "type"})
+
+	// DuplicatePings retried uploads that were already stored
+	DuplicatePings = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "duplicate_pings_total",
+		Help:      "Locations skipped because they were already stored.",
+	})
+
+	// ValidationFailures invalid requests by endpoint
+	ValidationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validation_failures_total",
+		Help:      "Requests or batch items rejected by validation.",
+	}, []string{"endpoint"})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database latency by repository method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"repository", "method"})
+
+	// TelegramCommands telegram bot commands by command
+	TelegramCommands = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_commands_total",
+		Help:      "Telegram bot commands.",
+	}, []string{"command"})
+
+	// IngestStored locations stored by the write-behind queue
+	IngestStored = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingest_stored_total",
+		Help:      "Locations stored by the write-behind ingestion queue.",
+	})
+
+	// IngestFailures failed batch inserts of the write-behind queue
+	IngestFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingest_failures_total",
+		Help:      "Failed batch inserts of the write-behind ingestion queue.",
+	})
+
+	// IngestDeadLetters locations the write-behind queue could not store and moved to the dead-letter file
+	IngestDeadLetters = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingest_dead_letters_total",
+		Help:      "Locations the write-behind ingestion queue could not store and moved to the dead-letter file.",
+	})
+
+	// ArchiveFailures received payloads that could not be archived
+	ArchiveFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "archive_failures_total",
+		Help:      "Received payloads that could not be written to the raw payload archive.",
+	})
+
+	ingestDepth atomic.Value
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ingest_queue_depth",
+		Help:      "Locations waiting in the write-behind ingestion queue.",
+	}, func() float64 {
+		if depth, ok := ingestDepth.Load().(func() int); ok {
+			return float64(depth())
+		}
+
+		return 0
+	})
+
+	lastIngest = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_ingest_timestamp_seconds",
+		Help:      "Unix time of the latest accepted location.",
+	})
+	lastIngestAt atomic.Int64
+
+	// StreamSubscribers open live location streams
+	StreamSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_subscribers",
+		Help:      "Open live location streams.",
+	})
+
+	// StreamDropped locations not sent to a stream subscriber which didn't keep up
+	StreamDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stream_dropped_total",
+		Help:      "Locations dropped for slow live stream subscribers.",
+	})
+
+	// DeviceAlerts device alerts started or resolved by kind
+	DeviceAlerts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "device_alerts_total",
+		Help:      "Device alerts started or resolved by kind.",
+	}, []string{"kind"})
+
+	lastFix = newLastFixCollector()
+)
+
+//nolint:gochecknoinits
+func init() {
+	prometheus.MustRegister(lastFix)
+}
+
+// ObserveRequest records the latency of a http request, route is the registered path pattern
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveDBQuery records the latency of a repository method since start, use it with defer
+func ObserveDBQuery(repository, method string, start time.Time) {
+	dbQueryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
+
+// SetIngestQueueDepth sets the source of the ingest queue depth gauge
+func SetIngestQueueDepth(depth func() int) {
+	ingestDepth.Store(depth)
+}
+
+// SetLastFix records the time of the latest location of a device and the time it was accepted
+func SetLastFix(username, device string, tst int64) {
+	lastFix.set(username, device, tst)
+
+	now := time.Now().Unix()
+	lastIngestAt.Store(now)
+	lastIngest.Set(float64(now))
+}
+
+// LastIngest time the latest location was accepted, zero when none since start
+func LastIngest() time.Time {
+	if at := lastIngestAt.Load(); at > 0 {
+		return time.Unix(at, 0)
+	}
+
+	return time.Time{}
+}
+
+type deviceKey struct {
+	username string
+	device   string
+}
+
+// lastFixCollector exports seconds since the last location of every device seen since start
+type lastFixCollector struct {
+	desc  *prometheus.Desc
+	mu    sync.RWMutex
+	fixes map[deviceKey]int64
+}
+
+func newLastFixCollector() *lastFixCollector {
+	return &lastFixCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "seconds_since_last_fix"),
+			"Seconds since the latest location of a device.",
+			[]string{"username", "device"}, nil,
+		),
+		fixes: map[deviceKey]int64{},
+	}
+}
+
+func (c *lastFixCollector) set(username, device string, tst int64) {
+	key := deviceKey{username: username, device: device}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tst > c.fixes[key] {
+		c.fixes[key] = tst
+	}
+}
+
+func (c *lastFixCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *lastFixCollector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now().Unix()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for key, tst := range c.fixes {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(now-tst), key.username, key.device)
+	}
+}
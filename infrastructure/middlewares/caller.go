@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"errors"
+	"ot-recorder/app/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuthenticatedCaller rejects requests whose x-limit-u isn't the basic auth user the proxy
+// authenticated, so a client can't act as another user, an admin, through the header. Without
+// x-limit-u the basic auth user is the caller, without basic auth the proxy sets x-limit-u
+func AuthenticatedCaller(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		if user, _, ok := req.BasicAuth(); ok {
+			switch caller := req.Header.Get("x-limit-u"); caller {
+			case "":
+				req.Header.Set("x-limit-u", user)
+			case user:
+			default:
+				return c.JSON(response.RespondError(response.ErrForbidden,
+					errors.New("x-limit-u isn't the authenticated user")))
+			}
+		}
+
+		return next(c)
+	}
+}
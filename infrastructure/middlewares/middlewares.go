@@ -2,22 +2,28 @@ package middlewares
 
 import (
 	"ot-recorder/infrastructure/config"
+	"ot-recorder/infrastructure/logger"
+	"ot-recorder/infrastructure/metrics"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
-const EchoLogFormat = "time: ${time_rfc3339_nano} || ${method}: ${uri} || u_agent: ${user_agent} || status: ${status}" +
-	" || latency: ${latency_human} \n"
-
 // Attach middlewares required for the application
 func Attach(e *echo.Echo) error {
 	cfg := config.Get().App
 
 	// echo middlewares
-	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{Format: EchoLogFormat}))
+	e.Use(middleware.RequestID())
+	e.Use(requestLogger)
+	e.Use(requestMetrics)
+	e.Use(otelecho.Middleware(config.Get().Tracing.ServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		return c.Path() == "/metrics"
+	})))
 	e.Use(middleware.Recover())
 	e.Use(middleware.BodyLimit(cfg.RequestBodyLimit))
 	e.Pre(middleware.RemoveTrailingSlash())
@@ -32,3 +38,55 @@ func Attach(e *echo.Echo) error {
 
 	return nil
 }
+
+// requestLogger attaches a logger with the request id, username & device to the
+// request context, so errors logged while serving it carry them, and writes the access log
+func requestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		req := c.Request()
+
+		fields := logrus.Fields{logger.FieldRequestID: c.Response().Header().Get(echo.HeaderXRequestID)}
+		if username := req.Header.Get("x-limit-u"); username != "" {
+			fields[logger.FieldUsername] = username
+		}
+
+		if device := req.Header.Get("x-limit-d"); device != "" {
+			fields[logger.FieldDevice] = device
+		}
+
+		entry := logrus.WithFields(fields)
+		c.SetRequest(req.WithContext(logger.WithContext(req.Context(), entry)))
+
+		err := next(c)
+		if err != nil {
+			c.Error(err)
+		}
+
+		entry.WithFields(logrus.Fields{
+			"method":     req.Method,
+			"uri":        req.RequestURI,
+			"status":     c.Response().Status,
+			"latency":    time.Since(start).String(),
+			"user_agent": req.UserAgent(),
+		}).Info("request")
+
+		return err
+	}
+}
+
+// requestMetrics records request latency by route pattern
+func requestMetrics(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+
+		err := next(c)
+		if err != nil {
+			c.Error(err)
+		}
+
+		metrics.ObserveRequest(c.Request().Method, c.Path(), c.Response().Status, time.Since(start))
+
+		return err
+	}
+}
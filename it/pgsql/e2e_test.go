@@ -1,6 +1,7 @@
 package pgsql
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -9,10 +10,14 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"ot-recorder/app/location/ingest"
+	locationRepo "ot-recorder/app/location/repository/pgsql"
+	"ot-recorder/app/model"
 	"ot-recorder/app/response"
 	"ot-recorder/app/server"
 	"ot-recorder/infrastructure/config"
 	"ot-recorder/infrastructure/db"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"testing"
@@ -20,6 +25,7 @@ import (
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/pgx"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/suite"
@@ -114,6 +120,645 @@ func (s *e2eTestSuite) Test_EndToEnd_Ping_Mobile() {
 	s.Equal(`[]`, strings.Trim(string(body), "\n"))
 }
 
+func (s *e2eTestSuite) Test_EndToEnd_Ping_Duplicate() {
+	body := postPing(s, pingReqStr)
+	s.Equal(`[]`, strings.Trim(string(body), "\n"))
+
+	body = postPing(s, pingReqStr)
+	s.Equal(`[]`, strings.Trim(string(body), "\n"))
+
+	var count int
+	s.NoError(s.db.QueryRow("SELECT COUNT(*) FROM locations").Scan(&count))
+	s.Equal(1, count)
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_Ping_Batch() {
+	reqStr := fmt.Sprintf("[%s, %s, {\"_type\":\"location\",\"tst\":%d}]", pingReqStr, pingReqStr, epoch)
+
+	req, err := http.NewRequestWithContext(context.Background(), echo.POST, s.apiBaseURL+"/ping/batch",
+		strings.NewReader(reqStr))
+	s.NoError(err)
+
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("x-limit-u", username)
+	req.Header.Set("x-limit-d", device)
+	req.Header.Set("X-Real-IP", clientIP)
+
+	client := http.Client{}
+	res, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+
+	_ = res.Body.Close()
+
+	bodystr := string(body)
+	s.Contains(bodystr, `"created":1`)
+	s.Contains(bodystr, `"duplicates":1`)
+	s.Contains(bodystr, `"invalid":1`)
+
+	var count int
+	s.NoError(s.db.QueryRow("SELECT COUNT(*) FROM locations").Scan(&count))
+	s.Equal(1, count)
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_Metrics() {
+	postPing(s, pingReqStr)
+
+	req, err := http.NewRequestWithContext(context.Background(), echo.GET,
+		strings.TrimSuffix(s.apiBaseURL, "/api/v1")+"/metrics", nil)
+	s.NoError(err)
+
+	client := http.Client{}
+	response, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, response.StatusCode)
+
+	body, err := io.ReadAll(response.Body)
+	s.NoError(err)
+	s.NoError(response.Body.Close())
+
+	s.Contains(string(body), fmt.Sprintf(`ot_recorder_pings_total{device="%s",type="location",username="%s"}`,
+		device, username))
+	s.Contains(string(body), `ot_recorder_http_request_duration_seconds_count{method="POST",route="/api/v1/ping"`)
+	s.Contains(string(body), `ot_recorder_db_query_duration_seconds_count{method="CreateLocation",repository="location"}`)
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_Health() {
+	baseURL := strings.TrimSuffix(s.apiBaseURL, "/api/v1")
+
+	for _, path := range []string{"/health", "/health/live", "/health/ready", "/health/details"} {
+		req, err := http.NewRequestWithContext(context.Background(), echo.GET, baseURL+path, nil)
+		s.NoError(err)
+
+		client := http.Client{}
+		response, err := client.Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, response.StatusCode, path)
+
+		body, err := io.ReadAll(response.Body)
+		s.NoError(err)
+		s.NoError(response.Body.Close())
+
+		if path == "/health/details" {
+			s.Contains(string(body), `{"name":"database","status":"up","critical":true`)
+			s.Contains(string(body), `{"name":"schema","status":"up","critical":true`)
+		}
+	}
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_Stream() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, echo.GET, s.apiBaseURL+"/stream", nil)
+	s.NoError(err)
+
+	client := http.Client{}
+	response, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, response.StatusCode)
+
+	defer response.Body.Close()
+
+	postPing(s, pingReqStr)
+
+	reader := bufio.NewReader(response.Body)
+	event, err := reader.ReadString('\n')
+	s.NoError(err)
+	s.Equal("event: location\n", event)
+
+	data, err := reader.ReadString('\n')
+	s.NoError(err)
+	s.Contains(data, fmt.Sprintf(`"username":"%s","device":"%s"`, username, device))
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_History() {
+	postPing(s, pingReqStr)
+
+	for _, path := range []string{"/history", "/trips", "/stays"} {
+		req, err := http.NewRequestWithContext(context.Background(), echo.GET,
+			fmt.Sprintf("%s%s?username=%s&from=%d&to=%d", s.apiBaseURL, path, username, epoch-60, epoch+60), nil)
+		s.NoError(err)
+
+		client := http.Client{}
+		response, err := client.Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, response.StatusCode, path)
+
+		body, err := io.ReadAll(response.Body)
+		s.NoError(err)
+		s.NoError(response.Body.Close())
+
+		if path == "/history" {
+			s.Contains(string(body), fmt.Sprintf(`{"device":"%s","created_at":%d,"lat":23,"lon":90`, device, epoch))
+		}
+	}
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_Spatial() {
+	postPing(s, pingReqStr)
+
+	for path, expected := range map[string]string{
+		"/locations/within?bbox=89.9,22.9,90.1,23.1": fmt.Sprintf(`{"username":"%s","device":"%s","created_at":%d`,
+			username, device, epoch),
+		"/locations/nearby?lat=23.0005&lon=90&radius=100": fmt.Sprintf(`{"username":"%s","device":"%s","created_at":%d`,
+			username, device, epoch),
+		"/locations/nearby?lat=23.01&lon=90&radius=100": `"data":[]`,
+	} {
+		req, err := http.NewRequestWithContext(context.Background(), echo.GET,
+			fmt.Sprintf("%s%s&from=%d&to=%d", s.apiBaseURL, path, epoch-60, epoch+60), nil)
+		s.NoError(err)
+
+		client := http.Client{}
+		response, err := client.Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, response.StatusCode, path)
+
+		body, err := io.ReadAll(response.Body)
+		s.NoError(err)
+		s.NoError(response.Body.Close())
+		s.Contains(string(body), expected, path)
+	}
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_HeatmapAndTiles() {
+	postPing(s, pingReqStr)
+
+	client := http.Client{}
+	rangeQuery := fmt.Sprintf("from=%d&to=%d", epoch-60, epoch+60)
+
+	res, err := client.Get(fmt.Sprintf("%s/heatmap?username=%s&precision=5&%s", //nolint:noctx
+		s.apiBaseURL, username, rangeQuery))
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	s.NoError(res.Body.Close())
+	s.Contains(string(body), `{"geohash":"wh04b","lat":23,"lon":90,"count":1}`)
+
+	// the ping at 23, 90 is inside tile 10/768/444
+	res, err = client.Get(fmt.Sprintf("%s/tiles/10/768/444.mvt?%s", s.apiBaseURL, rangeQuery)) //nolint:noctx
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("application/vnd.mapbox-vector-tile", res.Header.Get(echo.HeaderContentType))
+
+	body, err = io.ReadAll(res.Body)
+	s.NoError(err)
+	s.NoError(res.Body.Close())
+	s.Contains(string(body), "points")
+	s.Contains(string(body), device)
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_BatteryAndNetworkHistory() {
+	postPing(s, pingReqStr)
+
+	bucket := epoch - epoch%3600
+
+	for path, expected := range map[string]string{
+		"/history/battery?bucket=1h&username=" + username: fmt.Sprintf(`{"device":"%s","from":%d,"points":`, device, bucket),
+		"/history/network?bucket=1h&username=" + username: fmt.Sprintf(`"ip_address":"%s"`, clientIP),
+	} {
+		res, err := http.Get(s.apiBaseURL + path) //nolint:noctx
+		s.NoError(err)
+		s.Equal(http.StatusOK, res.StatusCode, path)
+
+		body, err := io.ReadAll(res.Body)
+		s.NoError(err)
+		s.NoError(res.Body.Close())
+		s.Contains(string(body), expected, path)
+	}
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_DeviceStatus() {
+	postPing(s, pingReqStr)
+
+	baseURL := strings.TrimSuffix(s.apiBaseURL, "/api/v1")
+
+	for url, expected := range map[string]string{
+		s.apiBaseURL + "/devices/status?username=" + username: fmt.Sprintf(
+			`"device":"%s","last_seen":%d`, device, epoch),
+		s.apiBaseURL + "/devices/status?username=nobody": `"message":`,
+		baseURL + "/health/details":                      `{"name":"device_notifiers","status":"disabled"`,
+	} {
+		res, err := http.Get(url) //nolint:noctx
+		s.NoError(err)
+
+		body, err := io.ReadAll(res.Body)
+		s.NoError(err)
+		s.NoError(res.Body.Close())
+		s.Contains(string(body), expected, url)
+
+		if strings.HasSuffix(url, "nobody") {
+			s.Equal(http.StatusNotFound, res.StatusCode)
+		} else {
+			s.Equal(http.StatusOK, res.StatusCode, url)
+		}
+	}
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_Places() {
+	client := http.Client{}
+
+	req, err := http.NewRequestWithContext(context.Background(), echo.POST, s.apiBaseURL+"/places",
+		strings.NewReader(`{"name":"Office","lat":23.0001,"lon":90,"radius":50}`))
+	s.NoError(err)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("x-limit-u", username)
+
+	res, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+	s.NoError(res.Body.Close())
+
+	postPing(s, pingReqStr)
+
+	for path, expected := range map[string]string{
+		"/places":                             `"name":"Office","kind":"","lat":23.0001,"lon":90,"radius":50`,
+		"/last-location?username=" + username: `"place":"Office"`,
+	} {
+		req, err := http.NewRequestWithContext(context.Background(), echo.GET, s.apiBaseURL+path, nil)
+		s.NoError(err)
+		req.Header.Set("x-limit-u", username)
+
+		res, err := client.Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, res.StatusCode, path)
+
+		body, err := io.ReadAll(res.Body)
+		s.NoError(err)
+		s.NoError(res.Body.Close())
+		s.Contains(string(body), expected, path)
+	}
+
+	req, err = http.NewRequestWithContext(context.Background(), echo.POST, s.apiBaseURL+"/places/refresh", nil)
+	s.NoError(err)
+	req.Header.Set("x-limit-u", username)
+
+	res, err = client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.NoError(res.Body.Close())
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_AccessPoints() {
+	client := http.Client{}
+
+	// the place is far away from the fix, the access point tells it
+	req, err := http.NewRequestWithContext(context.Background(), echo.POST, s.apiBaseURL+"/places",
+		strings.NewReader(`{"name":"Office","lat":24,"lon":91,"radius":50}`))
+	s.NoError(err)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("x-limit-u", username)
+
+	res, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+
+	var created struct {
+		Data struct {
+			ID int64 `json:"id"`
+		} `json:"data"`
+	}
+	s.NoError(json.NewDecoder(res.Body).Decode(&created))
+	s.NoError(res.Body.Close())
+
+	req, err = http.NewRequestWithContext(context.Background(), echo.POST,
+		fmt.Sprintf("%s/places/%d/access-points", s.apiBaseURL, created.Data.ID),
+		strings.NewReader(`{"bssid":"C0:0:0:0:0:0","ssid":"dev-test"}`))
+	s.NoError(err)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("x-limit-u", username)
+
+	res, err = client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+	s.NoError(res.Body.Close())
+
+	postPing(s, pingReqStr[0:len(pingReqStr)-1]+wifiInfo)
+
+	for path, expected := range map[string]string{
+		"/places/access-points":               `"bssid":"c0:00:00:00:00:00","ssid":"dev-test"`,
+		"/last-location?username=" + username: `"place":"Office WiFi"`,
+	} {
+		req, err := http.NewRequestWithContext(context.Background(), echo.GET, s.apiBaseURL+path, nil)
+		s.NoError(err)
+		req.Header.Set("x-limit-u", username)
+
+		res, err := client.Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, res.StatusCode, path)
+
+		body, err := io.ReadAll(res.Body)
+		s.NoError(err)
+		s.NoError(res.Body.Close())
+		s.Contains(string(body), expected, path)
+	}
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_Preferences() {
+	client := http.Client{}
+
+	// cached preferences outlive the tables of the test, the defaults are back for the others
+	defer func() {
+		req, err := http.NewRequestWithContext(context.Background(), echo.PATCH, s.apiBaseURL+"/preferences",
+			strings.NewReader(`{"time_zone":"","units":"","clock":"","language":""}`))
+		s.NoError(err)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("x-limit-u", username)
+
+		res, err := client.Do(req)
+		s.NoError(err)
+		s.NoError(res.Body.Close())
+	}()
+
+	req, err := http.NewRequestWithContext(context.Background(), echo.PATCH, s.apiBaseURL+"/preferences",
+		strings.NewReader(`{"time_zone":"Asia/Dhaka","units":"imperial","clock":"12h","language":"de"}`))
+	s.NoError(err)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("x-limit-u", username)
+
+	res, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.NoError(res.Body.Close())
+
+	postPing(s, pingReqStr)
+
+	for path, expected := range map[string]string{
+		"/preferences": `"time_zone":"Asia/Dhaka","units":"imperial","clock":"12h","language":"de"`,
+		"/last-location?username=" + username: fmt.Sprintf(`"date_time":"%s","date_time_iso":"%s","timestamp":%d`,
+			time.Unix(epoch, 0).In(time.FixedZone("", 6*3600)).Format("2006-01-02 03:04:05 PM"),
+			time.Unix(epoch, 0).In(time.FixedZone("", 6*3600)).Format(time.RFC3339), epoch),
+	} {
+		req, err := http.NewRequestWithContext(context.Background(), echo.GET, s.apiBaseURL+path, nil)
+		s.NoError(err)
+		req.Header.Set("x-limit-u", username)
+
+		res, err := client.Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, res.StatusCode, path)
+
+		body, err := io.ReadAll(res.Body)
+		s.NoError(err)
+		s.NoError(res.Body.Close())
+		s.Contains(string(body), expected, path)
+	}
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_LocationFields() {
+	reqStr := pingReqStr[0:len(pingReqStr)-1] + `,"_id":"3b0e1f2a","cog":270,"rad":150,"p":100.93,"poi":"Cafe",` +
+		`"tag":"lunch","conn":"w","inregions":["home","work"],"inrids":["5f2d8c"],"motionactivities":["walking"]}`
+	postPing(s, reqStr)
+
+	req, err := http.NewRequestWithContext(context.Background(), echo.GET,
+		s.apiBaseURL+"/last-location?username="+username, nil)
+	s.NoError(err)
+
+	client := http.Client{}
+	res, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	s.NoError(res.Body.Close())
+
+	s.Contains(string(body), `"course":270,"radius":150,"pressure":100.93,"poi":"Cafe","tag":"lunch",`+
+		`"connection":"WiFi","in_regions":["home","work"],"in_region_ids":["5f2d8c"],`+
+		`"motion_activities":["walking"],"topic":"owntracks/dev/phone","message_id":"3b0e1f2a",`+
+		fmt.Sprintf(`"message_created_at":%d`, epoch))
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_Waypoints() {
+	client := http.Client{}
+
+	// admins define the geofences of the whole fleet
+	for caller, code := range map[string]int{"admin": http.StatusCreated, username: http.StatusForbidden} {
+		req, err := http.NewRequestWithContext(context.Background(), echo.POST, s.apiBaseURL+"/waypoints",
+			strings.NewReader(`{"username":"*","description":"HQ","lat":23.7,"lon":90.4,"radius":250}`))
+		s.NoError(err)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("x-limit-u", caller)
+
+		res, err := client.Do(req)
+		s.NoError(err)
+		s.Equal(code, res.StatusCode, caller)
+		s.NoError(res.Body.Close())
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), echo.GET, s.apiBaseURL+"/waypoints", nil)
+	s.NoError(err)
+	req.Header.Set("x-limit-u", username)
+
+	res, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	s.NoError(res.Body.Close())
+	s.Contains(string(body), `"username":"*","device":"","description":"HQ","lat":23.7,"lon":90.4,"radius":250`)
+
+	// the device gets them in the response of its ping, again when it resends the ping
+	for i := 0; i < 2; i++ {
+		body = postPing(s, pingReqStr)
+		s.Contains(string(body), `[{"_type":"cmd","action":"setWaypoints","waypoints":{"_type":"waypoints",`+
+			`"waypoints":[{"_type":"waypoint","desc":"HQ","lat":23.7,"lon":90.4,"rad":250,"tst":`)
+	}
+
+	// until its next ping confirms them
+	body = postPing(s, strings.ReplaceAll(pingReqStr, fmt.Sprint(epoch), fmt.Sprint(epoch+1)))
+	s.Equal(`[]`, strings.Trim(string(body), "\n"))
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_DeviceCommands() {
+	client := http.Client{}
+	path := fmt.Sprintf("%s/devices/%s/commands?username=%s", s.apiBaseURL, device, username)
+
+	for caller, code := range map[string]int{"admin": http.StatusCreated, username: http.StatusForbidden} {
+		req, err := http.NewRequestWithContext(context.Background(), echo.POST, path,
+			strings.NewReader(`{"action":"reportLocation"}`))
+		s.NoError(err)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("x-limit-u", caller)
+
+		res, err := client.Do(req)
+		s.NoError(err)
+		s.Equal(code, res.StatusCode, caller)
+		s.NoError(res.Body.Close())
+	}
+
+	// the device gets it in the response of its ping, again when it resends the ping
+	for i := 0; i < 2; i++ {
+		body := postPing(s, pingReqStr)
+		s.JSONEq(`[{"_type":"cmd","action":"reportLocation"}]`, string(body))
+	}
+
+	// its next ping confirms it
+	body := postPing(s, strings.ReplaceAll(pingReqStr, fmt.Sprint(epoch), fmt.Sprint(epoch+1)))
+	s.Equal(`[]`, strings.Trim(string(body), "\n"))
+
+	req, err := http.NewRequestWithContext(context.Background(), echo.GET, path, nil)
+	s.NoError(err)
+	req.Header.Set("x-limit-u", "admin")
+
+	res, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	var listed struct {
+		Data []struct {
+			Action      string `json:"action"`
+			DeliveredAt int64  `json:"delivered_at"`
+		} `json:"data"`
+	}
+	s.NoError(json.NewDecoder(res.Body).Decode(&listed))
+	s.NoError(res.Body.Close())
+	s.Len(listed.Data, 1)
+	s.Equal("reportLocation", listed.Data[0].Action)
+	s.NotZero(listed.Data[0].DeliveredAt)
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_IngestQueue() {
+	queue, err := ingest.NewQueue(locationRepo.NewPgsqlLocationRepository(s.db), config.IngestConfig{
+		Enabled:        true,
+		SpillFile:      filepath.Join(s.T().TempDir(), "spill.ndjson"),
+		DeadLetterFile: filepath.Join(s.T().TempDir(), "failed.ndjson"),
+		FlushInterval:  time.Hour,
+		BatchSize:      10,
+		QueueSize:      10,
+	})
+	s.Require().NoError(err)
+
+	location := &model.Location{
+		Username:  username,
+		Device:    device,
+		CreatedAt: epoch,
+		Lat:       23,
+		Lon:       90,
+		Topic:     "owntracks/dev/phone",
+		InRegions: model.StringList{"home"},
+		Raw:       json.RawMessage(`{"_type":"location"}`),
+	}
+	duplicate := *location
+
+	// stored with COPY into a temporary table moved to locations on close, duplicates are skipped
+	s.NoError(queue.CreateLocation(context.Background(), location))
+	s.NoError(queue.CreateLocation(context.Background(), &duplicate))
+	s.NoError(queue.Close(context.Background()))
+
+	var (
+		count            int
+		topic, inregions string
+		raw              []byte
+	)
+
+	s.NoError(s.db.QueryRow("SELECT COUNT(*) FROM locations").Scan(&count))
+	s.Equal(1, count)
+	s.NoError(s.db.QueryRow("SELECT topic, inregions, raw FROM locations").Scan(&topic, &inregions, &raw))
+	s.Equal("owntracks/dev/phone", topic)
+	s.Equal(`["home"]`, inregions)
+	s.JSONEq(`{"_type":"location"}`, string(raw))
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_DbCopyFromSqlite() {
+	dir := s.T().TempDir()
+
+	srcMigration, err := migrate.New("file://../../infrastructure/db/migrations/sqlite", "sqlite3://"+dir+"/src.db")
+	s.Require().NoError(err)
+	s.Require().NoError(srcMigration.Up())
+
+	src, err := db.Open(db.TypeSqlite, dir+"/src.db")
+	s.Require().NoError(err)
+
+	defer src.Close()
+
+	// sqlite keeps booleans as integers
+	_, err = src.Exec("INSERT INTO places (id, username, name, lat, lon, radius, pinned, updated_at) " +
+		"VALUES (1, 'dev', 'Home', 23.8, 90.4, 50, 1, 100)")
+	s.Require().NoError(err)
+
+	cp, err := db.LoadCheckpoint(dir+"/checkpoint.json", "src", "dst")
+	s.Require().NoError(err)
+	s.Require().NoError(db.Copy(context.Background(), db.Endpoint{Type: db.TypeSqlite, DB: src},
+		db.Endpoint{Type: db.TypePgsql, DB: s.db}, 100, cp))
+
+	var (
+		name   string
+		pinned bool
+	)
+
+	s.NoError(s.db.QueryRow("SELECT name, pinned FROM places WHERE id = 1").Scan(&name, &pinned))
+	s.Equal("Home", name)
+	s.True(pinned)
+}
+
+func (s *e2eTestSuite) Test_EndToEnd_Share() {
+	postPing(s, pingReqStr)
+
+	client := http.Client{}
+
+	req, err := http.NewRequestWithContext(context.Background(), echo.POST, s.apiBaseURL+"/shares",
+		strings.NewReader(`{"expires_in":"1h","track_hours":1}`))
+	s.NoError(err)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("x-limit-u", username)
+
+	res, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+
+	var created struct {
+		Data struct {
+			ID  int64  `json:"id"`
+			URL string `json:"url"`
+		} `json:"data"`
+	}
+	s.NoError(json.NewDecoder(res.Body).Decode(&created))
+	s.NoError(res.Body.Close())
+
+	baseURL := strings.TrimSuffix(s.apiBaseURL, "/api/v1")
+	for _, path := range []string{created.Data.URL, created.Data.URL + "/location"} {
+		res, err = client.Get(baseURL + path) //nolint:noctx
+		s.NoError(err)
+		s.Equal(http.StatusOK, res.StatusCode, path)
+
+		body, err := io.ReadAll(res.Body)
+		s.NoError(err)
+		s.NoError(res.Body.Close())
+
+		if strings.HasSuffix(path, "/location") {
+			s.Contains(string(body), fmt.Sprintf(`"username":"%s"`, username))
+			s.Contains(string(body), fmt.Sprintf(`"track":[{"created_at":%d`, epoch))
+			s.NotContains(string(body), `"ip_address"`)
+			s.NotContains(string(body), `"device"`)
+		}
+	}
+
+	var views int
+	s.NoError(s.db.QueryRow("SELECT views FROM shares").Scan(&views))
+	s.Equal(1, views)
+
+	req, err = http.NewRequestWithContext(context.Background(), echo.DELETE,
+		fmt.Sprintf("%s/shares/%d", s.apiBaseURL, created.Data.ID), nil)
+	s.NoError(err)
+	req.Header.Set("x-limit-u", username)
+
+	res, err = client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.NoError(res.Body.Close())
+
+	res, err = client.Get(baseURL + created.Data.URL + "/location") //nolint:noctx
+	s.NoError(err)
+	s.Equal(http.StatusGone, res.StatusCode)
+	s.NoError(res.Body.Close())
+}
+
 func (s *e2eTestSuite) Test_EndToEnd_Ping_Waypoint() {
 	reqStr := fmt.Sprintf(`{"_type":"waypoint","desc":"home","lat":23.0000000,"lon":90.0000000,"rad":50,
 "topic":"owntracks/dev/phone/waypoints","tst":%d}`, epoch)
@@ -154,6 +799,41 @@ func (s *e2eTestSuite) Test_EndToEnd_Last_Location() {
 	s.Equal("dev-test", resultsMap["wifi_name"])
 }
 
+func (s *e2eTestSuite) Test_EndToEnd_Users() {
+	postPing(s, pingReqStr)
+
+	// an older fix of a second device must not be masked by the phone
+	req, err := http.NewRequestWithContext(context.Background(), echo.POST, s.apiBaseURL+"/ping",
+		strings.NewReader(strings.ReplaceAll(pingReqStr, fmt.Sprint(epoch), fmt.Sprint(epoch-60))))
+	s.NoError(err)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("x-limit-u", username)
+	req.Header.Set("x-limit-d", "tablet")
+	req.Header.Set("X-Real-IP", clientIP)
+
+	client := http.Client{}
+	res, err := client.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.NoError(res.Body.Close())
+
+	for path, expected := range map[string]string{
+		"/users": fmt.Sprintf(`"data":["%s"]`, username),
+		"/users/" + username + "/devices": fmt.Sprintf(`[{"device":"%s","last_seen":%d},{"device":"tablet","last_seen":%d}]`,
+			device, epoch, epoch-60),
+		"/last-locations": `"device":"tablet"`,
+	} {
+		res, err = client.Get(s.apiBaseURL + path) //nolint:noctx
+		s.NoError(err)
+		s.Equal(http.StatusOK, res.StatusCode, path)
+
+		body, err := io.ReadAll(res.Body)
+		s.NoError(err)
+		s.NoError(res.Body.Close())
+		s.Contains(string(body), expected, path)
+	}
+}
+
 func (s *e2eTestSuite) Test_EndToEnd_Last_Location_Not_Found() {
 	req, err := http.NewRequestWithContext(
 		context.Background(),